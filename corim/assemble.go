@@ -0,0 +1,103 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+// AssembleFromFS builds an unsigned CoRIM with the supplied corim-id (see
+// UnsignedCorim.SetID) from the tags stored in the *.cbor files found when
+// walking fsys (in lexical order).  Each file must contain a tagged CoMID
+// (506) or CoSWID (505), which is decoded to check it, and then added as is,
+// without being re-encoded.  Since an unsigned CoRIM has at most one profile,
+// at most one profile can be supplied.  The problems found with each file are
+// reported, joined (see errors.Join), together with the file's path.  The
+// returned CoRIM is validated.
+func AssembleFromFS(fsys fs.FS, id interface{}, profiles []string) (*UnsignedCorim, error) {
+	if len(profiles) > 1 {
+		return nil, fmt.Errorf("at most one profile can be set, got %d", len(profiles))
+	}
+
+	ret := NewUnsignedCorim()
+
+	if ret.SetID(id) == nil {
+		return nil, fmt.Errorf("invalid corim-id %v", id)
+	}
+
+	for _, p := range profiles {
+		if err := ret.SetProfileErr(p); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || path.Ext(p) != ".cbor" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		if err := ret.addTagFromFile(data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := ret.Valid(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (o *UnsignedCorim) addTagFromFile(data []byte) error {
+	num, payload, err := Tag(data).split()
+	if err != nil {
+		return err
+	}
+
+	switch num {
+	case ComidTagNumber:
+		var c comid.Comid
+		if err := c.FromCBOR(payload); err != nil {
+			return fmt.Errorf("decoding CoMID: %w", err)
+		}
+		if err := c.Valid(); err != nil {
+			return fmt.Errorf("invalid CoMID: %w", err)
+		}
+	case CoswidTagNumber:
+		var c swid.SoftwareIdentity
+		if err := c.FromCBOR(payload); err != nil {
+			return fmt.Errorf("decoding CoSWID: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported tag number %d", num)
+	}
+
+	return o.AddRawTagErr(num, payload)
+}
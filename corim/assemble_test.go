@@ -0,0 +1,60 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestAssembleFromFS_ok(t *testing.T) {
+	psa := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	psaCBOR, err := psa.ToCBOR()
+	require.NoError(t, err)
+
+	keys := comidFromJSON(t, comid.PSAKeysJSONTemplate)
+	keysCBOR, err := keys.ToCBOR()
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{
+		"b/keys.cbor":   {Data: append(TagHeader(ComidTagNumber), keysCBOR...)},
+		"a/refval.cbor": {Data: append(TagHeader(ComidTagNumber), psaCBOR...)},
+		"README.md":     {Data: []byte("not a tag")},
+	}
+
+	c, err := AssembleFromFS(fsys, "assembled", []string{"https://example.com/profile"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "assembled", c.GetID())
+	assert.True(t, c.HasProfile("https://example.com/profile"))
+	require.Len(t, c.Tags, 2)
+	assert.Equal(t, fsys["a/refval.cbor"].Data, []byte(c.Tags[0]))
+	assert.Equal(t, fsys["b/keys.cbor"].Data, []byte(c.Tags[1]))
+}
+
+func TestAssembleFromFS_NOK(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bad.cbor":     {Data: []byte{0xff}},
+		"cots.cbor":    {Data: append(TagHeader(CotsTagNumber), 0xa0)},
+		"dir/ok.txt":   {Data: []byte{0xff}},
+		"invalid.cbor": {Data: append(TagHeader(ComidTagNumber), 0xa0)},
+	}
+
+	_, err := AssembleFromFS(fsys, "assembled", nil)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "bad.cbor: decoding tag: ")
+	assert.ErrorContains(t, err, "cots.cbor: unsupported tag number 507")
+	assert.ErrorContains(t, err, "invalid.cbor: decoding CoMID: ")
+	assert.NotContains(t, err.Error(), "ok.txt")
+
+	_, err = AssembleFromFS(fstest.MapFS{}, "assembled", nil)
+	assert.EqualError(t, err, "validation failed: tags validation failed: no tags")
+
+	_, err = AssembleFromFS(fstest.MapFS{}, "assembled", []string{"1.2.3", "1.2.4"})
+	assert.EqualError(t, err, "at most one profile can be set, got 2")
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/corim/cots"
+	"github.com/veraison/swid"
+)
+
+// CorimBuilder accumulates the operations needed to construct an unsigned
+// CoRIM.  The operations are only applied, and the result validated, when
+// Build or BuildAll is called, so that an invalid UnsignedCorim is never
+// returned to the caller.
+type CorimBuilder struct {
+	ops []func(*UnsignedCorim) error
+}
+
+// NewCorimBuilder instantiates an empty CorimBuilder
+func NewCorimBuilder() *CorimBuilder {
+	return &CorimBuilder{}
+}
+
+func (o *CorimBuilder) add(op func(*UnsignedCorim) error) *CorimBuilder {
+	if o != nil {
+		o.ops = append(o.ops, op)
+	}
+	return o
+}
+
+// SetID sets the corim-id (see UnsignedCorim.SetID)
+func (o *CorimBuilder) SetID(v interface{}) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		if c.SetID(v) == nil {
+			return fmt.Errorf("invalid corim-id %v", v)
+		}
+		return nil
+	})
+}
+
+// AddComid appends the supplied CoMID to the tags
+func (o *CorimBuilder) AddComid(v comid.Comid) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		return c.AddComidErr(v)
+	})
+}
+
+// AddCoswid appends the supplied CoSWID to the tags
+func (o *CorimBuilder) AddCoswid(v swid.SoftwareIdentity) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		return c.AddCoswidErr(v)
+	})
+}
+
+// AddCots appends the supplied CoTS to the tags
+func (o *CorimBuilder) AddCots(v cots.ConciseTaStore) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		return c.AddCotsErr(v)
+	})
+}
+
+// AddRawTag appends the supplied tag to the tags (see UnsignedCorim.AddRawTag)
+func (o *CorimBuilder) AddRawTag(tagNumber uint64, payload []byte) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		return c.AddRawTagErr(tagNumber, payload)
+	})
+}
+
+// AddDependentRim appends a corim-locator-map to the dependent RIMs
+func (o *CorimBuilder) AddDependentRim(href string, thumbprint *swid.HashEntry) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		c.AddDependentRim(href, thumbprint)
+		return nil
+	})
+}
+
+// SetProfile sets the profile (see UnsignedCorim.SetProfile)
+func (o *CorimBuilder) SetProfile(urlOrOID string) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		return c.SetProfileErr(urlOrOID)
+	})
+}
+
+// SetRimValidity sets the validity period of the CoRIM
+func (o *CorimBuilder) SetRimValidity(notAfter time.Time, notBefore *time.Time) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		if c.SetRimValidity(notAfter, notBefore) == nil {
+			return errors.New("invalid RIM validity")
+		}
+		return nil
+	})
+}
+
+// AddEntity adds an organizational entity (see UnsignedCorim.AddEntity)
+func (o *CorimBuilder) AddEntity(name string, regID *string, roles ...Role) *CorimBuilder {
+	return o.add(func(c *UnsignedCorim) error {
+		if c.AddEntity(name, regID, roles...) == nil {
+			return fmt.Errorf("invalid entity %q", name)
+		}
+		return nil
+	})
+}
+
+// Build applies the accumulated operations to a new UnsignedCorim and
+// validates the result.  The first error encountered is returned.
+func (o *CorimBuilder) Build() (*UnsignedCorim, error) {
+	if o == nil {
+		return nil, errors.New("nil CorimBuilder")
+	}
+
+	c := NewUnsignedCorim()
+
+	for i, op := range o.ops {
+		if err := op(c); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	if err := c.Valid(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return c, nil
+}
+
+// BuildAll is like Build, but rather than stopping at the first error, it
+// collects all the errors from the accumulated operations and the validation
+// of the result, and returns them joined (see errors.Join).
+func (o *CorimBuilder) BuildAll() (*UnsignedCorim, error) {
+	if o == nil {
+		return nil, errors.New("nil CorimBuilder")
+	}
+
+	var errs []error
+
+	c := NewUnsignedCorim()
+
+	for i, op := range o.ops {
+		if err := op(c); err != nil {
+			errs = append(errs, fmt.Errorf("operation %d: %w", i, err))
+		}
+	}
+
+	for _, err := range c.validationErrors(ValidationOptions{}) {
+		errs = append(errs, fmt.Errorf("validation failed: %w", err))
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return c, nil
+}
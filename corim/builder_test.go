@@ -0,0 +1,69 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestCorimBuilder_Build_ok(t *testing.T) {
+	c, err := NewCorimBuilder().
+		SetID("test corim id").
+		AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate)).
+		AddDependentRim("https://parent.example/rims.cbor", nil).
+		SetProfile("https://arm.com/psa/iot/1").
+		AddEntity("ACME Ltd.", nil, RoleManifestCreator).
+		Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, "test corim id", c.GetID())
+	assert.Len(t, c.Tags, 1)
+	assert.NoError(t, c.Valid())
+}
+
+func TestCorimBuilder_Build_first_error(t *testing.T) {
+	_, err := NewCorimBuilder().
+		SetID("test corim id").
+		SetProfile("%%%").
+		AddComid(comid.Comid{}).
+		Build()
+
+	assert.ErrorContains(t, err, "operation 1: invalid profile")
+
+	_, err = NewCorimBuilder().
+		AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate)).
+		Build()
+
+	assert.EqualError(t, err, "validation failed: empty id")
+}
+
+func TestCorimBuilder_BuildAll(t *testing.T) {
+	c, err := NewCorimBuilder().
+		SetProfile("%%%").
+		AddComid(comid.Comid{}).
+		AddDependentRim("", nil).
+		BuildAll()
+
+	assert.Nil(t, c)
+	require.Error(t, err)
+
+	msg := err.Error()
+	assert.Contains(t, msg, "operation 0: invalid profile")
+	assert.Contains(t, msg, "operation 1: invalid CoMID")
+	assert.Contains(t, msg, "validation failed: empty id")
+	assert.Contains(t, msg, "validation failed: tags validation failed: no tags")
+	assert.Contains(t, msg, "validation failed: dependent RIM validation failed at pos 0: empty href")
+
+	c, err = NewCorimBuilder().
+		SetID("test corim id").
+		AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate)).
+		BuildAll()
+
+	require.NoError(t, err)
+	assert.Len(t, c.Tags, 1)
+}
@@ -4,89 +4,271 @@
 package corim
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 	"reflect"
 
 	cbor "github.com/fxamacker/cbor/v2"
 	"github.com/veraison/corim/comid"
+	"github.com/veraison/corim/cots"
 )
 
 var (
-	em, emError = initCBOREncMode()
-	dm, dmError = initCBORDecMode()
+	deterministicEncoding bool
+	customTagSet          bool
+
+	modes, modesError = newCBORModes(nil)
+
+	em = modes.encMode(deterministicEncoding)
+	dm = modes.dec
+)
+
+// CBOR tag numbers of the items that can be found in, or wrap, an
+// unsigned-corim-map
+const (
+	UnsignedCorimTagNumber uint64 = 501
+	CoswidTagNumber        uint64 = 505
+	ComidTagNumber         uint64 = 506
+	CotsTagNumber          uint64 = 507
 )
 
 var (
-	UnsignedCorimTag = []byte{0xd9, 0x01, 0xf5} // 501()
-	CoswidTag        = []byte{0xd9, 0x01, 0xf9} // 505()
-	ComidTag         = []byte{0xd9, 0x01, 0xfa} // 506()
+	UnsignedCorimTag = TagHeader(UnsignedCorimTagNumber) // 501()
+	CoswidTag        = TagHeader(CoswidTagNumber)        // 505()
+	ComidTag         = TagHeader(ComidTagNumber)         // 506()
 
 	corimTagsMap = map[uint64]interface{}{
-		32:  comid.TaggedURI(""),
-		501: TaggedUnsignedCorim(UnsignedCorim{}),
+		32:                     comid.TaggedURI(""),
+		UnsignedCorimTagNumber: TaggedUnsignedCorim(UnsignedCorim{}),
 	}
 )
 
-func corimTags() cbor.TagSet {
-	opts := cbor.TagOptions{
-		EncTag: cbor.EncTagRequired,
-		DecTag: cbor.DecTagRequired,
+// TagHeader returns the (shortest) CBOR encoding of the head of a tag with the
+// supplied number, i.e., the bytes that precede the tag content
+func TagHeader(number uint64) []byte {
+	const majorTypeTag = 0xc0
+
+	switch {
+	case number < 24:
+		return []byte{majorTypeTag | byte(number)}
+	case number <= math.MaxUint8:
+		return []byte{majorTypeTag | 24, byte(number)}
+	case number <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16([]byte{majorTypeTag | 25}, uint16(number))
+	case number <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32([]byte{majorTypeTag | 26}, uint32(number))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{majorTypeTag | 27}, number)
+	}
+}
+
+// cborModes are the encoding and decoding modes used for CoRIMs, all built from
+// the same tag set
+type cborModes struct {
+	enc    cbor.EncMode
+	detEnc cbor.EncMode // Core Deterministic Encoding
+	dec    cbor.DecMode
+	// wideDec is like dec, but with the widest limits allowed by the cbor
+	// package, for input that has already been checked against the limits
+	// requested by the caller (see FromCBORWithOptions)
+	wideDec cbor.DecMode
+}
+
+// encMode returns the encoding mode that, if deterministic is set, uses Core
+// Deterministic Encoding (see SetDeterministicEncoding)
+func (o cborModes) encMode(deterministic bool) cbor.EncMode {
+	if deterministic {
+		return o.detEnc
 	}
+	return o.enc
+}
 
-	tags := cbor.NewTagSet()
+// newCBORModes builds the CoRIM encoding and decoding modes from the CoRIM tags
+// and, if ts is not nil, the tags in ts.  The cbor package provides no way of
+// copying a TagSet, but the modes take a copy of the tags they are created
+// with, so the CoRIM tags are only added to ts while the modes are built.
+func newCBORModes(ts cbor.TagSet) (cborModes, error) {
+	var m cborModes
 
-	for tag, typ := range corimTagsMap {
-		if err := tags.Add(opts, reflect.TypeOf(typ), tag); err != nil {
-			panic(err)
-		}
+	tags := ts
+	if tags == nil {
+		tags = cbor.NewTagSet()
 	}
 
-	return tags
-}
+	added, err := addCorimTags(tags)
+
+	defer func() {
+		for _, typ := range added {
+			tags.Remove(typ)
+		}
+	}()
+
+	if err != nil {
+		return m, fmt.Errorf("adding CoRIM tags: %w", err)
+	}
 
-func initCBOREncMode() (en cbor.EncMode, err error) {
 	encOpt := cbor.EncOptions{
 		IndefLength: cbor.IndefLengthForbidden,
 		TimeTag:     cbor.EncTagRequired,
 	}
-	return encOpt.EncModeWithTags(corimTags())
-}
 
-func initCBORDecMode() (dm cbor.DecMode, err error) {
+	if m.enc, err = encOpt.EncModeWithTags(tags); err != nil {
+		return m, err
+	}
+
+	encOpt.Sort = cbor.SortCoreDeterministic
+	encOpt.ShortestFloat = cbor.ShortestFloat16
+
+	if m.detEnc, err = encOpt.EncModeWithTags(tags); err != nil {
+		return m, err
+	}
+
 	decOpt := cbor.DecOptions{
 		IndefLength: cbor.IndefLengthForbidden,
 		TimeTag:     cbor.DecTagRequired,
 	}
-	return decOpt.DecModeWithTags(corimTags())
+
+	if m.dec, err = decOpt.DecModeWithTags(tags); err != nil {
+		return m, err
+	}
+
+	decOpt.MaxNestedLevels = maxNestedLevelsLimit
+	decOpt.MaxArrayElements = maxArrayElementsLimit
+	decOpt.MaxMapPairs = maxArrayElementsLimit
+
+	if m.wideDec, err = decOpt.DecModeWithTags(tags); err != nil {
+		return m, err
+	}
+
+	return m, nil
 }
 
-func registerCORIMTag(tag uint64, t interface{}) error {
-	if _, exists := corimTagsMap[tag]; exists {
-		return fmt.Errorf("tag %d is already registered", tag)
+// the widest decoding limits allowed by the cbor package
+const (
+	maxNestedLevelsLimit  = 65535
+	maxArrayElementsLimit = 2147483647
+)
+
+// newLimitsDecMode returns a decoding mode that enforces the supplied limits
+// (zero selects the cbor package default).  It has no tags registered, and is
+// only meant to check input with Wellformed before it is decoded.
+func newLimitsDecMode(maxNestedLevels, maxArrayElements int) (cbor.DecMode, error) {
+	decOpt := cbor.DecOptions{
+		IndefLength:      cbor.IndefLengthAllowed,
+		MaxNestedLevels:  maxNestedLevels,
+		MaxArrayElements: maxArrayElements,
+		MaxMapPairs:      maxArrayElements,
 	}
 
-	corimTagsMap[tag] = t
+	return decOpt.DecMode()
+}
+
+// addCorimTags adds the CoRIM tags to the supplied tag set, returning the types
+// that have been added, including on failure
+func addCorimTags(tags cbor.TagSet) ([]reflect.Type, error) {
+	added := make([]reflect.Type, 0, len(corimTagsMap))
+
+	for tag, typ := range corimTagsMap {
+		if err := addCorimTag(tags, tag, typ); err != nil {
+			return added, err
+		}
+		added = append(added, reflect.TypeOf(typ))
+	}
+
+	return added, nil
+}
+
+func addCorimTag(tags cbor.TagSet, tag uint64, typ interface{}) error {
+	opts := cbor.TagOptions{
+		EncTag: cbor.EncTagRequired,
+		DecTag: cbor.DecTagRequired,
+	}
 
-	var err error
+	return tags.Add(opts, reflect.TypeOf(typ), tag)
+}
 
-	em, err = initCBOREncMode()
+// SetTagSet makes the CBOR encoding and decoding of CoRIMs (ToCBOR, FromCBOR,
+// etc.) use the CoRIM tags together with the tags in the supplied set, so that
+// additional, e.g. profile-specific, tags are honoured.  ts must not contain any
+// of the CoRIM tags.  The encoding and decoding modes are built from ts before
+// SetTagSet returns, and ts is not retained: later changes to ts take effect
+// only if SetTagSet is called again.  Since the CoRIM tags are temporarily added
+// to ts while the modes are built, ts must not be used concurrently with
+// SetTagSet.  Passing nil restores the default tag set.  On failure, the
+// previous setting is kept.
+//
+// Note that the content of CoMID and CoTS tags is encoded and decoded by the
+// comid and cots packages, which are not affected by this setting.
+func SetTagSet(ts cbor.TagSet) error {
+	m, err := newCBORModes(ts)
 	if err != nil {
 		return err
 	}
 
-	dm, err = initCBORDecMode()
+	setCBORModes(m)
+	customTagSet = ts != nil
+
+	return nil
+}
+
+func setCBORModes(m cborModes) {
+	modes = m
+	em = modes.encMode(deterministicEncoding)
+	dm = modes.dec
+}
+
+// SetDeterministicEncoding toggles the use of Core Deterministic Encoding (see
+// RFC 8949, §4.2.1) when serializing CoRIMs to CBOR.  When enabled, map keys are
+// sorted in bytewise lexicographic order of their encoding (Sort:
+// cbor.SortCoreDeterministic) and floating point values are encoded using the
+// shortest form that preserves their value (ShortestFloat:
+// cbor.ShortestFloat16).  Integers and lengths always use their shortest form,
+// and indefinite-length items are always forbidden.
+//
+// The setting is propagated to the cots package, so that CoTS tags added to
+// the CoRIM are encoded in the same way.  CoMID tags are always encoded
+// deterministically.  CoSWID tags are encoded by the swid package, which is not
+// affected by this setting.
+//
+// On failure, the previous setting is kept, in both packages.
+func SetDeterministicEncoding(v bool) error {
+	if err := cots.SetDeterministicEncoding(v); err != nil {
+		return err
+	}
+
+	deterministicEncoding = v
+	em = modes.encMode(v)
+
+	return nil
+}
+
+// registerCORIMTag adds a CBOR tag to the CoRIM tags.  Since the tags supplied
+// to SetTagSet are not retained, this fails while a custom tag set is in use.
+func registerCORIMTag(tag uint64, t interface{}) error {
+	if _, exists := corimTagsMap[tag]; exists {
+		return fmt.Errorf("tag %d is already registered", tag)
+	}
+
+	if customTagSet {
+		return fmt.Errorf("cannot register tag %d while a custom tag set is in use", tag)
+	}
+
+	corimTagsMap[tag] = t
+
+	m, err := newCBORModes(nil)
 	if err != nil {
+		delete(corimTagsMap, tag)
 		return err
 	}
 
+	setCBORModes(m)
+
 	return nil
 }
 
 func init() {
-	if emError != nil {
-		panic(emError)
-	}
-	if dmError != nil {
-		panic(dmError)
+	if modesError != nil {
+		panic(modesError)
 	}
 }
@@ -0,0 +1,169 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"reflect"
+	"testing"
+
+	cbor "github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/corim/cots"
+)
+
+func TestSetDeterministicEncoding(t *testing.T) {
+	require.NoError(t, SetDeterministicEncoding(true))
+	defer func() { require.NoError(t, SetDeterministicEncoding(false)) }()
+
+	tv := map[string]float64{"bb": 1.0, "a": 2.0, "c": 3.0}
+
+	// {"a": 2.0, "c": 3.0, "bb": 1.0} with keys sorted by their encoding and
+	// values in half-precision
+	expected := comid.MustHexDecode(t, "a36161f940006163f94200626262f93c00")
+
+	for i := 0; i < 10; i++ {
+		actual, err := em.Marshal(tv)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	var tvCorim UnsignedCorim
+	require.NoError(t, tvCorim.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	actual, err := tvCorim.ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, testGoodUnsignedCorimCBOR, actual)
+}
+
+func TestSetDeterministicEncoding_extension_keys(t *testing.T) {
+	require.NoError(t, SetDeterministicEncoding(true))
+	defer func() { require.NoError(t, SetDeterministicEncoding(false)) }()
+
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(testGoodUnsignedCorimCBOR))
+	require.NotNil(t, tv.SetExtension(-1, "a"))
+	require.NotNil(t, tv.SetExtension(-10, "b"))
+	require.NotNil(t, tv.SetExtension(6, "c"))
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	canonical, err := canonicalCBOR(data)
+	require.NoError(t, err)
+	assert.Equal(t, canonical, data)
+
+	loc := Locator{Href: comid.TaggedURI("https://example.com/corim")}
+	require.NotNil(t, loc.SetLocatorHint(-1, "a"))
+	require.NotNil(t, loc.SetLocatorHint(-10, "b"))
+	require.NotNil(t, loc.SetLocatorHint(6, "c"))
+
+	data, err = em.Marshal(loc)
+	require.NoError(t, err)
+
+	canonical, err = canonicalCBOR(data)
+	require.NoError(t, err)
+	assert.Equal(t, canonical, data)
+}
+
+func TestTagHeader(t *testing.T) {
+	tvs := []struct {
+		number   uint64
+		expected []byte
+	}{
+		{1, []byte{0xc1}},
+		{32, []byte{0xd8, 0x20}},
+		{UnsignedCorimTagNumber, []byte{0xd9, 0x01, 0xf5}},
+		{CoswidTagNumber, []byte{0xd9, 0x01, 0xf9}},
+		{ComidTagNumber, []byte{0xd9, 0x01, 0xfa}},
+		{CotsTagNumber, []byte{0xd9, 0x01, 0xfb}},
+		{0x63740212, []byte{0xda, 0x63, 0x74, 0x02, 0x12}},
+		{1 << 32, []byte{0xdb, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tv := range tvs {
+		assert.Equal(t, tv.expected, TagHeader(tv.number))
+	}
+
+	assert.Equal(t, ComidTag, TagHeader(ComidTagNumber))
+	assert.Equal(t, cots.CotsTag, TagHeader(CotsTagNumber))
+}
+
+type testProfileTagged string
+
+func TestSetTagSet(t *testing.T) {
+	ts := cbor.NewTagSet()
+	require.NoError(t, ts.Add(
+		cbor.TagOptions{EncTag: cbor.EncTagRequired, DecTag: cbor.DecTagRequired},
+		reflect.TypeOf(testProfileTagged("")), 60000))
+
+	require.NoError(t, SetTagSet(ts))
+	defer func() { require.NoError(t, SetTagSet(nil)) }()
+
+	data, err := em.Marshal(testProfileTagged("x"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd9, 0xea, 0x60, 0x61, 'x'}, data)
+
+	var v testProfileTagged
+	assert.Error(t, dm.Unmarshal([]byte{0x61, 'x'}, &v), "tag is required")
+	require.NoError(t, dm.Unmarshal(data, &v))
+	assert.Equal(t, testProfileTagged("x"), v)
+
+	// the CoRIM tags are still honoured
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	data, err = tv.ToTaggedCBOR()
+	require.NoError(t, err)
+	require.NoError(t, tv.FromTaggedCBOR(data))
+
+	// ts is left alone, so it can be set again
+	require.NoError(t, SetTagSet(ts))
+	require.NoError(t, ts.Add(
+		cbor.TagOptions{EncTag: cbor.EncTagRequired, DecTag: cbor.DecTagRequired},
+		reflect.TypeOf(comid.TaggedURI("")), 60001))
+	ts.Remove(reflect.TypeOf(comid.TaggedURI("")))
+
+	// CoRIM tags cannot be redefined, and the previous setting is kept
+	clash := cbor.NewTagSet()
+	require.NoError(t, clash.Add(
+		cbor.TagOptions{EncTag: cbor.EncTagRequired, DecTag: cbor.DecTagRequired},
+		reflect.TypeOf(comid.TaggedURI("")), 60001))
+	assert.ErrorContains(t, SetTagSet(clash), "adding CoRIM tags: ")
+
+	data, err = em.Marshal(testProfileTagged("x"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd9, 0xea, 0x60, 0x61, 'x'}, data)
+
+	data, err = tv.ToTaggedCBOR()
+	require.NoError(t, err)
+	require.NoError(t, tv.FromTaggedCBOR(data))
+
+	// the modes are built once: later changes to ts have no effect, and
+	// CoRIM tags cannot be registered until the default set is restored
+	require.NoError(t, SetTagSet(ts))
+	ts.Remove(reflect.TypeOf(testProfileTagged("")))
+	require.NoError(t, ts.Add(
+		cbor.TagOptions{EncTag: cbor.EncTagRequired, DecTag: cbor.DecTagRequired},
+		reflect.TypeOf(comid.TaggedURI("")), 60001))
+
+	require.NoError(t, SetDeterministicEncoding(true))
+	data, err = em.Marshal(testProfileTagged("x"))
+	require.NoError(t, SetDeterministicEncoding(false))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd9, 0xea, 0x60, 0x61, 'x'}, data)
+
+	data, err = tv.ToTaggedCBOR()
+	require.NoError(t, err)
+	require.NoError(t, tv.FromTaggedCBOR(data))
+
+	assert.EqualError(t, registerCORIMTag(60002, testProfileTagged("")),
+		"cannot register tag 60002 while a custom tag set is in use")
+
+	// back to the default
+	require.NoError(t, SetTagSet(nil))
+
+	data, err = em.Marshal(testProfileTagged("x"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x61, 'x'}, data)
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+// CoswidType is the type of a CoSWID, as determined by its corpus, patch and
+// supplemental flags
+type CoswidType string
+
+const (
+	// CoswidPrimary is the type of a CoSWID with none of the corpus, patch
+	// and supplemental flags set
+	CoswidPrimary CoswidType = "primary"
+	// CoswidCorpus is the type of a CoSWID with the corpus flag set
+	CoswidCorpus CoswidType = "corpus"
+	// CoswidPatch is the type of a CoSWID with the patch flag set
+	CoswidPatch CoswidType = "patch"
+	// CoswidSupplemental is the type of a CoSWID with the supplemental flag
+	// set
+	CoswidSupplemental CoswidType = "supplemental"
+)
+
+// CoswidSummary decodes the CoSWIDs found in the tags array of the
+// unsigned-corim-map and returns how many there are of each type.  A CoSWID
+// with more than one of the corpus, patch and supplemental flags set is
+// counted once for each of them.  Types with no CoSWIDs are omitted.  An error
+// is returned if any of the CoSWIDs cannot be decoded.
+func (o UnsignedCorim) CoswidSummary() (map[CoswidType]int, error) {
+	coswids, err := o.GetCoswids()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make(map[CoswidType]int)
+
+	for _, c := range coswids {
+		if !c.Corpus && !c.Patch && !c.Supplemental {
+			summary[CoswidPrimary]++
+			continue
+		}
+
+		if c.Corpus {
+			summary[CoswidCorpus]++
+		}
+
+		if c.Patch {
+			summary[CoswidPatch]++
+		}
+
+		if c.Supplemental {
+			summary[CoswidSupplemental]++
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+func TestUnsignedCorim_CoswidSummary(t *testing.T) {
+	var c swid.SoftwareIdentity
+	err := c.FromXML([]byte(`<SoftwareIdentity xmlns="http://standards.iso.org/iso/19770/-2/2015/schema.xsd" tagId="com.acme.rrd2013-ce-sp1-v4-1-5-0" name="ACME Roadrunner Detector 2013 Coyote Edition SP1" version="4.1.5"><Entity name="The ACME Corporation" regid="acme.com" role="tagCreator softwareCreator"></Entity></SoftwareIdentity>`))
+	require.NoError(t, err)
+
+	primary := c
+
+	corpusPatch := c
+	corpusPatch.Corpus = true
+	corpusPatch.Patch = true
+
+	supplemental := c
+	supplemental.Supplemental = true
+
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddCoswid(primary).
+		AddCoswid(corpusPatch).
+		AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate)).
+		AddCoswid(supplemental).
+		AddCoswid(primary)
+	require.NotNil(t, tv)
+
+	summary, err := tv.CoswidSummary()
+	require.NoError(t, err)
+	assert.Equal(t, map[CoswidType]int{
+		CoswidPrimary:      2,
+		CoswidCorpus:       1,
+		CoswidPatch:        1,
+		CoswidSupplemental: 1,
+	}, summary)
+
+	// no CoSWIDs
+	summary, err = unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).CoswidSummary()
+	require.NoError(t, err)
+	assert.Empty(t, summary)
+
+	tv.Tags = append(tv.Tags, append(TagHeader(CoswidTagNumber), 0x01))
+	_, err = tv.CoswidSummary()
+	assert.ErrorContains(t, err, "decoding CoSWID at pos 5")
+}
@@ -0,0 +1,353 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/eat"
+	"github.com/veraison/swid"
+)
+
+// DiffKind describes the nature of a difference between two UnsignedCorims
+type DiffKind string
+
+const (
+	DiffAdded    DiffKind = "added"
+	DiffRemoved  DiffKind = "removed"
+	DiffModified DiffKind = "modified"
+)
+
+// CorimDiff stores the differences between two UnsignedCorims, as computed by
+// Diff.  It can be serialized to JSON for rendering.
+type CorimDiff struct {
+	Profile       *ProfileDiff  `json:"profile,omitempty"`
+	DependentRims []LocatorDiff `json:"dependent-rims,omitempty"`
+	Tags          []TagDiff     `json:"tags,omitempty"`
+}
+
+// IsEmpty returns true if no differences have been found
+func (o CorimDiff) IsEmpty() bool {
+	return o.Profile == nil && len(o.DependentRims) == 0 && len(o.Tags) == 0
+}
+
+// ProfileDiff records a change of profile.  An empty string means that the
+// profile is absent.
+type ProfileDiff struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// LocatorDiff records a dependent RIM that has been added or removed, or whose
+// thumbprint has been modified
+type LocatorDiff struct {
+	Kind DiffKind `json:"kind"`
+	Href string   `json:"href"`
+}
+
+// TagDiff records a CoMID or CoSWID that has been added, removed or modified.
+// For modified CoMIDs, the measurement-level differences in reference and
+// endorsed values are reported in Measurements.
+type TagDiff struct {
+	Kind         DiffKind          `json:"kind"`
+	Type         string            `json:"type"`
+	TagID        string            `json:"tag-id"`
+	Measurements []MeasurementDiff `json:"measurements,omitempty"`
+}
+
+// MeasurementDiff records a measurement that has been added to, removed from or
+// modified within a reference or endorsed value triple.  Measurements are
+// matched by environment and measurement key, or by position if they have no
+// key.
+type MeasurementDiff struct {
+	Kind        DiffKind        `json:"kind"`
+	Triple      string          `json:"triple"`
+	Environment json.RawMessage `json:"environment"`
+	Key         json.RawMessage `json:"key,omitempty"`
+	From        json.RawMessage `json:"from,omitempty"`
+	To          json.RawMessage `json:"to,omitempty"`
+}
+
+// Diff computes the differences between a and b, i.e., what needs to change in
+// a to obtain b.  CoMIDs and CoSWIDs are matched by their tag-id.  Tags of other
+// types are not compared.
+func Diff(a, b UnsignedCorim) (*CorimDiff, error) {
+	var ret CorimDiff
+
+	fromProfile, err := profileString(a.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("first CoRIM profile: %w", err)
+	}
+
+	toProfile, err := profileString(b.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("second CoRIM profile: %w", err)
+	}
+
+	if fromProfile != toProfile {
+		ret.Profile = &ProfileDiff{From: fromProfile, To: toProfile}
+	}
+
+	ret.DependentRims = diffLocators(a.DependentRims, b.DependentRims)
+
+	fromTags, err := diffableTags(a)
+	if err != nil {
+		return nil, fmt.Errorf("first CoRIM: %w", err)
+	}
+
+	toTags, err := diffableTags(b)
+	if err != nil {
+		return nil, fmt.Errorf("second CoRIM: %w", err)
+	}
+
+	for _, k := range fromTags.order {
+		from := fromTags.entries[k]
+
+		to, ok := toTags.entries[k]
+		if !ok {
+			ret.Tags = append(ret.Tags, TagDiff{Kind: DiffRemoved, Type: from.typ, TagID: from.id})
+			continue
+		}
+
+		if bytes.Equal(from.raw, to.raw) {
+			continue
+		}
+
+		td := TagDiff{Kind: DiffModified, Type: from.typ, TagID: from.id}
+
+		if from.comid != nil && to.comid != nil {
+			if td.Measurements, err = diffComidMeasurements(*from.comid, *to.comid); err != nil {
+				return nil, fmt.Errorf("CoMID %s: %w", from.id, err)
+			}
+		}
+
+		ret.Tags = append(ret.Tags, td)
+	}
+
+	for _, k := range toTags.order {
+		if _, ok := fromTags.entries[k]; !ok {
+			to := toTags.entries[k]
+			ret.Tags = append(ret.Tags, TagDiff{Kind: DiffAdded, Type: to.typ, TagID: to.id})
+		}
+	}
+
+	return &ret, nil
+}
+
+func profileString(p *eat.Profile) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+
+	return NormalizeProfile(*p)
+}
+
+func diffLocators(a, b *[]Locator) []LocatorDiff {
+	var from, to []Locator
+
+	if a != nil {
+		from = *a
+	}
+
+	if b != nil {
+		to = *b
+	}
+
+	find := func(ls []Locator, href comid.TaggedURI) *Locator {
+		for i := range ls {
+			if ls[i].Href == href {
+				return &ls[i]
+			}
+		}
+		return nil
+	}
+
+	var ret []LocatorDiff
+
+	for _, l := range from {
+		other := find(to, l.Href)
+		if other == nil {
+			ret = append(ret, LocatorDiff{Kind: DiffRemoved, Href: string(l.Href)})
+		} else if !thumbprintsEqual(l.Thumbprints(), other.Thumbprints()) {
+			ret = append(ret, LocatorDiff{Kind: DiffModified, Href: string(l.Href)})
+		}
+	}
+
+	for _, l := range to {
+		if find(from, l.Href) == nil {
+			ret = append(ret, LocatorDiff{Kind: DiffAdded, Href: string(l.Href)})
+		}
+	}
+
+	return ret
+}
+
+func thumbprintsEqual(a, b []swid.HashEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !thumbprintMatches(&a[i], b[i].HashAlgID, b[i].HashValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type diffableTag struct {
+	typ   string
+	id    string
+	raw   Tag
+	comid *comid.Comid
+}
+
+type diffableTagSet struct {
+	order   []string
+	entries map[string]diffableTag
+}
+
+func diffableTags(o UnsignedCorim) (*diffableTagSet, error) {
+	ret := diffableTagSet{entries: make(map[string]diffableTag)}
+
+	for i, t := range o.Tags {
+		num, content, err := t.split()
+		if err != nil {
+			return nil, fmt.Errorf("tag at pos %d: %w", i, err)
+		}
+
+		var entry diffableTag
+
+		switch num {
+		case ComidTagNumber:
+			var c comid.Comid
+			if err := c.FromCBOR(content); err != nil {
+				return nil, fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+			}
+			entry = diffableTag{typ: "comid", id: c.TagIdentity.TagID.String(), raw: t, comid: &c}
+		case CoswidTagNumber:
+			var c swid.SoftwareIdentity
+			if err := c.FromCBOR(content); err != nil {
+				return nil, fmt.Errorf("decoding CoSWID at pos %d: %w", i, err)
+			}
+			entry = diffableTag{typ: "coswid", id: c.TagID.String(), raw: t}
+		default:
+			continue
+		}
+
+		k := entry.typ + "/" + entry.id
+		if _, ok := ret.entries[k]; ok {
+			return nil, fmt.Errorf("duplicate %s tag-id %q at pos %d", entry.typ, entry.id, i)
+		}
+
+		ret.order = append(ret.order, k)
+		ret.entries[k] = entry
+	}
+
+	return &ret, nil
+}
+
+type diffableMeasurement struct {
+	env json.RawMessage
+	key json.RawMessage
+	val json.RawMessage
+}
+
+func diffComidMeasurements(a, b comid.Comid) ([]MeasurementDiff, error) {
+	var ret []MeasurementDiff
+
+	for _, triple := range []struct {
+		name     string
+		from, to *comid.ValueTriples
+	}{
+		{"reference-values", a.Triples.ReferenceValues, b.Triples.ReferenceValues},
+		{"endorsed-values", a.Triples.EndorsedValues, b.Triples.EndorsedValues},
+	} {
+		fromOrder, from, err := diffableMeasurements(triple.from)
+		if err != nil {
+			return nil, err
+		}
+
+		toOrder, to, err := diffableMeasurements(triple.to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, k := range fromOrder {
+			f := from[k]
+
+			t, ok := to[k]
+			if !ok {
+				ret = append(ret, MeasurementDiff{
+					Kind: DiffRemoved, Triple: triple.name,
+					Environment: f.env, Key: f.key, From: f.val,
+				})
+			} else if !bytes.Equal(f.val, t.val) {
+				ret = append(ret, MeasurementDiff{
+					Kind: DiffModified, Triple: triple.name,
+					Environment: f.env, Key: f.key, From: f.val, To: t.val,
+				})
+			}
+		}
+
+		for _, k := range toOrder {
+			if _, ok := from[k]; !ok {
+				t := to[k]
+				ret = append(ret, MeasurementDiff{
+					Kind: DiffAdded, Triple: triple.name,
+					Environment: t.env, Key: t.key, To: t.val,
+				})
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func diffableMeasurements(vts *comid.ValueTriples) ([]string, map[string]diffableMeasurement, error) {
+	entries := make(map[string]diffableMeasurement)
+
+	if vts == nil {
+		return nil, entries, nil
+	}
+
+	var order []string
+
+	for _, vt := range vts.Values {
+		env, err := json.Marshal(vt.Environment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding environment: %w", err)
+		}
+
+		for i, m := range vt.Measurements.Values {
+			var key json.RawMessage
+
+			id := fmt.Sprintf("%s#%d", env, i)
+
+			if m.Key != nil {
+				if key, err = json.Marshal(m.Key); err != nil {
+					return nil, nil, fmt.Errorf("encoding measurement key: %w", err)
+				}
+				id = fmt.Sprintf("%s/%s", env, key)
+			}
+
+			val, err := json.Marshal(m.Val)
+			if err != nil {
+				return nil, nil, fmt.Errorf("encoding measurement value: %w", err)
+			}
+
+			if _, ok := entries[id]; !ok {
+				order = append(order, id)
+			}
+
+			entries[id] = diffableMeasurement{env: env, key: key, val: val}
+		}
+	}
+
+	return order, entries, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+func comidFromJSON(t *testing.T, s string) comid.Comid {
+	var c comid.Comid
+	require.NoError(t, c.FromJSON([]byte(s)))
+	return c
+}
+
+func TestDiff(t *testing.T) {
+	orig := comidFromJSON(t, comid.PSARefValJSONTemplate)
+
+	modified := comidFromJSON(t, strings.NewReplacer(
+		// new digest for BL
+		"h0KPxSKAPTEGXnvOPPA/5HUJZjHl4Hu9eg/eYMTPJcc=",
+		"AmOCmYm2/ZVPcrqvL8ZLwuLwHWktTecphuqAj26ZgT8=",
+		// new version of ARoT
+		`"version": "0.1.4"`,
+		`"version": "0.1.5"`,
+	).Replace(comid.PSARefValJSONTemplate))
+
+	keys := comidFromJSON(t, comid.PSAKeysJSONTemplate)
+
+	thumbprint := swid.HashEntry{
+		HashAlgID: swid.Sha256,
+		HashValue: comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75"),
+	}
+
+	a := NewUnsignedCorim().
+		SetID("test corim id").
+		SetProfile("https://arm.com/psa/iot/1.0.0").
+		AddDependentRim("https://endorser.example/removed.corim", nil).
+		AddDependentRim("https://endorser.example/modified.corim", nil).
+		AddComid(orig)
+	require.NotNil(t, a)
+
+	b := NewUnsignedCorim().
+		SetID("test corim id").
+		SetProfile("https://arm.com/psa/iot/2.0.0").
+		AddDependentRim("https://endorser.example/modified.corim", &thumbprint).
+		AddDependentRim("https://endorser.example/added.corim", nil).
+		AddComid(modified).
+		AddComid(keys)
+	require.NotNil(t, b)
+
+	actual, err := Diff(*a, *b)
+	require.NoError(t, err)
+
+	assert.Equal(t, &ProfileDiff{
+		From: "https://arm.com/psa/iot/1.0.0",
+		To:   "https://arm.com/psa/iot/2.0.0",
+	}, actual.Profile)
+
+	assert.Equal(t, []LocatorDiff{
+		{Kind: DiffRemoved, Href: "https://endorser.example/removed.corim"},
+		{Kind: DiffModified, Href: "https://endorser.example/modified.corim"},
+		{Kind: DiffAdded, Href: "https://endorser.example/added.corim"},
+	}, actual.DependentRims)
+
+	require.Len(t, actual.Tags, 2)
+
+	assert.Equal(t, DiffModified, actual.Tags[0].Kind)
+	assert.Equal(t, "comid", actual.Tags[0].Type)
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", actual.Tags[0].TagID)
+
+	assert.Equal(t, TagDiff{
+		Kind:  DiffAdded,
+		Type:  "comid",
+		TagID: "366d0a0a-5988-45ed-8488-2f2a544f6242",
+	}, actual.Tags[1])
+
+	ms := actual.Tags[0].Measurements
+	require.Len(t, ms, 3)
+
+	assert.Equal(t, DiffModified, ms[0].Kind)
+	assert.Equal(t, "reference-values", ms[0].Triple)
+	assert.Contains(t, string(ms[0].Key), `"label":"BL"`)
+	assert.Contains(t, string(ms[0].From), "h0KPxSKAPTEGXnvOPPA/5HUJZjHl4Hu9eg/eYMTPJcc=")
+	assert.Contains(t, string(ms[0].To), "AmOCmYm2/ZVPcrqvL8ZLwuLwHWktTecphuqAj26ZgT8=")
+
+	assert.Equal(t, DiffRemoved, ms[1].Kind)
+	assert.Contains(t, string(ms[1].Key), `"version":"0.1.4"`)
+	assert.Nil(t, ms[1].To)
+
+	assert.Equal(t, DiffAdded, ms[2].Kind)
+	assert.Contains(t, string(ms[2].Key), `"version":"0.1.5"`)
+	assert.Nil(t, ms[2].From)
+
+	// the diff can be rendered as JSON
+	_, err = json.Marshal(actual)
+	assert.NoError(t, err)
+
+	// the reverse diff swaps additions and removals
+	reverse, err := Diff(*b, *a)
+	require.NoError(t, err)
+	require.Len(t, reverse.Tags, 2)
+	assert.Equal(t, DiffRemoved, reverse.Tags[1].Kind)
+}
+
+func TestDiff_identical(t *testing.T) {
+	var a UnsignedCorim
+	require.NoError(t, a.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	actual, err := Diff(a, *a.Clone())
+	require.NoError(t, err)
+	assert.True(t, actual.IsEmpty())
+}
+
+func TestDiff_bad_tag(t *testing.T) {
+	var a UnsignedCorim
+	require.NoError(t, a.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	b := a.Clone()
+	b.Tags = append(b.Tags, append(ComidTag, 0x01))
+
+	_, err := Diff(a, *b)
+	assert.EqualError(t, err, "second CoRIM: decoding CoMID at pos 1: expected map (CBOR Major Type 5), found Major Type 0")
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import "bytes"
+
+// CorimEncoder serializes unsigned CoRIMs to CBOR, like ToCBOR, but reuses its
+// output buffer across calls, which reduces allocations when encoding many
+// CoRIMs in a row (e.g., in a high-throughput service).  A CorimEncoder must
+// not be used concurrently.
+type CorimEncoder struct {
+	buf bytes.Buffer
+}
+
+// NewCorimEncoder instantiates a CorimEncoder with an empty buffer
+func NewCorimEncoder() *CorimEncoder {
+	return &CorimEncoder{}
+}
+
+// Encode serializes the supplied unsigned CoRIM to CBOR.  The returned slice
+// refers to the internal buffer of the encoder and is only valid until the next
+// call to Encode or Reset: callers that need to retain it must copy it.
+func (o *CorimEncoder) Encode(c UnsignedCorim) ([]byte, error) {
+	o.buf.Reset()
+
+	if err := c.EncodeCBOR(&o.buf); err != nil {
+		return nil, err
+	}
+
+	return o.buf.Bytes(), nil
+}
+
+// Reset releases the internal buffer of the encoder, e.g., after encoding an
+// unusually large CoRIM, so that its memory can be reclaimed
+func (o *CorimEncoder) Reset() {
+	o.buf = bytes.Buffer{}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeCorimTags is the number of tags in the CoRIM used by the benchmarks
+const largeCorimTags = 1000
+
+func largeCorim(tb testing.TB, n int) *UnsignedCorim {
+	var c UnsignedCorim
+	require.NoError(tb, c.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	tag := c.Tags[0]
+	c.Tags = make([]Tag, n)
+	for i := range c.Tags {
+		c.Tags[i] = tag
+	}
+
+	return &c
+}
+
+func TestCorimEncoder(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	expected, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	enc := NewCorimEncoder()
+
+	for i := 0; i < 2; i++ {
+		actual, err := enc.Encode(*tv)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	large := largeCorim(t, 10)
+	expected, err = large.ToCBOR()
+	require.NoError(t, err)
+
+	actual, err := enc.Encode(*large)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	enc.Reset()
+
+	actual, err = enc.Encode(*tv)
+	require.NoError(t, err)
+	assert.Equal(t, len(testGoodUnsignedCorimCBOR), len(actual))
+}
+
+func BenchmarkEncodeLargeCorim(b *testing.B) {
+	c := largeCorim(b, largeCorimTags)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.ToCBOR(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeLargeCorim_CorimEncoder(b *testing.B) {
+	c := largeCorim(b, largeCorimTags)
+	enc := NewCorimEncoder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := enc.Encode(*c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeLargeCorim(b *testing.B) {
+	data, err := largeCorim(b, largeCorimTags).ToCBOR()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var c UnsignedCorim
+		if err := c.FromCBOR(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
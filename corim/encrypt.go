@@ -0,0 +1,211 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	cbor "github.com/fxamacker/cbor/v2"
+	cose "github.com/veraison/go-cose"
+)
+
+// AES-GCM content encryption algorithms (RFC 9053, Section 4.1), for use with
+// Encrypt0 and EncryptCorim.  The go-cose package only deals with signatures.
+const (
+	AlgorithmA128GCM cose.Algorithm = 1
+	AlgorithmA192GCM cose.Algorithm = 2
+	AlgorithmA256GCM cose.Algorithm = 3
+)
+
+// COSEEncrypt0TagNumber is the CBOR tag number of a COSE_Encrypt0_Tagged
+// message
+const COSEEncrypt0TagNumber uint64 = 16
+
+// ErrDecryption is returned when the ciphertext of a COSE_Encrypt0 message
+// cannot be authenticated, e.g., because the wrong key was supplied
+var ErrDecryption = errors.New("decryption failed")
+
+const gcmNonceSize = 12
+
+// encrypt0 is the COSE_Encrypt0 structure (RFC 9052, Section 5.2)
+type encrypt0 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int64]cbor.RawMessage
+	Ciphertext  []byte
+}
+
+// EncryptCorim returns the target unsigned CoRIM, CBOR-encoded and encrypted
+// with Encrypt0 using ContentType as the content type
+func (o UnsignedCorim) EncryptCorim(key []byte, alg cose.Algorithm) ([]byte, error) {
+	payload, err := o.ToCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("failed CBOR encoding of unsigned CoRIM: %w", err)
+	}
+
+	return Encrypt0(payload, ContentType, key, alg)
+}
+
+// DecryptCorim decrypts the supplied COSE_Encrypt0 message (see EncryptCorim)
+// and decodes the enclosed unsigned CoRIM, along with the extensions registered
+// for its profile, if any.  Messages enclosing anything other than an unsigned
+// CoRIM (e.g., a signed CoRIM) must be decrypted using Decrypt0.
+func DecryptCorim(buf, key []byte) (*UnsignedCorim, error) {
+	payload, contentType, err := Decrypt0(buf, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != ContentType {
+		return nil, fmt.Errorf("expecting content type %q, got %q instead", ContentType, contentType)
+	}
+
+	return UnmarshalUnsignedCorimFromCBOR(payload)
+}
+
+// Encrypt0 encrypts the supplied payload into a COSE_Encrypt0_Tagged message
+// using the supplied AES-GCM algorithm, and the supplied key directly as the
+// content encryption key.  The content type is carried in the protected
+// header, and a random IV in the unprotected header.  Signed CoRIMs can be
+// encrypted by passing the output of SignedCorim.Sign as the payload, with a
+// content type of MediaTypeSignedCorim.
+func Encrypt0(payload []byte, contentType string, key []byte, alg cose.Algorithm) ([]byte, error) {
+	aead, err := newGCM(alg, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType == "" {
+		return nil, errors.New("empty content type")
+	}
+
+	protected, err := em.Marshal(map[int64]interface{}{
+		cose.HeaderLabelAlgorithm:   alg,
+		cose.HeaderLabelContentType: contentType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating IV: %w", err)
+	}
+
+	iv, err := em.Marshal(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := encStructure(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := encrypt0{
+		Protected:   protected,
+		Unprotected: map[int64]cbor.RawMessage{cose.HeaderLabelIV: iv},
+		Ciphertext:  aead.Seal(nil, nonce, payload, aad),
+	}
+
+	return em.Marshal(cbor.Tag{Number: COSEEncrypt0TagNumber, Content: msg})
+}
+
+// Decrypt0 decrypts the supplied COSE_Encrypt0 message, tagged or untagged,
+// using the supplied key as the content encryption key, and returns the
+// payload along with its content type.  If the ciphertext cannot be
+// authenticated, ErrDecryption is returned.
+func Decrypt0(buf, key []byte) ([]byte, string, error) {
+	if len(buf) != 0 && buf[0]>>5 == cborMajorTypeTag {
+		var tag cbor.RawTag
+		if err := dm.Unmarshal(buf, &tag); err != nil {
+			return nil, "", fmt.Errorf("decoding COSE_Encrypt0: %w", err)
+		}
+
+		if tag.Number != COSEEncrypt0TagNumber {
+			return nil, "", fmt.Errorf("unexpected CBOR tag %d", tag.Number)
+		}
+
+		buf = tag.Content
+	}
+
+	var msg encrypt0
+	if err := dm.Unmarshal(buf, &msg); err != nil {
+		return nil, "", fmt.Errorf("decoding COSE_Encrypt0: %w", err)
+	}
+
+	var hdr struct {
+		Alg         *int64  `cbor:"1,keyasint"`
+		ContentType *string `cbor:"3,keyasint"`
+	}
+	if err := dm.Unmarshal(msg.Protected, &hdr); err != nil {
+		return nil, "", fmt.Errorf("decoding protected header: %w", err)
+	}
+
+	if hdr.Alg == nil {
+		return nil, "", errors.New("missing algorithm in protected header")
+	}
+
+	if hdr.ContentType == nil {
+		return nil, "", errors.New("missing content type in protected header")
+	}
+
+	var nonce []byte
+	if err := dm.Unmarshal(msg.Unprotected[cose.HeaderLabelIV], &nonce); err != nil || len(nonce) != gcmNonceSize {
+		return nil, "", fmt.Errorf("expecting a %d-byte IV in unprotected header", gcmNonceSize)
+	}
+
+	aead, err := newGCM(cose.Algorithm(*hdr.Alg), key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	aad, err := encStructure(msg.Protected)
+	if err != nil {
+		return nil, "", err
+	}
+
+	payload, err := aead.Open(nil, nonce, msg.Ciphertext, aad)
+	if err != nil {
+		return nil, "", ErrDecryption
+	}
+
+	return payload, *hdr.ContentType, nil
+}
+
+// encStructure returns the Enc_structure (RFC 9052, Section 5.3) authenticated
+// as additional data for a COSE_Encrypt0 with the supplied protected header
+func encStructure(protected []byte) ([]byte, error) {
+	return em.Marshal([]interface{}{"Encrypt0", protected, NoExternalData})
+}
+
+func newGCM(alg cose.Algorithm, key []byte) (cipher.AEAD, error) {
+	var keyLen int
+
+	switch alg {
+	case AlgorithmA128GCM:
+		keyLen = 16
+	case AlgorithmA192GCM:
+		keyLen = 24
+	case AlgorithmA256GCM:
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("unsupported content encryption algorithm %d", alg)
+	}
+
+	if len(key) != keyLen {
+		return nil, fmt.Errorf("expecting a %d-byte key for algorithm %d, got %d bytes", keyLen, alg, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+func TestUnsignedCorim_EncryptCorim_DecryptCorim(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	for _, tc := range []struct {
+		alg    cose.Algorithm
+		keyLen int
+	}{
+		{AlgorithmA128GCM, 16},
+		{AlgorithmA192GCM, 24},
+		{AlgorithmA256GCM, 32},
+	} {
+		key := bytes.Repeat([]byte{0x42}, tc.keyLen)
+
+		data, err := tv.EncryptCorim(key, tc.alg)
+		require.NoError(t, err)
+
+		// COSE_Encrypt0_Tagged, with the content type in the protected header
+		var tag cbor.RawTag
+		require.NoError(t, dm.Unmarshal(data, &tag))
+		assert.Equal(t, COSEEncrypt0TagNumber, tag.Number)
+		assert.False(t, bytes.Contains(data, testGoodUnsignedCorimCBOR[5:40]))
+
+		actual, err := DecryptCorim(data, key)
+		require.NoError(t, err)
+		assert.True(t, tv.Equal(*actual))
+
+		// untagged
+		actual, err = DecryptCorim(tag.Content, key)
+		require.NoError(t, err)
+		assert.True(t, tv.Equal(*actual))
+
+		_, err = DecryptCorim(data, bytes.Repeat([]byte{0x24}, tc.keyLen))
+		assert.ErrorIs(t, err, ErrDecryption)
+	}
+
+	// the IV is random
+	key := make([]byte, 16)
+	a, err := tv.EncryptCorim(key, AlgorithmA128GCM)
+	require.NoError(t, err)
+	b, err := tv.EncryptCorim(key, AlgorithmA128GCM)
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+
+	// tampering with the ciphertext is detected
+	a[len(a)-1] ^= 0x01
+	_, err = DecryptCorim(a, key)
+	assert.ErrorIs(t, err, ErrDecryption)
+}
+
+func TestEncrypt0_sign_then_encrypt(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	SignedCorimIn := SignedCorim{
+		UnsignedCorim: *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR),
+		Meta:          *metaGood(t),
+	}
+
+	signed, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	key := make([]byte, 32)
+
+	data, err := Encrypt0(signed, MediaTypeSignedCorim, key, AlgorithmA256GCM)
+	require.NoError(t, err)
+
+	_, err = DecryptCorim(data, key)
+	assert.EqualError(t, err, `expecting content type "application/rim+cbor", got "application/rim+cose" instead`)
+
+	payload, contentType, err := Decrypt0(data, key)
+	require.NoError(t, err)
+	assert.Equal(t, MediaTypeSignedCorim, contentType)
+
+	var SignedCorimOut SignedCorim
+	require.NoError(t, SignedCorimOut.FromCOSE(payload))
+	assert.NoError(t, SignedCorimOut.Verify(pk))
+}
+
+func TestEncrypt0_Decrypt0_errors(t *testing.T) {
+	_, err := Encrypt0([]byte("payload"), ContentType, make([]byte, 16), cose.AlgorithmES256)
+	assert.EqualError(t, err, "unsupported content encryption algorithm -7")
+
+	_, err = Encrypt0([]byte("payload"), ContentType, make([]byte, 16), AlgorithmA256GCM)
+	assert.EqualError(t, err, "expecting a 32-byte key for algorithm 3, got 16 bytes")
+
+	_, err = Encrypt0([]byte("payload"), "", make([]byte, 16), AlgorithmA128GCM)
+	assert.EqualError(t, err, "empty content type")
+
+	key := make([]byte, 16)
+
+	_, _, err = Decrypt0(testGoodSignedCorimCBOR, key)
+	assert.EqualError(t, err, "unexpected CBOR tag 18")
+
+	_, _, err = Decrypt0([]byte{0x80}, key)
+	assert.ErrorContains(t, err, "decoding COSE_Encrypt0: ")
+
+	encode := func(protected map[int64]interface{}, unprotected map[int64]cbor.RawMessage) []byte {
+		p, err := em.Marshal(protected)
+		require.NoError(t, err)
+		data, err := em.Marshal(encrypt0{Protected: p, Unprotected: unprotected, Ciphertext: []byte{0x00}})
+		require.NoError(t, err)
+		return data
+	}
+
+	iv := cbor.RawMessage(append([]byte{0x4c}, make([]byte, 12)...))
+
+	_, _, err = Decrypt0(encode(map[int64]interface{}{3: ContentType}, map[int64]cbor.RawMessage{5: iv}), key)
+	assert.EqualError(t, err, "missing algorithm in protected header")
+
+	_, _, err = Decrypt0(encode(map[int64]interface{}{1: 1}, map[int64]cbor.RawMessage{5: iv}), key)
+	assert.EqualError(t, err, "missing content type in protected header")
+
+	_, _, err = Decrypt0(encode(map[int64]interface{}{1: 1, 3: ContentType}, nil), key)
+	assert.EqualError(t, err, "expecting a 12-byte IV in unprotected header")
+
+	_, _, err = Decrypt0(encode(map[int64]interface{}{1: 1, 3: ContentType}, map[int64]cbor.RawMessage{5: iv}), key)
+	assert.ErrorIs(t, err, ErrDecryption)
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"unicode/utf8"
 
 	"github.com/veraison/corim/comid"
@@ -155,6 +156,17 @@ func (o *Entities) Add(val *Entity) *Entities {
 	return (*Entities)(ret)
 }
 
+// hasRole returns true if at least one of the entities claims the supplied role
+func (o Entities) hasRole(role Role) bool {
+	for _, e := range o.Values {
+		if slices.Contains(e.Roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (o Entities) MarshalCBOR() ([]byte, error) {
 	return (extensions.Collection[Entity, *Entity])(o).MarshalCBOR()
 }
@@ -0,0 +1,192 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/veraison/swid"
+)
+
+// Equal reports whether the target and other unsigned CoRIMs are semantically
+// equal.  The comparison is content-based rather than serialization-based:
+//
+//   - corim-ids must be the same;
+//   - profiles are compared in their normalized form (see NormalizeProfile);
+//   - dependent RIMs are compared irrespective of their order, by href and
+//     thumbprints (the latter also irrespective of their order);
+//   - tags are compared irrespective of their order, by decoded content, so
+//     that tags whose encodings only differ in, e.g., the order of map keys
+//     are considered equal;
+//   - any other entry (including raw extensions) is compared by decoded
+//     content.
+//
+// Equal returns false if any of the above cannot be decoded.
+func (o UnsignedCorim) Equal(other UnsignedCorim) bool {
+	if o.ID != other.ID {
+		return false
+	}
+
+	a, err := profileString(o.Profile)
+	if err != nil {
+		return false
+	}
+
+	b, err := profileString(other.Profile)
+	if err != nil || a != b {
+		return false
+	}
+
+	if !slices.Equal(locatorKeys(o.DependentRims), locatorKeys(other.DependentRims)) {
+		return false
+	}
+
+	aTags, err := canonicalTags(o.Tags)
+	if err != nil {
+		return false
+	}
+
+	bTags, err := canonicalTags(other.Tags)
+	if err != nil || !slices.Equal(aTags, bTags) {
+		return false
+	}
+
+	aRest, err := o.canonicalRest()
+	if err != nil {
+		return false
+	}
+
+	bRest, err := other.canonicalRest()
+
+	return err == nil && bytes.Equal(aRest, bRest)
+}
+
+// locatorKeys returns, in sorted order, a string identifying each of the
+// supplied locators by href and thumbprints
+func locatorKeys(ls *[]Locator) []string {
+	if ls == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(*ls))
+
+	for _, l := range *ls {
+		tps := make([]string, 0, len(l.Thumbprints()))
+		for _, tp := range l.Thumbprints() {
+			tps = append(tps, fmt.Sprintf("%d:%x", tp.HashAlgID, tp.HashValue))
+		}
+		slices.Sort(tps)
+
+		keys = append(keys, string(l.Href)+" "+strings.Join(tps, ","))
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// canonicalTags returns, in sorted order, the canonical encoding of each of
+// the supplied tags
+func canonicalTags(tags []Tag) ([]string, error) {
+	ret := make([]string, 0, len(tags))
+
+	for _, t := range tags {
+		c, err := canonicalCBOR(t)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, string(c))
+	}
+
+	slices.Sort(ret)
+
+	return ret, nil
+}
+
+// canonicalRest returns the canonical encoding of the unsigned-corim-map
+// entries not compared explicitly by Equal
+func (o UnsignedCorim) canonicalRest() ([]byte, error) {
+	// the corim-id is mandatory, so it is replaced rather than removed
+	o.ID = *swid.NewTagID("-")
+	o.Profile = nil
+	o.DependentRims = nil
+	o.Tags = nil
+
+	data, err := o.ToCBOR()
+	if err != nil {
+		return nil, err
+	}
+
+	return canonicalCBOR(data)
+}
+
+// canonicalCBOR decodes the supplied CBOR data generically, and re-encodes it
+// using Core Deterministic Encoding
+func canonicalCBOR(data []byte) ([]byte, error) {
+	var v interface{}
+
+	if err := cbor.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	enc, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		return nil, err
+	}
+
+	return enc.Marshal(v)
+}
+
+// DuplicateTags returns, in ascending order, the positions in the tags array of
+// the tags whose decoded content is the same as that of an earlier tag (see
+// Equal).  Tags that cannot be decoded are compared by their raw bytes.
+func (o UnsignedCorim) DuplicateTags() []int {
+	var dups []int
+
+	seen := make(map[string]bool, len(o.Tags))
+
+	for i, t := range o.Tags {
+		key, err := canonicalCBOR(t)
+		if err != nil {
+			key = t
+		}
+
+		if seen[string(key)] {
+			dups = append(dups, i)
+			continue
+		}
+
+		seen[string(key)] = true
+	}
+
+	return dups
+}
+
+// DeduplicateTags removes from the tags array the tags reported by
+// DuplicateTags, keeping the first occurrence of each tag in its position
+func (o *UnsignedCorim) DeduplicateTags() *UnsignedCorim {
+	if o != nil {
+		dups := o.DuplicateTags()
+		if len(dups) == 0 {
+			return o
+		}
+
+		kept := make([]Tag, 0, len(o.Tags)-len(dups))
+
+		for i, t := range o.Tags {
+			if len(dups) != 0 && dups[0] == i {
+				dups = dups[1:]
+				continue
+			}
+			kept = append(kept, t)
+		}
+
+		o.Tags = kept
+	}
+	return o
+}
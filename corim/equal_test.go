@@ -0,0 +1,93 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/swid"
+)
+
+func TestUnsignedCorim_Equal(t *testing.T) {
+	tp1 := &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)}
+	tp2 := &swid.HashEntry{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)}
+
+	a := NewTestCorim().
+		SetProfile("https://example.com/profile").
+		AddRawTag(CoswidTagNumber, []byte{0xa2, 0x00, 0x01, 0x01, 0x02}).
+		AddDependentRim("https://example.com/a", tp1, tp2).
+		AddDependentRim("https://example.com/b", nil)
+	require.NotNil(t, a)
+
+	// same content: different profile case, tags and dependent RIMs order,
+	// and map key order within a tag
+	b := NewUnsignedCorim().
+		SetID(TestCorimID).
+		SetProfile("HTTPS://EXAMPLE.COM/profile").
+		AddRawTag(CoswidTagNumber, []byte{0xa2, 0x01, 0x02, 0x00, 0x01}).
+		AddDependentRim("https://example.com/b", nil).
+		AddDependentRim("https://example.com/a", tp2, tp1)
+	require.NotNil(t, b)
+	b.Tags = append(b.Tags, NewTestCorim().Tags...)
+
+	assert.True(t, a.Equal(*a))
+	assert.True(t, a.Equal(*b))
+	assert.True(t, b.Equal(*a))
+	assert.True(t, a.Equal(*a.Clone()))
+
+	for name, modify := range map[string]func(*UnsignedCorim){
+		"id": func(c *UnsignedCorim) { c.SetID("other") },
+		"profile": func(c *UnsignedCorim) {
+			c.SetProfile("https://example.com/other")
+		},
+		"no profile": func(c *UnsignedCorim) { c.Profile = nil },
+		"tag": func(c *UnsignedCorim) {
+			c.Tags[1] = append(TagHeader(CoswidTagNumber), 0xa1, 0x00, 0x01)
+		},
+		"extra tag": func(c *UnsignedCorim) { c.Tags = append(c.Tags, c.Tags[0]) },
+		"thumbprint": func(c *UnsignedCorim) {
+			(*c.DependentRims)[0].AltThumbprints = nil
+		},
+		"dependent RIM": func(c *UnsignedCorim) {
+			c.AddDependentRim("https://example.com/c", nil)
+		},
+		"validity": func(c *UnsignedCorim) {
+			c.SetRimValidity(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+		},
+		"extension": func(c *UnsignedCorim) { c.SetExtension(-1, "x") },
+	} {
+		c := a.Clone()
+		modify(c)
+		assert.False(t, a.Equal(*c), name)
+		assert.False(t, c.Equal(*a), name)
+	}
+}
+
+func TestUnsignedCorim_DuplicateTags(t *testing.T) {
+	tv := NewTestCorim().
+		AddRawTag(CoswidTagNumber, []byte{0xa2, 0x00, 0x01, 0x01, 0x02}).
+		// same content as the previous tag, different map key order
+		AddRawTag(CoswidTagNumber, []byte{0xa2, 0x01, 0x02, 0x00, 0x01}).
+		AddRawTag(CoswidTagNumber, []byte{0xa1, 0x00, 0x01})
+	require.NotNil(t, tv)
+
+	tv.Tags = append(tv.Tags, tv.Tags[0])
+	// undecodable tags are compared by raw bytes
+	tv.Tags = append(tv.Tags, Tag{0xff}, Tag{0xff})
+
+	assert.Equal(t, []int{2, 4, 6}, tv.DuplicateTags())
+
+	expected := []Tag{tv.Tags[0], tv.Tags[1], tv.Tags[3], tv.Tags[5]}
+
+	require.NotNil(t, tv.DeduplicateTags())
+	assert.Equal(t, expected, tv.Tags)
+	assert.Nil(t, tv.DuplicateTags())
+
+	// no duplicates
+	require.NotNil(t, tv.DeduplicateTags())
+	assert.Equal(t, expected, tv.Tags)
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+// MeasurementHit is a measurement found by UnsignedCorim.FindMeasurements,
+// together with its location in the CoRIM
+type MeasurementHit struct {
+	// TagID is the tag-id of the CoMID containing the measurement
+	TagID swid.TagID
+	// Triples is the name of the triples the measurement belongs to, i.e.,
+	// "reference-values" or "endorsed-values"
+	Triples string
+	// TripleIndex is the position of the triple within Triples
+	TripleIndex int
+	// MeasurementIndex is the position of the measurement within the triple
+	MeasurementIndex int
+	// Environment is the environment of the triple
+	Environment comid.Environment
+	Measurement comid.Measurement
+}
+
+// FindMeasurements returns, in order, the measurements of the reference and
+// endorsed values of the contained CoMIDs for which match returns true.  An
+// error is returned if any of the CoMIDs cannot be decoded.
+func (o UnsignedCorim) FindMeasurements(match func(comid.Measurement) bool) ([]MeasurementHit, error) {
+	if match == nil {
+		return nil, errors.New("nil match function")
+	}
+
+	comids, err := o.GetComids()
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []MeasurementHit
+
+	for _, c := range comids {
+		for _, ts := range []struct {
+			name string
+			vts  *comid.ValueTriples
+		}{
+			{"reference-values", c.Triples.ReferenceValues},
+			{"endorsed-values", c.Triples.EndorsedValues},
+		} {
+			if ts.vts == nil {
+				continue
+			}
+
+			for i, vt := range ts.vts.Values {
+				for j, m := range vt.Measurements.Values {
+					if !match(m) {
+						continue
+					}
+
+					hits = append(hits, MeasurementHit{
+						TagID:            c.TagIdentity.TagID,
+						Triples:          ts.name,
+						TripleIndex:      i,
+						MeasurementIndex: j,
+						Environment:      vt.Environment,
+						Measurement:      m,
+					})
+				}
+			}
+		}
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestUnsignedCorim_FindMeasurements(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	all, err := c.FindMeasurements(func(comid.Measurement) bool { return true })
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	hasLabel := func(label string) func(comid.Measurement) bool {
+		return func(m comid.Measurement) bool {
+			if m.Key == nil {
+				return false
+			}
+
+			id, err := m.Key.GetPSARefValID()
+			return err == nil && id.Label != nil && *id.Label == label
+		}
+	}
+
+	hits, err := c.FindMeasurements(hasLabel("PRoT"))
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", hits[0].TagID.String())
+	assert.Equal(t, "reference-values", hits[0].Triples)
+	assert.Equal(t, 0, hits[0].TripleIndex)
+	assert.Equal(t, 1, hits[0].MeasurementIndex)
+	assert.Equal(t, all[1].Measurement, hits[0].Measurement)
+	require.NotNil(t, hits[0].Environment.Class)
+	assert.Equal(t, "ACME", *hits[0].Environment.Class.Vendor)
+
+	hits, err = c.FindMeasurements(hasLabel("no such label"))
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+
+	_, err = c.FindMeasurements(nil)
+	assert.EqualError(t, err, "nil match function")
+
+	c.Tags = append(c.Tags, append(ComidTag, 0xff))
+	_, err = c.FindMeasurements(hasLabel("PRoT"))
+	assert.ErrorContains(t, err, "tag at pos 1: ")
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"slices"
+
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+// HashAlgorithmsUsed returns the sorted set of the hash algorithm IDs (see
+// the swid package constants, e.g. swid.Sha256) referenced by the dependent
+// RIM thumbprints and by the digests and integrity registers of the reference
+// and endorsed values of the contained CoMIDs.  An error is returned if any
+// of the CoMIDs cannot be decoded.
+func (o UnsignedCorim) HashAlgorithmsUsed() ([]uint64, error) {
+	seen := make(map[uint64]bool)
+
+	addDigests := func(ds []swid.HashEntry) {
+		for _, d := range ds {
+			seen[d.HashAlgID] = true
+		}
+	}
+
+	if o.DependentRims != nil {
+		for _, l := range *o.DependentRims {
+			if l.Thumbprint != nil {
+				seen[l.Thumbprint.HashAlgID] = true
+			}
+			addDigests(l.AltThumbprints)
+		}
+	}
+
+	comids, err := o.GetComids()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range comids {
+		for _, vts := range []*comid.ValueTriples{c.Triples.ReferenceValues, c.Triples.EndorsedValues} {
+			if vts == nil {
+				continue
+			}
+
+			for _, vt := range vts.Values {
+				for _, m := range vt.Measurements.Values {
+					if m.Val.Digests != nil {
+						addDigests(*m.Val.Digests)
+					}
+
+					if m.Val.IntegrityRegisters != nil {
+						for _, ds := range m.Val.IntegrityRegisters.IndexMap {
+							addDigests(ds)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	algs := make([]uint64, 0, len(seen))
+	for alg := range seen {
+		algs = append(algs, alg)
+	}
+	slices.Sort(algs)
+
+	return algs, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+func TestUnsignedCorim_HashAlgorithmsUsed(t *testing.T) {
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate)).
+		AddComid(comidFromJSON(t, comid.CCARealmRefValJSONTemplate)).
+		AddDependentRim("https://endorser.example/dep.corim",
+			&swid.HashEntry{HashAlgID: swid.Sha512, HashValue: make([]byte, 64)},
+			&swid.HashEntry{HashAlgID: swid.Sha3_256, HashValue: make([]byte, 32)})
+	require.NotNil(t, tv)
+
+	algs, err := tv.HashAlgorithmsUsed()
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{swid.Sha256, swid.Sha384, swid.Sha512, swid.Sha3_256}, algs)
+
+	// no digests at all
+	algs, err = NewTestCorim().HashAlgorithmsUsed()
+	require.NoError(t, err)
+	assert.Empty(t, algs)
+
+	// undecodable CoMID
+	tv.Tags = append(tv.Tags, append(ComidTag, 0x01))
+	_, err = tv.HashAlgorithmsUsed()
+	assert.ErrorContains(t, err, "decoding CoMID at pos 2")
+}
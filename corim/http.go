@@ -0,0 +1,243 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MediaTypeUnsignedCorimCBOR is the media type of a CBOR-encoded
+	// unsigned CoRIM
+	MediaTypeUnsignedCorimCBOR = "application/rim+cbor"
+	// MediaTypeSignedCorim is the media type of a COSE-signed CoRIM
+	MediaTypeSignedCorim = "application/rim+cose"
+	// MediaTypeUnsignedCorimJSON is the media type of the JSON representation
+	// of an unsigned CoRIM (see UnsignedCorim.ToJSON)
+	MediaTypeUnsignedCorimJSON = "application/json"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned by ReadCorim and ReadSignedCorim
+	// when the Content-Type of the request is not supported
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrSignedCorim is returned by ReadCorim when the request carries a
+	// signed CoRIM, which must be read using ReadSignedCorim instead
+	ErrSignedCorim = errors.New("signed CoRIM: use ReadSignedCorim")
+)
+
+// serveMediaTypes lists the media types ServeCorim can produce, in order of
+// preference
+var serveMediaTypes = []string{MediaTypeUnsignedCorimCBOR, MediaTypeUnsignedCorimJSON}
+
+// ServeCorim returns a handler that serves the supplied unsigned CoRIM to GET
+// and HEAD requests, either CBOR-encoded (MediaTypeUnsignedCorimCBOR, the
+// default) or as JSON (MediaTypeUnsignedCorimJSON), depending on the Accept
+// header of the request.  Requests that accept neither are answered with 406
+// Not Acceptable.  An invalid CoRIM is answered with 500 Internal Server Error.
+func ServeCorim(o UnsignedCorim) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		mt, ok := negotiate(r.Header.Values("Accept"), serveMediaTypes)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			return
+		}
+
+		if err := o.Valid(); err != nil {
+			http.Error(w, fmt.Sprintf("invalid CoRIM: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		var (
+			data []byte
+			err  error
+		)
+
+		if mt == MediaTypeUnsignedCorimJSON {
+			data, err = o.ToJSON()
+		} else {
+			data, err = o.ToCBOR()
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("encoding CoRIM: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", mt)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method != http.MethodHead {
+			_, _ = w.Write(data)
+		}
+	}
+}
+
+// ReadCorim decodes the unsigned CoRIM carried in the body of the supplied
+// request, according to its Content-Type (MediaTypeUnsignedCorimCBOR or
+// MediaTypeUnsignedCorimJSON).  The extensions registered for the profile of
+// the CoRIM, if any, are used (see UnmarshalUnsignedCorimFromCBOR).  Bodies
+// larger than DefaultMaxSize are rejected.  If the request carries a signed
+// CoRIM, ErrSignedCorim is returned.
+func ReadCorim(r *http.Request) (*UnsignedCorim, error) {
+	mt, err := requestMediaType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mt {
+	case MediaTypeUnsignedCorimCBOR, MediaTypeUnsignedCorimJSON:
+	case MediaTypeSignedCorim:
+		return nil, ErrSignedCorim
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mt)
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if mt == MediaTypeUnsignedCorimJSON {
+		return UnmarshalUnsignedCorimFromJSON(data)
+	}
+
+	return UnmarshalUnsignedCorimFromCBOR(data)
+}
+
+// ReadSignedCorim decodes the signed CoRIM (COSE_Sign1 or COSE_Sign) carried in
+// the body of the supplied request, whose Content-Type must be
+// MediaTypeSignedCorim.  The signature is not verified: doing so is the
+// responsibility of the caller.  Bodies larger than DefaultMaxSize are
+// rejected.
+func ReadSignedCorim(r *http.Request) (*SignedCorim, error) {
+	mt, err := requestMediaType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if mt != MediaTypeSignedCorim {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mt)
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnmarshalSignedCorimFromCBOR(data)
+}
+
+func requestMediaType(r *http.Request) (string, error) {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return "", fmt.Errorf("%w: missing Content-Type", ErrUnsupportedMediaType)
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnsupportedMediaType, err)
+	}
+
+	return mt, nil
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, errors.New("empty request body")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, DefaultMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+
+	if len(data) > DefaultMaxSize {
+		return nil, fmt.Errorf("request body exceeds the maximum of %d bytes", DefaultMaxSize)
+	}
+
+	return data, nil
+}
+
+// negotiate returns the media type, among the offered ones, that best matches
+// the supplied Accept header values.  Ties are resolved using the order of
+// offered.  A missing Accept header accepts anything.
+func negotiate(accept []string, offered []string) (string, bool) {
+	var ranges []string
+	for _, a := range accept {
+		ranges = append(ranges, strings.Split(a, ",")...)
+	}
+
+	if len(ranges) == 0 {
+		return offered[0], true
+	}
+
+	best, bestQ := "", 0.0
+
+	for _, o := range offered {
+		q := 0.0
+		specificity := -1
+
+		for _, rng := range ranges {
+			mt, params, err := mime.ParseMediaType(strings.TrimSpace(rng))
+			if err != nil {
+				continue
+			}
+
+			s, ok := mediaRangeMatch(mt, o)
+			if !ok || s < specificity {
+				continue
+			}
+
+			rq := 1.0
+			if v, ok := params["q"]; ok {
+				if rq, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+
+			// the most specific matching range determines the quality
+			if s > specificity {
+				specificity, q = s, rq
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+// mediaRangeMatch reports whether the media range matches the media type, and
+// how specifically (0 for */*, 1 for type/*, 2 for an exact match)
+func mediaRangeMatch(mediaRange, mediaType string) (int, bool) {
+	if mediaRange == "*/*" {
+		return 0, true
+	}
+
+	typ, sub, _ := strings.Cut(mediaRange, "/")
+	mtyp, _, _ := strings.Cut(mediaType, "/")
+
+	if sub == "*" {
+		return 1, typ == mtyp
+	}
+
+	return 2, mediaRange == mediaType
+}
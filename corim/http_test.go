@@ -0,0 +1,142 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+func TestServeCorim(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	cborData, err := c.ToCBOR()
+	require.NoError(t, err)
+	jsonData, err := c.ToJSON()
+	require.NoError(t, err)
+
+	tvs := []struct {
+		desc   string
+		accept []string
+		status int
+		ctype  string
+		body   []byte
+	}{
+		{"no Accept", nil, http.StatusOK, MediaTypeUnsignedCorimCBOR, cborData},
+		{"any", []string{"*/*"}, http.StatusOK, MediaTypeUnsignedCorimCBOR, cborData},
+		{"CBOR", []string{"application/rim+cbor"}, http.StatusOK, MediaTypeUnsignedCorimCBOR, cborData},
+		{"JSON", []string{"application/json"}, http.StatusOK, MediaTypeUnsignedCorimJSON, jsonData},
+		{"JSON preferred", []string{"application/rim+cbor;q=0.5, application/json"}, http.StatusOK, MediaTypeUnsignedCorimJSON, jsonData},
+		{"JSON over wildcard", []string{"application/json", "*/*;q=0.1"}, http.StatusOK, MediaTypeUnsignedCorimJSON, jsonData},
+		{"CBOR excluded", []string{"application/rim+cbor;q=0", "application/*"}, http.StatusOK, MediaTypeUnsignedCorimJSON, jsonData},
+		{"not acceptable", []string{"text/html"}, http.StatusNotAcceptable, "", nil},
+		{"all excluded", []string{"*/*;q=0"}, http.StatusNotAcceptable, "", nil},
+	}
+
+	for _, tv := range tvs {
+		t.Run(tv.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/corim", nil)
+			for _, a := range tv.accept {
+				req.Header.Add("Accept", a)
+			}
+
+			rec := httptest.NewRecorder()
+			ServeCorim(*c)(rec, req)
+
+			assert.Equal(t, tv.status, rec.Code)
+			if tv.status == http.StatusOK {
+				assert.Equal(t, tv.ctype, rec.Header().Get("Content-Type"))
+				assert.Equal(t, tv.body, rec.Body.Bytes())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	ServeCorim(*c)(rec, httptest.NewRequest(http.MethodHead, "/corim", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+
+	rec = httptest.NewRecorder()
+	ServeCorim(*c)(rec, httptest.NewRequest(http.MethodPost, "/corim", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+
+	rec = httptest.NewRecorder()
+	ServeCorim(UnsignedCorim{})(rec, httptest.NewRequest(http.MethodGet, "/corim", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestReadCorim(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	jsonData, err := c.ToJSON()
+	require.NoError(t, err)
+
+	newRequest := func(ctype string, body []byte) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/corim", bytes.NewReader(body))
+		if ctype != "" {
+			req.Header.Set("Content-Type", ctype)
+		}
+		return req
+	}
+
+	actual, err := ReadCorim(newRequest(MediaTypeUnsignedCorimCBOR, testGoodUnsignedCorimCBOR))
+	require.NoError(t, err)
+	assert.True(t, c.Equal(*actual))
+
+	actual, err = ReadCorim(newRequest("application/json; charset=utf-8", jsonData))
+	require.NoError(t, err)
+	assert.True(t, c.Equal(*actual))
+
+	_, err = ReadCorim(newRequest(MediaTypeSignedCorim, testGoodSignedCorimCBOR))
+	assert.ErrorIs(t, err, ErrSignedCorim)
+
+	_, err = ReadCorim(newRequest("text/plain", nil))
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+	assert.EqualError(t, err, `unsupported media type: "text/plain"`)
+
+	_, err = ReadCorim(newRequest("", testGoodUnsignedCorimCBOR))
+	assert.EqualError(t, err, "unsupported media type: missing Content-Type")
+
+	_, err = ReadCorim(newRequest(MediaTypeUnsignedCorimCBOR, make([]byte, DefaultMaxSize+1)))
+	assert.EqualError(t, err, "request body exceeds the maximum of 16777216 bytes")
+
+	_, err = ReadCorim(newRequest(MediaTypeUnsignedCorimCBOR, []byte{0xa0}))
+	assert.Error(t, err)
+}
+
+func TestReadSignedCorim(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/corim", bytes.NewReader(testGoodSignedCorimCBOR))
+	req.Header.Set("Content-Type", MediaTypeSignedCorim)
+
+	s, err := ReadSignedCorim(req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.UnsignedCorim.GetID())
+
+	// COSE_Sign
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	multi, err := s.SignMulti([]cose.Signer{signer})
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/corim", bytes.NewReader(multi))
+	req.Header.Set("Content-Type", MediaTypeSignedCorim)
+
+	s, err = ReadSignedCorim(req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.UnsignedCorim.GetID())
+
+	req = httptest.NewRequest(http.MethodPost, "/corim", bytes.NewReader(testGoodUnsignedCorimCBOR))
+	req.Header.Set("Content-Type", MediaTypeUnsignedCorimCBOR)
+
+	_, err = ReadSignedCorim(req)
+	assert.ErrorIs(t, err, ErrUnsupportedMediaType)
+}
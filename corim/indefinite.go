@@ -0,0 +1,225 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"fmt"
+
+	cbor "github.com/fxamacker/cbor/v2"
+)
+
+const (
+	cborIndefiniteArray = 0x9f
+	cborBreak           = 0xff
+)
+
+// indefiniteTags returns the supplied unsigned-corim-map with its tags array
+// re-encoded as an indefinite-length array (see EncodeOptions).  The map is
+// re-encoded with its keys in ascending order.
+func indefiniteTags(data []byte) ([]byte, error) {
+	var m map[int]cbor.RawMessage
+	if err := dm.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	var tags []cbor.RawMessage
+	if err := dm.Unmarshal(m[tagsKey], &tags); err != nil {
+		return nil, fmt.Errorf("encoding tags: %w", err)
+	}
+
+	arr := []byte{cborIndefiniteArray}
+	for _, t := range tags {
+		arr = append(arr, t...)
+	}
+	m[tagsKey] = append(arr, cborBreak)
+
+	return modes.detEnc.Marshal(m)
+}
+
+// normalizeCorimMap returns the supplied unsigned-corim-map with its tags and
+// profile entries in the form expected by the struct decoder (see
+// definiteArrays and bareProfile).  Maps that are already in that form, which
+// is the common case, are returned unchanged without being decoded.
+func normalizeCorimMap(dm cbor.DecMode, data []byte) ([]byte, error) {
+	if isNormalCorimMap(data) {
+		return data, nil
+	}
+
+	data, err := definiteArrays(dm, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return bareProfile(dm, data)
+}
+
+// isNormalCorimMap returns true if the supplied data is a definite-length map
+// whose tags entry, if any, is a definite-length array, and whose profile
+// entry, if any, is a bare OID (byte string) or URI (text string).  The map
+// is only scanned, not decoded.  Data that cannot be scanned (including
+// malformed data) is reported as not normal, so that it takes the slow path
+// where errors are reported.
+func isNormalCorimMap(data []byte) bool {
+	major, n, off, ok := cborHead(data, 0)
+	if !ok || major != cborMajorTypeMap {
+		return false
+	}
+
+	for i := uint64(0); i < n; i++ {
+		kmajor, key, _, ok := cborHead(data, off)
+		if !ok {
+			return false
+		}
+
+		voff, ok := skipCBORItems(data, off, 1)
+		if !ok || voff >= len(data) {
+			return false
+		}
+
+		if kmajor == 0 {
+			switch key {
+			case tagsKey:
+				if data[voff] == cborIndefiniteArray {
+					return false
+				}
+			case profileKey:
+				if m := data[voff] >> 5; m != 2 && m != 3 {
+					return false
+				}
+			}
+		}
+
+		if off, ok = skipCBORItems(data, voff, 1); !ok {
+			return false
+		}
+	}
+
+	return off == len(data)
+}
+
+// cborHead decodes the head of the CBOR data item at data[off:], returning
+// its major type, its argument and the offset of the byte following the head.
+// ok is false if the head is truncated, or if it is the head of an
+// indefinite-length item.
+func cborHead(data []byte, off int) (major byte, arg uint64, next int, ok bool) {
+	if off >= len(data) {
+		return 0, 0, 0, false
+	}
+
+	major, ai := data[off]>>5, data[off]&0x1f
+	off++
+
+	var size int
+
+	switch {
+	case ai < 24:
+		return major, uint64(ai), off, true
+	case ai == 24:
+		size = 1
+	case ai == 25:
+		size = 2
+	case ai == 26:
+		size = 4
+	case ai == 27:
+		size = 8
+	default:
+		return 0, 0, 0, false
+	}
+
+	if len(data)-off < size {
+		return 0, 0, 0, false
+	}
+
+	for _, b := range data[off : off+size] {
+		arg = arg<<8 | uint64(b)
+	}
+
+	return major, arg, off + size, true
+}
+
+// skipCBORItems returns the offset of the byte following the n definite-length
+// CBOR data items starting at data[off:].  The items are skipped iteratively,
+// so that deeply nested data cannot exhaust the stack.
+func skipCBORItems(data []byte, off int, n uint64) (int, bool) {
+	for pending := n; pending > 0; pending-- {
+		major, arg, next, ok := cborHead(data, off)
+		if !ok {
+			return 0, false
+		}
+
+		// no item is shorter than one byte, so longer counts are malformed
+		remaining := uint64(len(data) - next)
+
+		switch major {
+		case 2, 3: // byte and text strings
+			if arg > remaining {
+				return 0, false
+			}
+			next += int(arg)
+		case cborMajorTypeArray:
+			if arg > remaining {
+				return 0, false
+			}
+			pending += arg
+		case cborMajorTypeMap:
+			if arg > remaining/2 {
+				return 0, false
+			}
+			pending += 2 * arg
+		case cborMajorTypeTag:
+			pending++
+		}
+
+		off = next
+	}
+
+	return off, true
+}
+
+// definiteArrays returns the supplied unsigned-corim-map with its tags array
+// and profile array, if encoded as indefinite-length arrays, converted to their
+// definite-length form.  Indefinite-length items are not accepted anywhere
+// else.  data is returned unchanged if there is nothing to convert, or if it
+// cannot be decoded as a map (in which case the error is left to the caller to
+// report).
+func definiteArrays(dm cbor.DecMode, data []byte) ([]byte, error) {
+	opts := dm.DecOptions()
+	opts.IndefLength = cbor.IndefLengthAllowed
+
+	idm, err := opts.DecMode()
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[int]cbor.RawMessage
+	if err := idm.Unmarshal(data, &m); err != nil {
+		return data, nil
+	}
+
+	converted := false
+
+	for _, k := range []int{tagsKey, profileKey} {
+		v, ok := m[k]
+		if !ok || len(v) == 0 || v[0] != cborIndefiniteArray {
+			continue
+		}
+
+		var items []cbor.RawMessage
+		if err := idm.Unmarshal(v, &items); err != nil {
+			return nil, fmt.Errorf("decoding indefinite-length array for key %d: %w", k, err)
+		}
+
+		if m[k], err = em.Marshal(items); err != nil {
+			return nil, err
+		}
+
+		converted = true
+	}
+
+	if !converted {
+		return data, nil
+	}
+
+	return em.Marshal(m)
+}
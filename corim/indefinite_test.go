@@ -0,0 +1,123 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsignedCorim_IndefiniteLengthTags(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, c.SetProfile("http://example.com/indefinite"))
+
+	data, err := c.ToCBORWithOptions(EncodeOptions{IndefiniteLengthTags: true})
+	require.NoError(t, err)
+
+	// key 1, indefinite-length array
+	assert.True(t, bytes.Contains(data, []byte{0x01, 0x9f, 0x59}))
+	assert.Equal(t, byte(0xff), data[bytes.Index(data, []byte{0x01, 0x9f})+2+len(c.Tags[0])+3])
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	assert.True(t, c.Equal(actual))
+
+	decoded, err := UnmarshalUnsignedCorimFromCBOR(data)
+	require.NoError(t, err)
+	assert.True(t, c.Equal(*decoded))
+
+	data, err = c.ToCBORWithOptions(EncodeOptions{IndefiniteLengthTags: true, TaggedProfile: true})
+	require.NoError(t, err)
+
+	actual = UnsignedCorim{}
+	require.NoError(t, actual.FromCBOR(data))
+	assert.True(t, c.Equal(actual))
+}
+
+func TestUnsignedCorim_FromCBOR_indefinite_profile_array(t *testing.T) {
+	profile, err := em.Marshal("http://example.com/indefinite")
+	require.NoError(t, err)
+
+	data, err := em.Marshal(map[int]cbor.RawMessage{
+		0: {0x62, 0x69, 0x64},                             // "id"
+		1: {0x9f, 0xff},                                   // [_ ]
+		3: append(append([]byte{0x9f}, profile...), 0xff), // [_ profile]
+	})
+	require.NoError(t, err)
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	assert.Equal(t, "id", actual.GetID())
+	assert.Empty(t, actual.Tags)
+	require.NotNil(t, actual.Profile)
+	assert.True(t, actual.Profile.IsURI())
+}
+
+func TestUnsignedCorim_FromCBOR_indefinite_elsewhere(t *testing.T) {
+	data := []byte{
+		0xa2,                   // map(2)
+		0x00, 0x62, 0x69, 0x64, // 0: "id"
+		0x01, 0x9f, 0x5f, 0x41, 0x00, 0xff, 0xff, // 1: [_ (_ h'00')]
+	}
+
+	var actual UnsignedCorim
+	assert.Error(t, actual.FromCBOR(data))
+}
+
+func Test_normalizeCorimMap_fast_path(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, c.SetProfile("http://example.com/fast"))
+
+	plain, err := c.ToCBOR()
+	require.NoError(t, err)
+
+	tagged, err := c.ToCBORWithOptions(EncodeOptions{TaggedProfile: true})
+	require.NoError(t, err)
+
+	indefinite, err := c.ToCBORWithOptions(EncodeOptions{IndefiniteLengthTags: true})
+	require.NoError(t, err)
+
+	assert.True(t, isNormalCorimMap(plain))
+	assert.True(t, isNormalCorimMap(testGoodUnsignedCorimCBOR))
+	assert.False(t, isNormalCorimMap(tagged))
+	assert.False(t, isNormalCorimMap(indefinite))
+	assert.False(t, isNormalCorimMap(wrapProfile(t, plain, []byte{0x81}, nil)))
+	assert.False(t, isNormalCorimMap(plain[:len(plain)-1]))
+	assert.False(t, isNormalCorimMap(append(plain, 0x00)))
+	assert.False(t, isNormalCorimMap([]byte{0xbf, 0xff}))
+
+	// normal maps are returned as they are, without being copied
+	out, err := normalizeCorimMap(dm, plain)
+	require.NoError(t, err)
+	assert.True(t, &out[0] == &plain[0])
+
+	for _, data := range [][]byte{tagged, indefinite} {
+		out, err = normalizeCorimMap(dm, data)
+		require.NoError(t, err)
+		assert.True(t, isNormalCorimMap(out))
+
+		var actual UnsignedCorim
+		require.NoError(t, actual.FromCBOR(out))
+		assert.True(t, c.Equal(actual))
+	}
+}
+
+func Test_skipCBORItems_deep_nesting(t *testing.T) {
+	nested := append(bytes.Repeat([]byte{0x81}, 1<<20), 0x00)
+
+	off, ok := skipCBORItems(nested, 0, 1)
+	assert.True(t, ok)
+	assert.Equal(t, len(nested), off)
+
+	_, ok = skipCBORItems(nested[:len(nested)-1], 0, 1)
+	assert.False(t, ok)
+
+	// counts larger than the remaining data
+	_, ok = skipCBORItems([]byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 0, 1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,294 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UnsignedCorimJSONSchema is the JSON Schema of the JSON representation of an
+// unsigned CoRIM (see UnsignedCorim.ToJSON and ValidateJSON).  Unknown
+// top-level and entity members are allowed so that profile extensions can be
+// carried.
+//
+//go:embed schema/unsigned-corim.schema.json
+var UnsignedCorimJSONSchema []byte
+
+// CodeSchemaViolation is the ValidationCode of the problems reported by
+// ValidateJSON
+const CodeSchemaViolation ValidationCode = "schema-violation"
+
+// ValidateJSON checks the supplied JSON document against
+// UnsignedCorimJSONSchema, without decoding it into an UnsignedCorim.  All the
+// violations found are returned, joined, as *ValidationError's whose Path
+// locates the offending member (e.g. "entities[0].roles").
+func ValidateJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	if dec.More() {
+		return errors.New("decoding JSON: unexpected data after the top-level value")
+	}
+
+	var errs []error
+	for _, v := range unsignedCorimSchema.validate("", doc) {
+		errs = append(errs, v)
+	}
+
+	return errors.Join(errs...)
+}
+
+// jsonSchema is the subset of JSON Schema used by UnsignedCorimJSONSchema.
+// Schemas using any other keyword, or a "format" other than "date-time", are
+// rejected by parseJSONSchema, rather than having the keyword silently ignored.
+type jsonSchema struct {
+	// annotations, which do not affect validation
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 schemaTypes            `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	OneOf                []*jsonSchema          `json:"oneOf,omitempty"`
+}
+
+// parseJSONSchema decodes the supplied JSON Schema, and checks that it only
+// uses the keywords and formats that are supported by jsonSchema
+func parseJSONSchema(data []byte) (*jsonSchema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var o jsonSchema
+	if err := dec.Decode(&o); err != nil {
+		return nil, fmt.Errorf("unsupported JSON schema: %w", err)
+	}
+
+	if err := o.check("$"); err != nil {
+		return nil, fmt.Errorf("unsupported JSON schema: %w", err)
+	}
+
+	return &o, nil
+}
+
+func (o jsonSchema) check(path string) error {
+	for _, t := range o.Type {
+		switch t {
+		case "null", "boolean", "string", "integer", "number", "array", "object":
+		default:
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+
+	if o.Format != "" && o.Format != "date-time" {
+		return fmt.Errorf("%s: unsupported format %q", path, o.Format)
+	}
+
+	keys := make([]string, 0, len(o.Properties))
+	for k := range o.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := o.Properties[k]
+		if p == nil {
+			return fmt.Errorf("%s: null schema", memberPath(path, k))
+		}
+		if err := p.check(memberPath(path, k)); err != nil {
+			return err
+		}
+	}
+
+	if o.Items != nil {
+		if err := o.Items.check(path + "[]"); err != nil {
+			return err
+		}
+	}
+
+	for i, s := range o.OneOf {
+		if s == nil {
+			return fmt.Errorf("%s.oneOf[%d]: null schema", path, i)
+		}
+		if err := s.check(fmt.Sprintf("%s.oneOf[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// schemaTypes is the value of the "type" keyword, which can be either a
+// single type name or an array of type names
+type schemaTypes []string
+
+func (o *schemaTypes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*o = schemaTypes{s}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(o))
+}
+
+func (o jsonSchema) validate(path string, v interface{}) []*ValidationError {
+	var errs []*ValidationError
+
+	violation := func(p string, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{
+			Path: rootPath(p),
+			Code: CodeSchemaViolation,
+			Err:  fmt.Errorf(format, args...),
+		})
+	}
+
+	if len(o.Type) != 0 && !o.Type.match(v) {
+		violation(path, "expecting %s, found %s", strings.Join(o.Type, " or "), jsonTypeOf(v))
+		return errs
+	}
+
+	if len(o.OneOf) != 0 {
+		matches := 0
+		for _, s := range o.OneOf {
+			if len(s.validate(path, v)) == 0 {
+				matches++
+			}
+		}
+
+		if matches != 1 {
+			violation(path, "does not match exactly one of the allowed forms")
+			return errs
+		}
+	}
+
+	switch t := v.(type) {
+	case string:
+		if o.Enum != nil && !slices.Contains(o.Enum, t) {
+			violation(path, "%q is not one of %s", t, strings.Join(o.Enum, ", "))
+		}
+
+		if o.MinLength != nil && len(t) < *o.MinLength {
+			violation(path, "shorter than %d characters", *o.MinLength)
+		}
+
+		if o.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, t); err != nil {
+				violation(path, "%q is not an RFC 3339 date-time", t)
+			}
+		}
+	case []interface{}:
+		if o.Items != nil {
+			for i, e := range t {
+				errs = append(errs, o.Items.validate(fmt.Sprintf("%s[%d]", path, i), e)...)
+			}
+		}
+	case map[string]interface{}:
+		for _, k := range o.Required {
+			if _, ok := t[k]; !ok {
+				violation(memberPath(path, k), "required member is missing")
+			}
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			s, ok := o.Properties[k]
+			if !ok {
+				if o.AdditionalProperties != nil && !*o.AdditionalProperties {
+					violation(memberPath(path, k), "unexpected member")
+				}
+				continue
+			}
+
+			errs = append(errs, s.validate(memberPath(path, k), t[k])...)
+		}
+	}
+
+	return errs
+}
+
+func (o schemaTypes) match(v interface{}) bool {
+	actual := jsonTypeOf(v)
+
+	for _, t := range o {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func jsonTypeOf(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func memberPath(path, member string) string {
+	if path == "" {
+		return member
+	}
+
+	return path + "." + member
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "$"
+	}
+
+	return path
+}
+
+var unsignedCorimSchema jsonSchema
+
+func init() {
+	s, err := parseJSONSchema(UnsignedCorimJSONSchema)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded JSON schema: %v", err))
+	}
+
+	unsignedCorimSchema = *s
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJSON_ok(t *testing.T) {
+	assert.NoError(t, ValidateJSON(testUnsignedCorimJSON))
+	assert.NoError(t, ValidateJSON(testUnsignedCorimWithExtensionsJSON))
+
+	notBefore := time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	c := NewTestCorim().
+		SetProfile("1.2.3.4").
+		AddDependentRim("https://example.com/dep.cbor").
+		SetRimValidity(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), &notBefore).
+		AddEntity("ACME Ltd.", nil, RoleManifestCreator)
+	require.NotNil(t, c)
+
+	tp, err := c.Thumbprint(1)
+	require.NoError(t, err)
+	(*c.DependentRims)[0].Thumbprint = tp
+
+	data, err := c.ToJSON()
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidateJSON(data))
+}
+
+func TestValidateJSON_violations(t *testing.T) {
+	data := []byte(`{
+		"tags": [ { "type": "cbor", "value": {} }, 42 ],
+		"dependent-rims": [ { "thumbprint": 1, "hash": "x" } ],
+		"profile": { "type": "oid" },
+		"validity": { "not-after": "tomorrow" },
+		"entities": [ { "name": "", "roles": "manifestCreator" } ]
+	}`)
+
+	err := ValidateJSON(data)
+	require.Error(t, err)
+
+	var codes []ValidationCode
+	var msgs []string
+
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var ve *ValidationError
+		require.True(t, errors.As(e, &ve))
+		codes = append(codes, ve.Code)
+		msgs = append(msgs, ve.Error())
+	}
+
+	assert.Equal(t, []string{
+		"corim-id: required member is missing",
+		"dependent-rims[0].href: required member is missing",
+		"dependent-rims[0].hash: unexpected member",
+		"dependent-rims[0].thumbprint: expecting string or array, found integer",
+		"entities[0].name: does not match exactly one of the allowed forms",
+		"entities[0].roles: expecting array, found string",
+		"profile: does not match exactly one of the allowed forms",
+		"tags[0]: does not match exactly one of the allowed forms",
+		"tags[1]: does not match exactly one of the allowed forms",
+		`validity.not-after: "tomorrow" is not an RFC 3339 date-time`,
+	}, msgs)
+
+	for _, c := range codes {
+		assert.Equal(t, CodeSchemaViolation, c)
+	}
+
+	assert.EqualError(t, ValidateJSON([]byte(`[]`)), "$: expecting object, found array")
+	assert.ErrorContains(t, ValidateJSON([]byte(`{`)), "decoding JSON: ")
+	assert.EqualError(t, ValidateJSON([]byte(`{} {}`)),
+		"decoding JSON: unexpected data after the top-level value")
+}
+
+// jsonFields returns the names of the JSON members of the supplied struct
+// type, and those among them that are not optional
+func jsonFields(typ reflect.Type) (all, required []string) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+
+		tag, ok := f.Tag.Lookup("json")
+		if !ok || tag == "-" || f.Anonymous {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		all = append(all, name)
+
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	sort.Strings(all)
+	sort.Strings(required)
+
+	return all, required
+}
+
+func schemaFields(s *jsonSchema) (all, required []string) {
+	for k := range s.Properties {
+		all = append(all, k)
+	}
+
+	required = append(required, s.Required...)
+
+	sort.Strings(all)
+	sort.Strings(required)
+
+	return all, required
+}
+
+func TestUnsignedCorimJSONSchema_matches_structs(t *testing.T) {
+	props := unsignedCorimSchema.Properties
+
+	for _, tc := range []struct {
+		name   string
+		typ    reflect.Type
+		schema *jsonSchema
+	}{
+		{"unsigned-corim-map", reflect.TypeOf(UnsignedCorim{}), &unsignedCorimSchema},
+		{"corim-locator-map", reflect.TypeOf(locatorJSON{}), props["dependent-rims"].Items},
+		{"validity-map", reflect.TypeOf(Validity{}), props["validity"]},
+		{"corim-entity-map", reflect.TypeOf(Entity{}), props["entities"].Items},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NotNil(t, tc.schema)
+
+			expectedAll, expectedRequired := jsonFields(tc.typ)
+			actualAll, actualRequired := schemaFields(tc.schema)
+
+			assert.Equal(t, expectedAll, actualAll, "properties")
+			assert.Equal(t, expectedRequired, actualRequired, "required")
+		})
+	}
+}
+
+func TestParseJSONSchema(t *testing.T) {
+	// the embedded schema only uses supported keywords
+	s, err := parseJSONSchema(UnsignedCorimJSONSchema)
+	require.NoError(t, err)
+	assert.Equal(t, unsignedCorimSchema, *s)
+
+	for _, tc := range []struct {
+		schema   string
+		expected string
+	}{
+		{`{"type": "string", "pattern": "^a"}`, `unknown field "pattern"`},
+		{`{"properties": {"a": {"$ref": "#/$defs/a"}}}`, `unknown field "$ref"`},
+		{`{"items": {"type": "array", "minItems": 1}}`, `unknown field "minItems"`},
+		{`{"oneOf": [{"type": "string"}, {"const": 1}]}`, `unknown field "const"`},
+		{`{"properties": {"a": {"format": "uri"}}}`, `$.a: unsupported format "uri"`},
+		{`{"items": {"type": "strin"}}`, `$[]: unknown type "strin"`},
+		{`{"properties": {"a": null}}`, `$.a: null schema`},
+		{`{"enum": [1]}`, `cannot unmarshal number`},
+	} {
+		_, err := parseJSONSchema([]byte(tc.schema))
+		assert.ErrorContains(t, err, "unsupported JSON schema: ", tc.schema)
+		assert.ErrorContains(t, err, tc.expected, tc.schema)
+	}
+}
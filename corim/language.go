@@ -0,0 +1,85 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// LanguageKey is the unsigned-corim-map key used to carry the default language
+// of the human-readable strings in the CoRIM.  The base CoRIM map does not
+// define such an entry, so it is carried as a raw extension (see
+// SetExtension) using a key from the negative range, which is reserved for
+// profile specific extensions.
+const LanguageKey int64 = -10
+
+// languageTagRE matches the "langtag" and "privateuse" productions of BCP 47
+// (RFC 5646, Section 2.1).  Irregular grandfathered tags (e.g., "i-klingon")
+// are not accepted.
+var languageTagRE = regexp.MustCompile(
+	`(?i)^(?:` +
+		`(?:[a-z]{2,3}(?:-[a-z]{3}){0,3}|[a-z]{4}|[a-z]{5,8})` + // language
+		`(?:-[a-z]{4})?` + // script
+		`(?:-(?:[a-z]{2}|[0-9]{3}))?` + // region
+		`(?:-(?:[a-z0-9]{5,8}|[0-9][a-z0-9]{3}))*` + // variant
+		`(?:-[0-9a-wy-z](?:-[a-z0-9]{2,8})+)*` + // extension
+		`(?:-x(?:-[a-z0-9]{1,8})+)?` + // privateuse
+		`|x(?:-[a-z0-9]{1,8})+` +
+		`)$`,
+)
+
+// ValidLanguageTag checks that the supplied string is a syntactically valid
+// BCP 47 language tag (e.g., "en-GB")
+func ValidLanguageTag(tag string) error {
+	if tag == "" {
+		return errors.New("empty language tag")
+	}
+
+	if !languageTagRE.MatchString(tag) {
+		return fmt.Errorf("invalid language tag %q", tag)
+	}
+
+	return nil
+}
+
+// SetLanguage sets the default language of the target unsigned CoRIM to the
+// supplied BCP 47 language tag (see LanguageKey)
+func (o *UnsignedCorim) SetLanguage(bcp47 string) *UnsignedCorim {
+	if o != nil {
+		if ValidLanguageTag(bcp47) != nil {
+			return nil
+		}
+		return o.SetExtension(LanguageKey, bcp47)
+	}
+	return o
+}
+
+// GetLanguage returns the default language of the target unsigned CoRIM, or an
+// empty string if none has been set
+func (o UnsignedCorim) GetLanguage() string {
+	var lang string
+	if err := o.GetExtension(LanguageKey, &lang); err != nil {
+		return ""
+	}
+	return lang
+}
+
+func (o UnsignedCorim) validLanguage() error {
+	if _, ok := o.RawExtensions[LanguageKey]; !ok {
+		return nil
+	}
+
+	var lang string
+	if err := o.GetExtension(LanguageKey, &lang); err != nil {
+		return fmt.Errorf("language validation failed: %w", err)
+	}
+
+	if err := ValidLanguageTag(lang); err != nil {
+		return fmt.Errorf("language validation failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidLanguageTag(t *testing.T) {
+	for _, tag := range []string{
+		"en", "en-GB", "EN-gb", "zh-Hant-TW", "sr-Latn-RS", "es-419",
+		"de-CH-1901", "sl-rozaj-biske", "en-US-u-ca-gregory", "zh-cmn-Hans-CN",
+		"en-x-private", "x-whatever",
+	} {
+		assert.NoError(t, ValidLanguageTag(tag), tag)
+	}
+
+	for _, tag := range []string{
+		"en_US", "e", "en-", "toolonglanguage", "en-GB-", "en--GB", "x", "en-x",
+	} {
+		assert.EqualError(t, ValidLanguageTag(tag), `invalid language tag "`+tag+`"`)
+	}
+
+	assert.EqualError(t, ValidLanguageTag(""), "empty language tag")
+}
+
+func TestUnsignedCorim_SetLanguage(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	assert.Equal(t, "", tv.GetLanguage())
+
+	require.NotNil(t, tv.SetLanguage("en-GB"))
+	assert.Equal(t, "en-GB", tv.GetLanguage())
+	assert.NoError(t, tv.Valid())
+
+	assert.Nil(t, tv.SetLanguage("en_US"))
+	assert.Equal(t, "en-GB", tv.GetLanguage())
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	assert.Equal(t, "en-GB", actual.GetLanguage())
+
+	require.NotNil(t, tv.SetExtension(LanguageKey, "en_US"))
+	assert.EqualError(t, tv.Valid(), `language validation failed: invalid language tag "en_US"`)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// tagsKey is the key of the tags entry of the unsigned-corim-map
+const tagsKey = 1
+
+// FromCBORLenient is like FromCBOR, but does not fail if some of the entries
+// of the tags array are damaged.  Entries that are not byte strings holding a
+// well-formed CBOR tag with the number of a CoSWID, CoMID or CoTS are dropped,
+// and their positions in the original tags array are returned in badTags.  The
+// content of the tags is not validated any further, which is left to the caller
+// (see Tag.ValidStrict).  The rest of the unsigned CoRIM, including the good
+// tags, is decoded as usual.  An error is returned if the unsigned-corim-map
+// itself cannot be decoded.
+func (o *UnsignedCorim) FromCBORLenient(data []byte) (badTags []int, err error) {
+	if data, err = normalizeCorimMap(dm, data); err != nil {
+		return nil, err
+	}
+
+	var entries map[int64]cbor.RawMessage
+	if err := dm.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	rawTags, ok := entries[tagsKey]
+	if !ok {
+		return nil, errors.New("missing tags")
+	}
+
+	var items []cbor.RawMessage
+	if err := dm.Unmarshal(rawTags, &items); err != nil {
+		return nil, fmt.Errorf("decoding tags: %w", err)
+	}
+
+	good := make([]Tag, 0, len(items))
+
+	for i, item := range items {
+		var t Tag
+		if err := dm.Unmarshal(item, &t); err != nil || !wellFormedTag(t) {
+			badTags = append(badTags, i)
+			continue
+		}
+		good = append(good, t)
+	}
+
+	if len(badTags) != 0 {
+		if entries[tagsKey], err = em.Marshal(good); err != nil {
+			return nil, err
+		}
+
+		if data, err = em.Marshal(entries); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.fromCBOR(dm, data); err != nil {
+		return nil, err
+	}
+
+	return badTags, nil
+}
+
+// wellFormedTag returns true if the supplied Tag is a well-formed CBOR tag with
+// the number of one of the concise-tag-type-choice alternatives
+func wellFormedTag(t Tag) bool {
+	num, _, err := t.split()
+	if err != nil {
+		return false
+	}
+
+	switch num {
+	case CoswidTagNumber, ComidTagNumber, CotsTagNumber:
+		return true
+	default:
+		return false
+	}
+}
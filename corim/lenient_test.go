@@ -0,0 +1,77 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsignedCorim_FromCBORLenient(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	good := c.Tags[0]
+
+	// a corrupt CoMID, a non-bstr entry and a good tag
+	corrupt, err := em.Marshal(Tag(append(bytes.Clone(ComidTag), 0xa1, 0x00)))
+	require.NoError(t, err)
+	goodItem, err := em.Marshal(good)
+	require.NoError(t, err)
+
+	data, err := em.Marshal(map[int64]cbor.RawMessage{
+		0: {0x62, 0x69, 0x64}, // "id"
+		1: append([]byte{0x84}, append(append(append(bytes.Clone(goodItem), corrupt...), 0x01), goodItem...)...),
+	})
+	require.NoError(t, err)
+
+	var strict UnsignedCorim
+	assert.Error(t, strict.FromCBOR(data))
+
+	var actual UnsignedCorim
+	badTags, err := actual.FromCBORLenient(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, badTags)
+	assert.Equal(t, "id", actual.GetID())
+	assert.Equal(t, []Tag{good, good}, actual.Tags)
+
+	// well-formed tags are kept even if their content is not valid, and
+	// tags of an unknown type are dropped
+	invalid := Tag(append(bytes.Clone(ComidTag), 0xa0)) // empty CoMID
+	require.Error(t, invalid.ValidStrict())
+	invalidItem, err := em.Marshal(invalid)
+	require.NoError(t, err)
+	unknown, err := em.Marshal(Tag(append(TagHeader(508), 0xa0)))
+	require.NoError(t, err)
+
+	data, err = em.Marshal(map[int64]cbor.RawMessage{
+		0: {0x62, 0x69, 0x64}, // "id"
+		1: append([]byte{0x83}, append(append(bytes.Clone(invalidItem), unknown...), goodItem...)...),
+	})
+	require.NoError(t, err)
+
+	actual = UnsignedCorim{}
+	badTags, err = actual.FromCBORLenient(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, badTags)
+	assert.Equal(t, []Tag{invalid, good}, actual.Tags)
+
+	// nothing to salvage
+	actual = UnsignedCorim{}
+	badTags, err = actual.FromCBORLenient(testGoodUnsignedCorimCBOR)
+	require.NoError(t, err)
+	assert.Nil(t, badTags)
+	assert.True(t, c.Equal(actual))
+
+	_, err = actual.FromCBORLenient([]byte{0xa1, 0x00, 0x62, 0x69, 0x64})
+	assert.EqualError(t, err, "missing tags")
+
+	_, err = actual.FromCBORLenient([]byte{0xa2, 0x00, 0x62, 0x69, 0x64, 0x01, 0x00})
+	assert.ErrorContains(t, err, "decoding tags: ")
+
+	_, err = actual.FromCBORLenient([]byte{0x01})
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/veraison/corim/extensions"
+)
+
+var locatorHintValidators = map[int64]func(value []byte) error{}
+
+// RegisterLocatorHint registers a validator for the locator hint with the
+// supplied key.  Locator.Valid only checks hints whose key has been
+// registered; any other hint is ignored.  The validator is passed the CBOR
+// encoding of the hint value.
+func RegisterLocatorHint(key int64, v func(value []byte) error) error {
+	if v == nil {
+		return errors.New("nil locator hint validator")
+	}
+
+	if key == 0 || key == 1 {
+		return fmt.Errorf("locator hint key %d is reserved", key)
+	}
+
+	if _, ok := locatorHintValidators[key]; ok {
+		return fmt.Errorf("validator for locator hint %d already registered", key)
+	}
+
+	locatorHintValidators[key] = v
+
+	return nil
+}
+
+// UnregisterLocatorHint removes the validator registered for the locator hint
+// with the supplied key.  It returns false if there was none.
+func UnregisterLocatorHint(key int64) bool {
+	if _, ok := locatorHintValidators[key]; ok {
+		delete(locatorHintValidators, key)
+		return true
+	}
+
+	return false
+}
+
+// SetLocatorHint CBOR-encodes the supplied value and sets it as the hint with
+// the supplied key in the corim-locator-map.  Keys used by the standard
+// corim-locator-map fields (0 and 1) are rejected.
+func (o *Locator) SetLocatorHint(key int64, value interface{}) *Locator {
+	if o != nil {
+		if key == 0 || key == 1 {
+			return nil
+		}
+
+		data, err := em.Marshal(value)
+		if err != nil {
+			return nil
+		}
+
+		if o.Hints == nil {
+			o.Hints = make(map[int64]cbor.RawMessage)
+		}
+
+		o.Hints[key] = data
+	}
+	return o
+}
+
+// GetLocatorHint decodes the hint with the supplied key into out
+func (o Locator) GetLocatorHint(key int64, out interface{}) error {
+	data, ok := o.Hints[key]
+	if !ok {
+		return fmt.Errorf("%w: %d", extensions.ErrExtensionNotFound, key)
+	}
+
+	return dm.Unmarshal(data, out)
+}
+
+func (o Locator) validHints() error {
+	keys := make([]int64, 0, len(o.Hints))
+	for k := range o.Hints {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, k := range keys {
+		v, ok := locatorHintValidators[k]
+		if !ok {
+			continue
+		}
+
+		if err := v(o.Hints[k]); err != nil {
+			return fmt.Errorf("invalid locator hint %d: %w", k, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/extensions"
+)
+
+func TestLocator_SetLocatorHint_roundtrip(t *testing.T) {
+	l := Locator{Href: "https://example.com/rim.cbor"}
+	require.NotNil(t, l.SetLocatorHint(-1, "oci"))
+	require.NotNil(t, l.SetLocatorHint(2, map[string]string{"registry": "ghcr.io"}))
+
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	c.DependentRims = &[]Locator{l}
+
+	data, err := c.ToCBOR()
+	require.NoError(t, err)
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	require.NotNil(t, actual.DependentRims)
+	require.Len(t, *actual.DependentRims, 1)
+
+	rim := (*actual.DependentRims)[0]
+	assert.Equal(t, l.Href, rim.Href)
+	assert.Nil(t, rim.Thumbprint)
+
+	var transport string
+	require.NoError(t, rim.GetLocatorHint(-1, &transport))
+	assert.Equal(t, "oci", transport)
+
+	var registry map[string]string
+	require.NoError(t, rim.GetLocatorHint(2, &registry))
+	assert.Equal(t, map[string]string{"registry": "ghcr.io"}, registry)
+
+	err = rim.GetLocatorHint(3, &transport)
+	assert.ErrorIs(t, err, extensions.ErrExtensionNotFound)
+
+	// hints do not change the encoding of the standard fields
+	l.Hints = nil
+	other := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	other.DependentRims = &[]Locator{l}
+	require.NoError(t, actual.FromCBOR(mustToCBOR(t, other)))
+	assert.Nil(t, (*actual.DependentRims)[0].Hints)
+}
+
+func TestLocator_SetLocatorHint_reserved(t *testing.T) {
+	l := Locator{Href: "https://example.com/rim.cbor"}
+	assert.Nil(t, l.SetLocatorHint(0, "x"))
+	assert.Nil(t, l.SetLocatorHint(1, "x"))
+	assert.Nil(t, l.Hints)
+}
+
+func TestLocator_Valid_hints(t *testing.T) {
+	l := Locator{Href: "https://example.com/rim.cbor"}
+	require.NotNil(t, l.SetLocatorHint(-70000, "anything"))
+
+	// unregistered hints are ignored
+	assert.NoError(t, l.Valid())
+
+	require.NoError(t, RegisterLocatorHint(-70000, func(value []byte) error {
+		var s string
+		if err := dm.Unmarshal(value, &s); err != nil {
+			return err
+		}
+		if s != "oci" {
+			return errors.New("unsupported transport")
+		}
+		return nil
+	}))
+	defer UnregisterLocatorHint(-70000)
+
+	assert.EqualError(t, l.Valid(), "invalid locator hint -70000: unsupported transport")
+
+	require.NotNil(t, l.SetLocatorHint(-70000, "oci"))
+	assert.NoError(t, l.Valid())
+
+	err := RegisterLocatorHint(-70000, func([]byte) error { return nil })
+	assert.EqualError(t, err, "validator for locator hint -70000 already registered")
+
+	assert.EqualError(t, RegisterLocatorHint(1, func([]byte) error { return nil }),
+		"locator hint key 1 is reserved")
+	assert.EqualError(t, RegisterLocatorHint(-2, nil), "nil locator hint validator")
+	assert.False(t, UnregisterLocatorHint(-2))
+}
+
+func TestUnsignedCorim_Clone_locator_hints(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	l := Locator{Href: "https://example.com/rim.cbor"}
+	require.NotNil(t, l.SetLocatorHint(-1, "oci"))
+	c.DependentRims = &[]Locator{l}
+
+	clone := c.Clone()
+	(*clone.DependentRims)[0].Hints[-1][1] = 'x'
+
+	var transport string
+	require.NoError(t, (*c.DependentRims)[0].GetLocatorHint(-1, &transport))
+	assert.Equal(t, "oci", transport)
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/veraison/corim/comid"
+)
+
+// MatchResult is the outcome of UnsignedCorim.MatchEvidence.  Evidence items
+// are identified by their position in the supplied evidence.
+type MatchResult struct {
+	// Matched lists the evidence items for which a matching reference
+	// value was found
+	Matched []EvidenceMatch
+	// Unmatched lists the evidence items for which reference values with
+	// the same environment and measurement key exist, none of which matches
+	Unmatched []int
+	// UnknownEnvironment lists the evidence items for which there is no
+	// reference value with the same environment and measurement key
+	UnknownEnvironment []int
+}
+
+// EvidenceMatch is an evidence item matched by UnsignedCorim.MatchEvidence,
+// together with the first reference value it matches
+type EvidenceMatch struct {
+	// Evidence is the position of the evidence item
+	Evidence  int
+	Reference MeasurementHit
+}
+
+// MatchEvidence matches each of the supplied evidence measurements, taken from
+// the supplied environment, against the reference values of the contained
+// CoMIDs.  Only reference values whose environment has the same encoding as
+// env are considered.  Within the environment, the measured element is
+// identified by the measurement key (mkey), which must be equal in the
+// evidence and in the reference value (or unset in both).  A reference value
+// matches if every measurement value it carries is also found in the evidence:
+// digests match if the evidence has a digest with the same algorithm and value
+// as any of the reference digests, raw values are compared under the
+// raw-value-mask, if any, a min-svn is satisfied by any higher or equal SVN,
+// and all other values must be identical.  Endorsed values are not considered.
+// An error is returned if any of the CoMIDs cannot be decoded.
+func (o UnsignedCorim) MatchEvidence(
+	env comid.Environment,
+	evidence []comid.Measurement,
+) (MatchResult, error) {
+	var res MatchResult
+
+	all, err := o.FindMeasurements(func(comid.Measurement) bool { return true })
+	if err != nil {
+		return res, err
+	}
+
+	var refs []MeasurementHit
+
+	for _, ref := range all {
+		if ref.Triples != "reference-values" {
+			continue
+		}
+
+		ok, err := sameEncoding(ref.Environment, env)
+		if err != nil {
+			return res, fmt.Errorf("environment: %w", err)
+		} else if ok {
+			refs = append(refs, ref)
+		}
+	}
+
+	for i, ev := range evidence {
+		known, matched := false, false
+
+		for _, ref := range refs {
+			ok, err := sameMkey(ref.Measurement.Key, ev.Key)
+			if err != nil {
+				return res, fmt.Errorf("evidence at pos %d: %w", i, err)
+			} else if !ok {
+				continue
+			}
+
+			known = true
+
+			if ok, err = mvalMatches(ref.Measurement.Val, ev.Val); err != nil {
+				return res, fmt.Errorf("evidence at pos %d: %w", i, err)
+			} else if ok {
+				res.Matched = append(res.Matched, EvidenceMatch{Evidence: i, Reference: ref})
+				matched = true
+				break
+			}
+		}
+
+		switch {
+		case matched:
+		case known:
+			res.Unmatched = append(res.Unmatched, i)
+		default:
+			res.UnknownEnvironment = append(res.UnknownEnvironment, i)
+		}
+	}
+
+	return res, nil
+}
+
+func sameMkey(a, b *comid.Mkey) (bool, error) {
+	aSet, bSet := a != nil && a.IsSet(), b != nil && b.IsSet()
+
+	if !aSet || !bSet {
+		return aSet == bSet, nil
+	}
+
+	return sameEncoding(a, b)
+}
+
+func mvalMatches(ref, ev comid.Mval) (bool, error) {
+	if ref.Digests != nil && !digestsMatch(*ref.Digests, ev.Digests) {
+		return false, nil
+	}
+
+	if ref.RawValue != nil && !rawValueMatches(*ref.RawValue, ref.RawValueMask, ev.RawValue) {
+		return false, nil
+	}
+
+	for _, f := range []func() (bool, error){
+		func() (bool, error) { return fieldMatches(ref.Ver, ev.Ver) },
+		func() (bool, error) { return svnMatches(ref.SVN, ev.SVN) },
+		func() (bool, error) { return fieldMatches(ref.Flags, ev.Flags) },
+		func() (bool, error) { return fieldMatches(ref.MACAddr, ev.MACAddr) },
+		func() (bool, error) { return fieldMatches(ref.IPAddr, ev.IPAddr) },
+		func() (bool, error) { return fieldMatches(ref.SerialNumber, ev.SerialNumber) },
+		func() (bool, error) { return fieldMatches(ref.UEID, ev.UEID) },
+		func() (bool, error) { return fieldMatches(ref.UUID, ev.UUID) },
+		func() (bool, error) { return fieldMatches(ref.IntegrityRegisters, ev.IntegrityRegisters) },
+	} {
+		if ok, err := f(); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// fieldMatches returns true if ref is unset, or if ev is set and has the same
+// encoding
+func fieldMatches[T any](ref, ev *T) (bool, error) {
+	if ref == nil {
+		return true, nil
+	}
+
+	if ev == nil {
+		return false, nil
+	}
+
+	return sameEncoding(*ref, *ev)
+}
+
+// svnMatches returns true if ref is unset, or if ev satisfies it: an exact
+// svn reference requires the same exact svn, while a min-svn reference requires
+// an svn, or min-svn, no lower than its own.  SVN types other than the
+// standard ones must have the same encoding.
+func svnMatches(ref, ev *comid.SVN) (bool, error) {
+	if ref == nil {
+		return true, nil
+	}
+
+	if ev == nil {
+		return false, nil
+	}
+
+	r, refMin, ok := svnValue(*ref)
+	if !ok {
+		return sameEncoding(*ref, *ev)
+	}
+
+	e, evMin, ok := svnValue(*ev)
+	if !ok {
+		return false, nil
+	}
+
+	if refMin {
+		return e >= r, nil
+	}
+
+	return !evMin && e == r, nil
+}
+
+// svnValue returns the numeric value of the supplied SVN, and whether it is a
+// min-svn.  ok is false if the SVN is not of one of the standard types.
+func svnValue(svn comid.SVN) (v uint64, isMin bool, ok bool) {
+	switch t := svn.Value.(type) {
+	case comid.TaggedSVN:
+		return uint64(t), false, true
+	case *comid.TaggedSVN:
+		return uint64(*t), false, true
+	case comid.TaggedMinSVN:
+		return uint64(t), true, true
+	case *comid.TaggedMinSVN:
+		return uint64(*t), true, true
+	default:
+		return 0, false, false
+	}
+}
+
+func digestsMatch(ref comid.Digests, ev *comid.Digests) bool {
+	if ev == nil {
+		return false
+	}
+
+	for _, e := range *ev {
+		for i := range ref {
+			if thumbprintMatches(&ref[i], e.HashAlgID, e.HashValue) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func rawValueMatches(ref comid.RawValue, mask *[]byte, ev *comid.RawValue) bool {
+	if ev == nil {
+		return false
+	}
+
+	r, err := ref.GetBytes()
+	if err != nil {
+		return false
+	}
+
+	e, err := ev.GetBytes()
+	if err != nil || len(r) != len(e) {
+		return false
+	}
+
+	if mask == nil {
+		return bytes.Equal(r, e)
+	}
+
+	if len(*mask) != len(r) {
+		return false
+	}
+
+	for i, m := range *mask {
+		if r[i]&m != e[i]&m {
+			return false
+		}
+	}
+
+	return true
+}
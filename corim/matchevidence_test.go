@@ -0,0 +1,171 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+func TestUnsignedCorim_MatchEvidence(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	c, err := tv.ComidAt(0)
+	require.NoError(t, err)
+	refs := c.Triples.ReferenceValues.Values[0].Measurements.Values
+
+	// exact copy of the BL reference value
+	matching := refs[0]
+
+	// PRoT with a different digest
+	mismatching := refs[1]
+	mismatching.Val.Digests = &comid.Digests{
+		{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)},
+	}
+
+	// ARoT measured with two algorithms, one of which matches
+	multi := refs[2]
+	multi.Val.Digests = &comid.Digests{
+		{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)},
+		(*refs[2].Val.Digests)[0],
+	}
+
+	// a component the CoRIM knows nothing about
+	unknown, err := comid.NewUintMeasurement(uint64(99))
+	require.NoError(t, err)
+	require.NotNil(t, unknown.AddDigest(swid.Sha256, make([]byte, 32)))
+
+	env := c.Triples.ReferenceValues.Values[0].Environment
+	evidence := []comid.Measurement{matching, mismatching, *unknown, multi}
+
+	res, err := tv.MatchEvidence(env, evidence)
+	require.NoError(t, err)
+
+	require.Len(t, res.Matched, 2)
+	assert.Equal(t, 0, res.Matched[0].Evidence)
+	assert.Equal(t, "reference-values", res.Matched[0].Reference.Triples)
+	assert.Equal(t, 0, res.Matched[0].Reference.MeasurementIndex)
+	assert.Equal(t, c.Triples.ReferenceValues.Values[0].Environment, res.Matched[0].Reference.Environment)
+	assert.Equal(t, 3, res.Matched[1].Evidence)
+	assert.Equal(t, 2, res.Matched[1].Reference.MeasurementIndex)
+	assert.Equal(t, []int{1}, res.Unmatched)
+	assert.Equal(t, []int{2}, res.UnknownEnvironment)
+
+	// the same evidence from another environment matches nothing
+	other := env
+	vendor := "Other Vendor"
+	other.Class = &comid.Class{Vendor: &vendor}
+
+	res, err = tv.MatchEvidence(other, evidence)
+	require.NoError(t, err)
+	assert.Empty(t, res.Matched)
+	assert.Empty(t, res.Unmatched)
+	assert.Equal(t, []int{0, 1, 2, 3}, res.UnknownEnvironment)
+
+	// keyless reference values only match evidence from their own
+	// environment
+	keyless := matching
+	keyless.Key = nil
+
+	oc := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	require.NotNil(t, oc.SetTagIdentity("other tag", 0))
+	oc.Triples.ReferenceValues.Values[0].Environment = other
+	oc.Triples.ReferenceValues.Values[0].Measurements.Values = []comid.Measurement{keyless}
+	require.NotNil(t, tv.AddComid(oc))
+
+	res, err = tv.MatchEvidence(env, []comid.Measurement{keyless})
+	require.NoError(t, err)
+	assert.Empty(t, res.Matched)
+	assert.Equal(t, []int{0}, res.UnknownEnvironment)
+
+	res, err = tv.MatchEvidence(other, []comid.Measurement{keyless})
+	require.NoError(t, err)
+	require.Len(t, res.Matched, 1)
+	assert.Equal(t, other, res.Matched[0].Reference.Environment)
+
+	res, err = tv.MatchEvidence(env, nil)
+	require.NoError(t, err)
+	assert.Equal(t, MatchResult{}, res)
+}
+
+func Test_mvalMatches(t *testing.T) {
+	var ref, ev comid.Measurement
+
+	require.NotNil(t, ref.SetRawValueBytes([]byte{0x12, 0x34}, []byte{0xff, 0x00}).SetSVN(2))
+	require.NotNil(t, ev.SetRawValueBytes([]byte{0x12, 0x99}, nil).SetSVN(2))
+
+	ok, err := mvalMatches(ref.Val, ev.Val)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the masked byte differs
+	ev = comid.Measurement{}
+	require.NotNil(t, ev.SetRawValueBytes([]byte{0x13, 0x34}, nil).SetSVN(2))
+
+	ok, err = mvalMatches(ref.Val, ev.Val)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// the SVN is missing from the evidence
+	ev = comid.Measurement{}
+	require.NotNil(t, ev.SetRawValueBytes([]byte{0x12, 0x34}, nil))
+
+	ok, err = mvalMatches(ref.Val, ev.Val)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func Test_svnMatches(t *testing.T) {
+	exact := func(v uint64) *comid.SVN { return comid.MustNewTaggedSVN(v) }
+	minSVN := func(v uint64) *comid.SVN { return comid.MustNewTaggedMinSVN(v) }
+
+	for _, tc := range []struct {
+		name     string
+		ref, ev  *comid.SVN
+		expected bool
+	}{
+		{"no reference", nil, exact(1), true},
+		{"no evidence", exact(1), nil, false},
+		{"svn vs same svn", exact(5), exact(5), true},
+		{"svn vs other svn", exact(5), exact(6), false},
+		{"svn vs min-svn", exact(5), minSVN(5), false},
+		{"min-svn vs higher svn", minSVN(3), exact(5), true},
+		{"min-svn vs equal svn", minSVN(3), exact(3), true},
+		{"min-svn vs lower svn", minSVN(3), exact(2), false},
+		{"min-svn vs higher min-svn", minSVN(3), minSVN(4), true},
+		{"min-svn vs lower min-svn", minSVN(3), minSVN(2), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := svnMatches(tc.ref, tc.ev)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ok)
+		})
+	}
+}
+
+func TestUnsignedCorim_MatchEvidence_min_svn(t *testing.T) {
+	c := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	vt := &c.Triples.ReferenceValues.Values[0]
+
+	ref := vt.Measurements.Values[0]
+	ref.Val = comid.Mval{SVN: comid.MustNewTaggedMinSVN(uint64(3))}
+	vt.Measurements.Values = []comid.Measurement{ref}
+
+	tv := NewUnsignedCorim().SetID("test corim id").AddComid(c)
+	require.NotNil(t, tv)
+
+	higher, lower := ref, ref
+	higher.Val = comid.Mval{SVN: comid.MustNewTaggedSVN(uint64(5))}
+	lower.Val = comid.Mval{SVN: comid.MustNewTaggedSVN(uint64(2))}
+
+	res, err := tv.MatchEvidence(vt.Environment, []comid.Measurement{higher, lower})
+	require.NoError(t, err)
+	require.Len(t, res.Matched, 1)
+	assert.Equal(t, 0, res.Matched[0].Evidence)
+	assert.Equal(t, []int{1}, res.Unmatched)
+}
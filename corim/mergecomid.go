@@ -0,0 +1,216 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/veraison/corim/comid"
+)
+
+// MergeComid merges the triples of the supplied CoMID into the CoMID, found in
+// the tags array of the unsigned-corim-map, that has the same tag-id.  Value
+// triples (reference and endorsed values) and key triples with the same
+// environment are merged into a single triple, and measurements or keys
+// identical to existing ones are not duplicated.  All other parts of the
+// existing CoMID are left unchanged.  The merged CoMID is re-encoded in place.
+// If no CoMID with a matching tag-id is found, the supplied CoMID is appended
+// as with AddComid.
+func (o *UnsignedCorim) MergeComid(c comid.Comid) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
+
+	if err := c.Valid(); err != nil {
+		return fmt.Errorf("invalid CoMID: %w", err)
+	}
+
+	for i, t := range o.Tags {
+		if !bytes.HasPrefix(t, ComidTag) {
+			continue
+		}
+
+		var existing comid.Comid
+		if err := existing.FromCBOR(t[len(ComidTag):]); err != nil {
+			return fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+		}
+
+		if existing.TagIdentity.TagID != c.TagIdentity.TagID {
+			continue
+		}
+
+		if err := mergeTriples(&existing.Triples, c.Triples); err != nil {
+			return fmt.Errorf("merging CoMID at pos %d: %w", i, err)
+		}
+
+		if err := existing.Valid(); err != nil {
+			return fmt.Errorf("invalid merged CoMID: %w", err)
+		}
+
+		comidCBOR, err := existing.ToCBOR()
+		if err != nil {
+			return fmt.Errorf("encoding merged CoMID: %w", err)
+		}
+
+		o.Tags[i] = append(TagHeader(ComidTagNumber), comidCBOR...)
+
+		return nil
+	}
+
+	return o.AddComidErr(c)
+}
+
+func mergeTriples(dst *comid.Triples, src comid.Triples) error {
+	var err error
+
+	if dst.ReferenceValues, err = mergeValueTriples(dst.ReferenceValues, src.ReferenceValues); err != nil {
+		return fmt.Errorf("reference values: %w", err)
+	}
+
+	if dst.EndorsedValues, err = mergeValueTriples(dst.EndorsedValues, src.EndorsedValues); err != nil {
+		return fmt.Errorf("endorsed values: %w", err)
+	}
+
+	if dst.DevIdentityKeys, err = mergeKeyTriples(dst.DevIdentityKeys, src.DevIdentityKeys); err != nil {
+		return fmt.Errorf("device identity keys: %w", err)
+	}
+
+	if dst.AttestVerifKeys, err = mergeKeyTriples(dst.AttestVerifKeys, src.AttestVerifKeys); err != nil {
+		return fmt.Errorf("attestation verification keys: %w", err)
+	}
+
+	return nil
+}
+
+func mergeValueTriples(dst, src *comid.ValueTriples) (*comid.ValueTriples, error) {
+	if src == nil || len(src.Values) == 0 {
+		return dst, nil
+	}
+
+	if dst == nil {
+		dst = comid.NewValueTriples()
+	}
+
+	for _, vt := range src.Values {
+		i, err := findEnvironment(len(dst.Values), func(i int) comid.Environment {
+			return dst.Values[i].Environment
+		}, vt.Environment)
+		if err != nil {
+			return nil, err
+		}
+
+		if i < 0 {
+			dst.Values = append(dst.Values, comid.ValueTriple{Environment: vt.Environment})
+			i = len(dst.Values) - 1
+		}
+
+		target := &dst.Values[i].Measurements
+
+		for _, m := range vt.Measurements.Values {
+			j, err := indexEncoded(target.Values, m)
+			if err != nil {
+				return nil, err
+			}
+
+			if j < 0 {
+				target.Values = append(target.Values, m)
+			}
+		}
+	}
+
+	return dst, nil
+}
+
+func mergeKeyTriples(dst, src *comid.KeyTriples) (*comid.KeyTriples, error) {
+	if src == nil || len(*src) == 0 {
+		return dst, nil
+	}
+
+	if dst == nil {
+		dst = comid.NewKeyTriples()
+	}
+
+	for _, kt := range *src {
+		i, err := findEnvironment(len(*dst), func(i int) comid.Environment {
+			return (*dst)[i].Environment
+		}, kt.Environment)
+		if err != nil {
+			return nil, err
+		}
+
+		if i < 0 {
+			*dst = append(*dst, comid.KeyTriple{Environment: kt.Environment})
+			i = len(*dst) - 1
+		}
+
+		target := &(*dst)[i].VerifKeys
+
+		for _, k := range kt.VerifKeys {
+			j, err := indexEncoded(*target, k)
+			if err != nil {
+				return nil, err
+			}
+
+			if j < 0 {
+				*target = append(*target, k)
+			}
+		}
+	}
+
+	return dst, nil
+}
+
+// findEnvironment returns the index of the first of the n environments
+// returned by get that has the same encoding as env, or -1 if there is none
+func findEnvironment(n int, get func(int) comid.Environment, env comid.Environment) (int, error) {
+	for i := 0; i < n; i++ {
+		same, err := sameEncoding(get(i), env)
+		if err != nil {
+			return -1, err
+		}
+
+		if same {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// indexEncoded returns the index of the first of the supplied values that has
+// the same encoding as v, or -1 if there is none
+func indexEncoded[T any](values []T, v T) (int, error) {
+	for i := range values {
+		same, err := sameEncoding(values[i], v)
+		if err != nil {
+			return -1, err
+		}
+
+		if same {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// sameEncoding reports whether a and b have the same canonical CBOR encoding
+func sameEncoding(a, b any) (bool, error) {
+	var canonical [2][]byte
+
+	for i, v := range []any{a, b} {
+		data, err := em.Marshal(v)
+		if err != nil {
+			return false, err
+		}
+
+		if canonical[i], err = canonicalCBOR(data); err != nil {
+			return false, err
+		}
+	}
+
+	return bytes.Equal(canonical[0], canonical[1]), nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestUnsignedCorim_MergeComid(t *testing.T) {
+	orig := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	keys := comidFromJSON(t, comid.PSAKeysJSONTemplate)
+
+	tv := NewUnsignedCorim().SetID("test corim id").AddComid(orig).AddComid(keys)
+	require.NotNil(t, tv)
+
+	// same tag-id: the existing measurements, a new one for the same
+	// environment, a new environment and some keys
+	update := comidFromJSON(t, comid.PSARefValJSONTemplate)
+
+	vt := &update.Triples.ReferenceValues.Values[0]
+	newMeasurement := vt.Measurements.Values[0]
+	newMeasurement.Key = nil
+	newMeasurement.SetSVN(42)
+	vt.Measurements.Values = append(vt.Measurements.Values, newMeasurement)
+
+	otherEnv := comidFromJSON(t, comid.PSARefValJSONTemplate).Triples.ReferenceValues.Values[0]
+	vendor := "Other Vendor"
+	otherEnv.Environment.Class.Vendor = &vendor
+	update.Triples.ReferenceValues.Values = append(update.Triples.ReferenceValues.Values, otherEnv)
+
+	update.Triples.AttestVerifKeys = keys.Triples.AttestVerifKeys
+
+	require.NoError(t, tv.MergeComid(update))
+	require.Len(t, tv.Tags, 2)
+
+	merged, err := tv.ComidAt(0)
+	require.NoError(t, err)
+	assert.Equal(t, orig.TagIdentity, merged.TagIdentity)
+
+	rvs := merged.Triples.ReferenceValues.Values
+	require.Len(t, rvs, 2)
+	assert.Len(t, rvs[0].Measurements.Values, len(orig.Triples.ReferenceValues.Values[0].Measurements.Values)+1)
+	assert.Equal(t, newMeasurement.Val.SVN, rvs[0].Measurements.Values[3].Val.SVN)
+	assert.Equal(t, "Other Vendor", *rvs[1].Environment.Class.Vendor)
+	require.NotNil(t, merged.Triples.AttestVerifKeys)
+	assert.Equal(t, len(*keys.Triples.AttestVerifKeys), len(*merged.Triples.AttestVerifKeys))
+
+	// merging the same update again is a no-op
+	before := append([]Tag(nil), tv.Tags...)
+	require.NoError(t, tv.MergeComid(update))
+	again, err := tv.ComidAt(0)
+	require.NoError(t, err)
+	assert.Equal(t, merged, again)
+	assert.Equal(t, before[1], tv.Tags[1])
+
+	// unknown tag-id: appended
+	other := comidFromJSON(t, comid.CCARealmRefValJSONTemplate)
+	require.NoError(t, tv.MergeComid(other))
+	assert.Len(t, tv.Tags, 3)
+	assert.Equal(t, 3, tv.NumComids())
+}
+
+func TestUnsignedCorim_MergeComid_errors(t *testing.T) {
+	tv := NewUnsignedCorim()
+
+	assert.ErrorContains(t, tv.MergeComid(comid.Comid{}), "invalid CoMID: ")
+
+	tv.Tags = []Tag{append(append(Tag{}, ComidTag...), 0xff)}
+	err := tv.MergeComid(comidFromJSON(t, comid.PSARefValJSONTemplate))
+	assert.ErrorContains(t, err, "decoding CoMID at pos 0: ")
+
+	var nilCorim *UnsignedCorim
+	assert.EqualError(t, nilCorim.MergeComid(comid.Comid{}), "nil UnsignedCorim")
+}
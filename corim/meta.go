@@ -9,15 +9,26 @@ import (
 	"time"
 
 	"github.com/veraison/corim/extensions"
+	"github.com/veraison/eat"
 )
 
 // Meta stores a corim-meta-map with JSON and CBOR serializations.  It carries
 // information about the CoRIM signer and, optionally, a validity period
 // associated with the signed assertion.  A corim-meta-map is serialized to CBOR
 // and added to the protected header structure in the signed-corim as a byte string
+//
+// Signers is not part of the base corim-meta-map.  It optionally records an
+// ordered list of all the entities involved in signing the CoRIM, together
+// with their roles, for pipelines where more than one authority is involved.
+//
+// Profile is not part of the base corim-meta-map either.  When signing, it is
+// set to the profile of the unsigned CoRIM so that verifiers can select a key
+// or profile before parsing the payload.
 type Meta struct {
-	Signer   Signer    `cbor:"0,keyasint" json:"signer"`
-	Validity *Validity `cbor:"1,keyasint,omitempty" json:"validity,omitempty"`
+	Signer   Signer       `cbor:"0,keyasint" json:"signer"`
+	Validity *Validity    `cbor:"1,keyasint,omitempty" json:"validity,omitempty"`
+	Signers  *Entities    `cbor:"2,keyasint,omitempty" json:"signers,omitempty"`
+	Profile  *eat.Profile `cbor:"3,keyasint,omitempty" json:"profile,omitempty"`
 }
 
 func NewMeta() *Meta {
@@ -56,6 +67,29 @@ func (o *Meta) SetSigner(name string, uri *string) *Meta {
 	return o
 }
 
+// AddSigner appends an entity with the supplied name and roles to the list of
+// signers in the target Meta
+func (o *Meta) AddSigner(name string, roles ...Role) *Meta {
+	if o != nil {
+		e := NewEntity().
+			SetName(name).
+			SetRoles(roles...)
+
+		if e == nil {
+			return nil
+		}
+
+		if o.Signers == nil {
+			o.Signers = NewEntities()
+		}
+
+		if o.Signers.Add(e) == nil {
+			return nil
+		}
+	}
+	return o
+}
+
 // SetValidity sets the validity period of the target Meta to the supplied time
 // range
 func (o *Meta) SetValidity(notAfter time.Time, notBefore *time.Time) *Meta {
@@ -82,6 +116,22 @@ func (o Meta) Valid() error {
 		}
 	}
 
+	if o.Signers != nil {
+		if err := o.Signers.Valid(); err != nil {
+			return fmt.Errorf("invalid signers: %w", err)
+		}
+
+		if !o.Signers.hasRole(RoleManifestCreator) {
+			return fmt.Errorf("invalid signers: no entity with role %s", RoleManifestCreator)
+		}
+	}
+
+	if o.Profile != nil {
+		if err := ValidProfile(*o.Profile); err != nil {
+			return fmt.Errorf("invalid profile: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -216,3 +216,38 @@ func Test_meta_Valid(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid validity: invalid not-before / not-after")
 }
+
+func TestMeta_AddSigner(t *testing.T) {
+	// the error is ignored, as the role may already exist if the test is
+	// run more than once
+	_ = RegisterRole(100, "releaseAuthority")
+	releaseAuthority := Role(100)
+
+	tv := NewMeta().
+		SetSigner("ACME Ltd.", nil).
+		AddSigner("ACME Build System", RoleManifestCreator).
+		AddSigner("ACME Release Authority", releaseAuthority)
+	require.NotNil(t, tv)
+	require.Len(t, tv.Signers.Values, 2)
+	assert.Equal(t, "ACME Build System", tv.Signers.Values[0].Name.String())
+	assert.Equal(t, Roles{releaseAuthority}, tv.Signers.Values[1].Roles)
+
+	assert.NoError(t, tv.Valid())
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	var actual Meta
+	require.NoError(t, actual.FromCBOR(data))
+	assert.Equal(t, tv.Signers.Values, actual.Signers.Values)
+
+	tv = NewMeta().
+		SetSigner("ACME Ltd.", nil).
+		AddSigner("ACME Release Authority", releaseAuthority)
+	require.NotNil(t, tv)
+
+	assert.EqualError(t, tv.Valid(), "invalid signers: no entity with role manifestCreator")
+
+	assert.Nil(t, NewMeta().AddSigner("", RoleManifestCreator))
+	assert.Nil(t, NewMeta().AddSigner("ACME Ltd.", Role(666)))
+}
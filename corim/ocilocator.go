@@ -0,0 +1,110 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/veraison/swid"
+)
+
+// OCIScheme is the URI scheme of locator hrefs that reference a dependent RIM
+// distributed as an OCI artifact, e.g.:
+//
+//	oci://registry.example/repo@sha256:<hex digest>
+const OCIScheme = "oci"
+
+// ociDigestAlgorithms maps the OCI digest algorithms to the corresponding
+// hash algorithm IDs
+var ociDigestAlgorithms = map[string]uint64{
+	"sha256": swid.Sha256,
+	"sha512": swid.Sha512,
+}
+
+// OCIReference returns the digest-pinned OCI reference (i.e., the href without
+// the "oci://" prefix, e.g. "registry.example/repo@sha256:...") of the target
+// locator.  It returns false if the href is not a valid oci URI.
+func (o Locator) OCIReference() (string, bool) {
+	ref, _, _, err := o.parseOCI()
+	if err != nil {
+		return "", false
+	}
+
+	return ref, true
+}
+
+// parseOCI returns the OCI reference carried by the href of the target
+// locator, together with the algorithm and value of its digest
+func (o Locator) parseOCI() (string, uint64, []byte, error) {
+	u, err := url.Parse(string(o.Href))
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("invalid locator href: %w", err)
+	}
+
+	if !strings.EqualFold(u.Scheme, OCIScheme) {
+		return "", 0, nil, fmt.Errorf("locator href scheme %q is not %q", u.Scheme, OCIScheme)
+	}
+
+	if u.Host == "" || u.Path == "" || u.Path == "/" {
+		return "", 0, nil, errors.New("OCI reference must include a registry and a repository")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", 0, nil, errors.New("OCI reference must not include userinfo, query or fragment")
+	}
+
+	ref := u.Host + u.Path
+
+	repo, digest, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "@")
+	if !ok {
+		return "", 0, nil, errors.New("OCI reference is not pinned by digest")
+	}
+
+	if repo == "" {
+		return "", 0, nil, errors.New("OCI reference must include a registry and a repository")
+	}
+
+	name, encoded, ok := strings.Cut(digest, ":")
+	if !ok {
+		return "", 0, nil, fmt.Errorf("malformed OCI digest %q", digest)
+	}
+
+	alg, ok := ociDigestAlgorithms[name]
+	if !ok {
+		return "", 0, nil, fmt.Errorf("unsupported OCI digest algorithm %q", name)
+	}
+
+	value, err := hex.DecodeString(encoded)
+	if err != nil || encoded != strings.ToLower(encoded) {
+		return "", 0, nil, fmt.Errorf("malformed OCI digest %q: expecting lower-case hex", digest)
+	}
+
+	if err := swid.ValidHashEntry(alg, value); err != nil {
+		return "", 0, nil, fmt.Errorf("malformed OCI digest %q: %w", digest, err)
+	}
+
+	return ref, alg, value, nil
+}
+
+// validOCI checks the oci href of the target locator and, where the locator
+// has a thumbprint computed with the same algorithm as the OCI digest, that
+// the two match
+func (o Locator) validOCI() error {
+	_, alg, value, err := o.parseOCI()
+	if err != nil {
+		return err
+	}
+
+	for _, tp := range o.Thumbprints() {
+		if tp.HashAlgID == alg && !thumbprintMatches(&tp, alg, value) {
+			return errors.New("OCI digest does not match the locator thumbprint")
+		}
+	}
+
+	return nil
+}
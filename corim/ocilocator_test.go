@@ -0,0 +1,86 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+func TestLocator_OCIReference(t *testing.T) {
+	digest := sha256.Sum256([]byte("dependent RIM"))
+	pinned := "registry.example:5000/acme/rims@sha256:" + hex.EncodeToString(digest[:])
+
+	l := Locator{Href: comid.TaggedURI("oci://" + pinned)}
+
+	ref, ok := l.OCIReference()
+	assert.True(t, ok)
+	assert.Equal(t, pinned, ref)
+	assert.NoError(t, l.Valid())
+
+	l.Href = "https://example.com/dep.cbor"
+	_, ok = l.OCIReference()
+	assert.False(t, ok)
+}
+
+func TestLocator_Valid_OCI(t *testing.T) {
+	digest := sha256.Sum256([]byte("dependent RIM"))
+	hexDigest := hex.EncodeToString(digest[:])
+	href := comid.TaggedURI("oci://registry.example/acme/rims@sha256:" + hexDigest)
+
+	// matching thumbprint
+	l := Locator{
+		Href:       href,
+		Thumbprint: &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: digest[:]},
+	}
+	assert.NoError(t, l.Valid())
+
+	// thumbprint computed with a different algorithm is not cross-checked
+	l.Thumbprint = &swid.HashEntry{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)}
+	assert.NoError(t, l.Valid())
+
+	// alternative thumbprints are also cross-checked
+	l.AltThumbprints = []swid.HashEntry{{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)}}
+	assert.EqualError(t, l.Valid(), "invalid OCI locator: OCI digest does not match the locator thumbprint")
+
+	l.AltThumbprints = nil
+	l.Thumbprint = &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)}
+	assert.EqualError(t, l.Valid(), "invalid OCI locator: OCI digest does not match the locator thumbprint")
+
+	for _, tc := range []struct {
+		href     string
+		expected string
+	}{
+		{"oci://registry.example/acme/rims:v1", "OCI reference is not pinned by digest"},
+		{"oci://registry.example/acme/rims@sha512:" + strings.Repeat("ab", 64), ""},
+		{"oci://registry.example/@sha256:" + hexDigest, "OCI reference must include a registry and a repository"},
+		{"oci:///acme/rims@sha256:" + hexDigest, "OCI reference must include a registry and a repository"},
+		{"oci://registry.example/acme/rims@md5:00", `unsupported OCI digest algorithm "md5"`},
+		{"oci://registry.example/acme/rims@sha256", `malformed OCI digest "sha256"`},
+		{"oci://registry.example/acme/rims@sha256:zz", `malformed OCI digest "sha256:zz": expecting lower-case hex`},
+		{"oci://registry.example/acme/rims@sha256:" + strings.ToUpper(hexDigest), "expecting lower-case hex"},
+		{"oci://registry.example/acme/rims@sha256:00", `malformed OCI digest "sha256:00": `},
+		{"oci://registry.example/acme/rims@sha256:" + hexDigest + "?x=1", "OCI reference must not include userinfo, query or fragment"},
+	} {
+		l := Locator{Href: comid.TaggedURI(tc.href)}
+
+		_, ok := l.OCIReference()
+
+		if tc.expected == "" {
+			assert.True(t, ok, tc.href)
+			assert.NoError(t, l.Valid(), tc.href)
+			continue
+		}
+
+		assert.False(t, ok, tc.href)
+		assert.ErrorContains(t, l.Valid(), "invalid OCI locator: ", tc.href)
+		assert.ErrorContains(t, l.Valid(), tc.expected, tc.href)
+	}
+}
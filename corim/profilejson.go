@@ -0,0 +1,158 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/veraison/corim/encoding"
+	"github.com/veraison/eat"
+)
+
+const (
+	ProfileTypeOID = "oid"
+	ProfileTypeURI = "uri"
+)
+
+// ProfileKind is the kind of an EAT profile: an OID or a URI
+type ProfileKind int
+
+const (
+	ProfileKindOID ProfileKind = iota + 1
+	ProfileKindURI
+)
+
+// String returns the JSON type name of the profile kind (ProfileTypeOID or
+// ProfileTypeURI)
+func (o ProfileKind) String() string {
+	switch o {
+	case ProfileKindOID:
+		return ProfileTypeOID
+	case ProfileKindURI:
+		return ProfileTypeURI
+	default:
+		return fmt.Sprintf("ProfileKind(%d)", int(o))
+	}
+}
+
+// DetectProfileKind returns the kind of the supplied profile string, using the
+// same rules as SetProfile and ValidProfile.  An error is returned if the
+// string is neither a dotted-decimal OID nor a URI.
+func DetectProfileKind(s string) (ProfileKind, error) {
+	p, err := eat.NewProfile(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid profile %q: %w", s, err)
+	}
+
+	switch {
+	case p.IsOID():
+		return ProfileKindOID, nil
+	case p.IsURI():
+		return ProfileKindURI, nil
+	default:
+		return 0, fmt.Errorf("invalid profile %q: profile should be OID or URI", s)
+	}
+}
+
+// TypedProfile wraps an eat.Profile so that its JSON encoding records whether
+// the profile is an OID or a URI, e.g.:
+//
+//	{"type": "oid", "value": "1.2.3.4"}
+//	{"type": "uri", "value": "https://example.com/profile"}
+//
+// For backwards compatibility, a bare JSON string is also accepted when
+// decoding.  The CBOR encoding is that of the wrapped eat.Profile.
+type TypedProfile struct {
+	eat.Profile
+}
+
+// NewTypedProfile instantiates a TypedProfile from the supplied URI or
+// dotted-decimal OID
+func NewTypedProfile(urlOrOID string) (*TypedProfile, error) {
+	p, err := eat.NewProfile(urlOrOID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedProfile{*p}, nil
+}
+
+// MarshalJSON encodes the target profile as a type/value JSON object
+func (o TypedProfile) MarshalJSON() ([]byte, error) {
+	var typ string
+
+	switch {
+	case o.IsOID():
+		typ = ProfileTypeOID
+	case o.IsURI():
+		typ = ProfileTypeURI
+	default:
+		return nil, errors.New("no valid EAT profile")
+	}
+
+	s, err := o.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoding.TypeAndValue{Type: typ, Value: value})
+}
+
+// UnmarshalJSON decodes either a type/value JSON object, as produced by
+// MarshalJSON, or a bare JSON string into the target profile
+func (o *TypedProfile) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '"' {
+		return o.Profile.UnmarshalJSON(trimmed)
+	}
+
+	var tv encoding.TypeAndValue
+
+	if err := json.Unmarshal(data, &tv); err != nil {
+		return fmt.Errorf("profile: %w", err)
+	}
+
+	var s string
+
+	if err := json.Unmarshal(tv.Value, &s); err != nil {
+		return fmt.Errorf("profile value: %w", err)
+	}
+
+	p, err := eat.NewProfile(s)
+	if err != nil {
+		return err
+	}
+
+	switch tv.Type {
+	case ProfileTypeOID:
+		if !p.IsOID() {
+			return fmt.Errorf("profile %q is not an OID", s)
+		}
+	case ProfileTypeURI:
+		if !p.IsURI() {
+			return fmt.Errorf("profile %q is not a URI", s)
+		}
+	default:
+		return fmt.Errorf("unknown profile type %q", tv.Type)
+	}
+
+	o.Profile = *p
+
+	return nil
+}
+
+// unsignedCorimJSON overrides the JSON encoding of UnsignedCorim.Profile with
+// that of TypedProfile.  The shadowed profile field of the embedded
+// UnsignedCorim must be left nil.
+type unsignedCorimJSON struct {
+	Profile *TypedProfile `json:"profile,omitempty"`
+	UnsignedCorim
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedProfile_JSON_roundtrip(t *testing.T) {
+	oid, err := NewTypedProfile("1.2.3.4")
+	require.NoError(t, err)
+
+	uri, err := NewTypedProfile("https://example.com/profile")
+	require.NoError(t, err)
+
+	data, err := json.Marshal([]TypedProfile{*oid, *uri})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"type":"oid","value":"1.2.3.4"},
+		{"type":"uri","value":"https://example.com/profile"}
+	]`, string(data))
+
+	var actual []TypedProfile
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.Len(t, actual, 2)
+	assert.True(t, actual[0].IsOID())
+	assert.True(t, actual[1].IsURI())
+	assert.Equal(t, []TypedProfile{*oid, *uri}, actual)
+}
+
+func TestTypedProfile_UnmarshalJSON_bare_string(t *testing.T) {
+	var p TypedProfile
+
+	require.NoError(t, json.Unmarshal([]byte(`"2.5.2.8192"`), &p))
+	assert.True(t, p.IsOID())
+}
+
+func TestTypedProfile_UnmarshalJSON_NOK(t *testing.T) {
+	testCases := []struct {
+		input string
+		err   string
+	}{
+		{`{"type":"oid","value":"https://example.com"}`, `profile "https://example.com" is not an OID`},
+		{`{"type":"uri","value":"1.2.3.4"}`, `profile "1.2.3.4" is not a URI`},
+		{`{"type":"urn","value":"1.2.3.4"}`, `unknown profile type "urn"`},
+		{`{"type":"oid"}`, "profile: no value provided for oid"},
+		{`{"type":"oid","value":3}`, "profile value: json: cannot unmarshal number into Go value of type string"},
+	}
+
+	for _, tc := range testCases {
+		var p TypedProfile
+		assert.EqualError(t, json.Unmarshal([]byte(tc.input), &p), tc.err, tc.input)
+	}
+}
+
+func TestUnsignedCorim_JSON_typed_profile(t *testing.T) {
+	for _, profile := range []string{"1.2.3.4", "https://example.com/profile"} {
+		c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+		require.NoError(t, c.SetProfileErr(profile))
+
+		data, err := c.ToJSON()
+		require.NoError(t, err)
+
+		var actual UnsignedCorim
+		require.NoError(t, actual.FromJSON(data))
+		require.NotNil(t, actual.Profile)
+		assert.Equal(t, c.Profile.IsOID(), actual.Profile.IsOID())
+		assert.Equal(t, *c.Profile, *actual.Profile)
+	}
+}
+
+func TestDetectProfileKind(t *testing.T) {
+	for _, tc := range []struct {
+		profile  string
+		expected ProfileKind
+	}{
+		{"1.2.3.4", ProfileKindOID},
+		{"http://example.com/profile", ProfileKindURI},
+		{"tag:arm.com,2023:cca_platform#1.0.0", ProfileKindURI},
+	} {
+		kind, err := DetectProfileKind(tc.profile)
+		require.NoError(t, err, tc.profile)
+		assert.Equal(t, tc.expected, kind, tc.profile)
+	}
+
+	assert.Equal(t, "oid", ProfileKindOID.String())
+	assert.Equal(t, "uri", ProfileKindURI.String())
+	assert.Equal(t, "ProfileKind(0)", ProfileKind(0).String())
+
+	for _, bad := range []string{"", "not a profile", "1.2.x"} {
+		_, err := DetectProfileKind(bad)
+		assert.ErrorContains(t, err, fmt.Sprintf("invalid profile %q", bad))
+	}
+}
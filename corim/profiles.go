@@ -4,13 +4,13 @@ package corim
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 
 	"github.com/veraison/corim/comid"
 	"github.com/veraison/corim/extensions"
 	"github.com/veraison/eat"
-	"github.com/veraison/go-cose"
 )
 
 // SignedCorimMapExtensionPoints is a list of extension.Point's valid for a
@@ -43,25 +43,21 @@ var ComidMapExtensionPoints = []extensions.Point{
 var AllExtensionPoints = make(map[extensions.Point]bool) // populated inside init() below
 
 // UnmarshalSignedCorimFromCBOR unmarshals a SignedCorim from provided
-// CBOR data. If there are extensions associated with the profile specified by
-// the data, they will be registered with the UnsignedCorim before it is
-// unmarshaled.
+// CBOR data, which can be either a COSE_Sign1 or a COSE_Sign message. If there
+// are extensions associated with the profile specified by the data, they will
+// be registered with the UnsignedCorim before it is unmarshaled.
 func UnmarshalSignedCorimFromCBOR(buf []byte) (*SignedCorim, error) {
-	message := cose.NewSign1Message()
-
-	if err := message.UnmarshalCBOR(buf); err != nil {
-		return nil, fmt.Errorf("failed CBOR decoding for COSE-Sign1 signed CoRIM: %w", err)
+	payload, err := signedPayload(buf)
+	if err != nil {
+		return nil, err
 	}
 
-	profiled := struct {
-		Profile *eat.Profile `cbor:"3,keyasint,omitempty"`
-	}{}
-
-	if err := dm.Unmarshal(message.Payload, &profiled); err != nil {
+	profile, err := payloadProfile(payload)
+	if err != nil {
 		return nil, err
 	}
 
-	ret := GetSignedCorim(profiled.Profile)
+	ret := GetSignedCorim(profile)
 	if err := ret.FromCOSE(buf); err != nil {
 		return nil, err
 	}
@@ -74,20 +70,37 @@ func UnmarshalSignedCorimFromCBOR(buf []byte) (*SignedCorim, error) {
 // the data, they will be registered with the UnsignedCorim before it is
 // unmarshaled.
 func UnmarshalUnsignedCorimFromCBOR(buf []byte) (*UnsignedCorim, error) {
+	profile, err := payloadProfile(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := GetUnsignedCorim(profile)
+	if err := ret.FromCBOR(buf); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// payloadProfile returns the profile declared by the supplied
+// unsigned-corim-map, if any, in any of the forms accepted by
+// UnsignedCorim.FromCBOR
+func payloadProfile(buf []byte) (*eat.Profile, error) {
 	profiled := struct {
 		Profile *eat.Profile `cbor:"3,keyasint,omitempty"`
 	}{}
 
-	if err := dm.Unmarshal(buf, &profiled); err != nil {
+	bare, err := normalizeCorimMap(dm, buf)
+	if err != nil {
 		return nil, err
 	}
 
-	ret := GetUnsignedCorim(profiled.Profile)
-	if err := ret.FromCBOR(buf); err != nil {
+	if err := dm.Unmarshal(bare, &profiled); err != nil {
 		return nil, err
 	}
 
-	return ret, nil
+	return profiled.Profile, nil
 }
 
 // UnmarshalUnsignedCorimFromJSON unmarshals an UnsignedCorim from provided
@@ -96,14 +109,19 @@ func UnmarshalUnsignedCorimFromCBOR(buf []byte) (*UnsignedCorim, error) {
 // unmarshaled.
 func UnmarshalUnsignedCorimFromJSON(buf []byte) (*UnsignedCorim, error) {
 	profiled := struct {
-		Profile *eat.Profile `json:"profile,omitempty"`
+		Profile *TypedProfile `json:"profile,omitempty"`
 	}{}
 
 	if err := json.Unmarshal(buf, &profiled); err != nil {
 		return nil, err
 	}
 
-	ret := GetUnsignedCorim(profiled.Profile)
+	var profileID *eat.Profile
+	if profiled.Profile != nil {
+		profileID = &profiled.Profile.Profile
+	}
+
+	ret := GetUnsignedCorim(profileID)
 	if err := ret.FromJSON(buf); err != nil {
 		return nil, err
 	}
@@ -305,11 +323,121 @@ func GetProfile(id *eat.Profile) (Profile, bool) {
 	return prof, ok
 }
 
+// RegisterProfileValidator registers a function that implements the checks
+// specific to the supplied profile (a URI or OID string).  The validator is
+// run by UnsignedCorim.Valid, after the generic checks, on CoRIMs that declare
+// the profile.  An error is returned if the profile is invalid or a validator
+// has already been registered for it.
+func RegisterProfileValidator(profile string, v func(UnsignedCorim) error) error {
+	if v == nil {
+		return errors.New("nil profile validator")
+	}
+
+	strID, err := profileValidatorKey(profile)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := profileValidators[strID]; ok {
+		return fmt.Errorf("validator for profile %q already registered", strID)
+	}
+
+	profileValidators[strID] = v
+
+	return nil
+}
+
+// UnregisterProfileValidator removes the validator registered for the
+// specified profile.  Returns true if a validator was previously registered
+// and has been removed, and false otherwise.
+func UnregisterProfileValidator(profile string) bool {
+	strID, err := profileValidatorKey(profile)
+	if err != nil {
+		return false
+	}
+
+	if _, ok := profileValidators[strID]; ok {
+		delete(profileValidators, strID)
+		return true
+	}
+
+	return false
+}
+
+// ErrNoDependentRims is returned by RequireDependentRims when the CoRIM has no
+// dependent RIMs
+var ErrNoDependentRims = errors.New("profile requires at least one dependent RIM")
+
+// RequireDependentRims is a profile validator that rejects CoRIMs with an
+// absent or empty dependent-rims list.  Profiles that mandate dependent RIMs
+// can register it directly with RegisterProfileValidator, or call it from
+// their own validator.
+func RequireDependentRims(o UnsignedCorim) error {
+	if o.DependentRims == nil || len(*o.DependentRims) == 0 {
+		return ErrNoDependentRims
+	}
+
+	return nil
+}
+
+// ErrNoEntities is returned by RequireEntities when the CoRIM has no entities
+var ErrNoEntities = errors.New("profile requires at least one entity")
+
+// RequireEntities is a profile validator that rejects CoRIMs with an absent or
+// empty entities list (see RequireDependentRims).
+func RequireEntities(o UnsignedCorim) error {
+	if o.Entities == nil || o.Entities.IsEmpty() {
+		return ErrNoEntities
+	}
+
+	return nil
+}
+
+func profileValidatorKey(profile string) (string, error) {
+	p, err := eat.NewProfile(profile)
+	if err != nil {
+		return "", fmt.Errorf("invalid profile %q: %w", profile, err)
+	}
+
+	return NormalizeProfile(*p)
+}
+
+// validProfile runs the validator registered for the profile declared by the
+// target CoRIM, if any.  If rejectUnknown is set, a profile that has neither a
+// validator nor extensions registered is reported as an error.
+func (o UnsignedCorim) validProfile(rejectUnknown bool) error {
+	if o.Profile == nil {
+		return nil
+	}
+
+	strID, err := NormalizeProfile(*o.Profile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidProfile, err)
+	}
+
+	v, ok := profileValidators[strID]
+	if !ok {
+		if _, registered := GetProfile(o.Profile); rejectUnknown && !registered {
+			return fmt.Errorf("%w: unknown profile %q", ErrInvalidProfile, strID)
+		}
+		return nil
+	}
+
+	if err := v(o); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidProfile, strID, err)
+	}
+
+	return nil
+}
+
 type iextensible interface {
 	RegisterExtensions(exts extensions.Map) error
 }
 
-var profilesRegister = make(map[string]Profile)
+var (
+	profilesRegister  = make(map[string]Profile)
+	profileValidators = make(map[string]func(UnsignedCorim) error)
+)
 
 func init() {
 	for _, p := range SignedCorimMapExtensionPoints {
@@ -3,13 +3,17 @@
 package corim
 
 import (
+	"crypto/rand"
+	"errors"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/veraison/corim/comid"
 	"github.com/veraison/corim/extensions"
 	"github.com/veraison/eat"
+	"github.com/veraison/go-cose"
 )
 
 func TestProfile_registration(t *testing.T) {
@@ -117,6 +121,15 @@ func TestProfile_marshaling(t *testing.T) {
 	assert.Equal(t, profID, c.Profile)
 	assert.Equal(t, "foo", c.Extensions.MustGetString("Extension1"))
 
+	// the profile is also found when wrapped in a CBOR tag
+	tagged, err := c.ToCBORWithOptions(EncodeOptions{TaggedProfile: true})
+	require.NoError(t, err)
+
+	ct, err := UnmarshalUnsignedCorimFromCBOR(tagged)
+	require.NoError(t, err)
+	assert.Equal(t, profID, ct.Profile)
+	assert.Equal(t, "foo", ct.Extensions.MustGetString("Extension1"))
+
 	profile, ok := GetProfile(c.Profile)
 	assert.True(t, ok)
 
@@ -183,5 +196,134 @@ func TestProfile_marshaling(t *testing.T) {
 	assert.Equal(t, profID, s.UnsignedCorim.Profile)
 	assert.Equal(t, "foo", s.UnsignedCorim.Extensions.MustGetString("Extension1"))
 
+	// the profile is also found in signed payloads using the tagged,
+	// indefinite-length and array-wrapped forms
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	tagged, err = s.UnsignedCorim.ToCBORWithOptions(EncodeOptions{TaggedProfile: true})
+	require.NoError(t, err)
+
+	indefinite, err := s.UnsignedCorim.ToCBORWithOptions(
+		EncodeOptions{TaggedProfile: true, IndefiniteLengthTags: true},
+	)
+	require.NoError(t, err)
+
+	for _, payload := range [][]byte{
+		tagged,
+		indefinite,
+		wrapProfile(t, tagged, []byte{0x81}, nil),
+		wrapProfile(t, tagged, []byte{0x9f}, []byte{0xff}),
+	} {
+		msg := cose.NewSign1Message()
+		msg.Headers = s.message.Headers
+		msg.Headers.Protected.SetAlgorithm(signer.Algorithm())
+		msg.Payload = payload
+		require.NoError(t, msg.Sign(rand.Reader, NoExternalData, signer))
+
+		buf, err := msg.MarshalCBOR()
+		require.NoError(t, err)
+
+		st, err := UnmarshalSignedCorimFromCBOR(buf)
+		require.NoError(t, err)
+		assert.Equal(t, profID, st.UnsignedCorim.Profile)
+		assert.Equal(t, "foo", st.UnsignedCorim.Extensions.MustGetString("Extension1"))
+	}
+
 	UnregisterProfile(profID)
 }
+
+// wrapProfile returns the supplied unsigned-corim-map with its profile
+// enclosed between head and tail, e.g., in a one-element array
+func wrapProfile(t *testing.T, data, head, tail []byte) []byte {
+	var m map[int]cbor.RawMessage
+	require.NoError(t, dm.Unmarshal(data, &m))
+
+	m[profileKey] = append(append(head, m[profileKey]...), tail...)
+
+	out, err := em.Marshal(m)
+	require.NoError(t, err)
+
+	return out
+}
+
+func TestProfile_validator(t *testing.T) {
+	err := RegisterProfileValidator("http://example.com/validated", nil)
+	assert.EqualError(t, err, "nil profile validator")
+
+	failing := func(UnsignedCorim) error { return errors.New("no CoMIDs allowed") }
+
+	err = RegisterProfileValidator("HTTP://Example.com/validated", failing)
+	require.NoError(t, err)
+	defer UnregisterProfileValidator("http://example.com/validated")
+
+	err = RegisterProfileValidator("http://example.com/validated",
+		func(UnsignedCorim) error { return nil })
+	assert.EqualError(t, err,
+		`validator for profile "http://example.com/validated" already registered`)
+
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	assert.NoError(t, c.Valid())
+
+	require.NotNil(t, c.SetProfile("http://example.com/validated"))
+	assert.EqualError(t, c.Valid(),
+		`profile validation failed: http://example.com/validated: no CoMIDs allowed`)
+
+	require.NotNil(t, c.SetProfile("http://example.com/unknown"))
+	assert.NoError(t, c.Valid())
+	assert.EqualError(t, c.ValidWithOptions(ValidationOptions{RejectUnknownProfiles: true}),
+		`profile validation failed: unknown profile "http://example.com/unknown"`)
+
+	assert.True(t, UnregisterProfileValidator("http://example.com/validated"))
+	assert.False(t, UnregisterProfileValidator("http://example.com/validated"))
+}
+
+func TestProfile_RequireDependentRims(t *testing.T) {
+	err := RegisterProfileValidator("http://example.com/with-deps", RequireDependentRims)
+	require.NoError(t, err)
+	defer UnregisterProfileValidator("http://example.com/with-deps")
+
+	// base CoRIMs are unaffected
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	assert.NoError(t, c.Valid())
+
+	require.NotNil(t, c.SetProfile("http://example.com/with-deps"))
+
+	err = c.Valid()
+	assert.ErrorIs(t, err, ErrNoDependentRims)
+	assert.EqualError(t, err,
+		`profile validation failed: http://example.com/with-deps: profile requires at least one dependent RIM`)
+
+	c.DependentRims = &[]Locator{}
+	assert.ErrorIs(t, c.Valid(), ErrNoDependentRims)
+
+	require.NotNil(t, c.AddDependentRim("https://example.com/dep.cbor"))
+	assert.NoError(t, c.Valid())
+}
+
+func TestProfile_RequireEntities(t *testing.T) {
+	err := RegisterProfileValidator("http://example.com/with-entities", RequireEntities)
+	require.NoError(t, err)
+	defer UnregisterProfileValidator("http://example.com/with-entities")
+
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	c.Entities = nil
+	assert.NoError(t, c.Valid())
+
+	require.NotNil(t, c.SetProfile("http://example.com/with-entities"))
+
+	err = c.Valid()
+	assert.ErrorIs(t, err, ErrNoEntities)
+	assert.EqualError(t, err,
+		`profile validation failed: http://example.com/with-entities: profile requires at least one entity`)
+
+	c.Entities = NewEntities()
+	assert.ErrorIs(t, c.Valid(), ErrNoEntities)
+
+	regID := "https://acme.example"
+	require.NotNil(t, c.AddEntity("ACME Ltd.", &regID, RoleManifestCreator))
+	assert.NoError(t, c.Valid())
+
+	// entity roles must be registered
+	assert.Nil(t, c.AddEntity("Other Ltd.", nil, Role(99)))
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"fmt"
+
+	cbor "github.com/fxamacker/cbor/v2"
+	"github.com/veraison/corim/encoding"
+	"github.com/veraison/eat"
+)
+
+const (
+	// ProfileOIDTagNumber is the CBOR tag number of tagged-oid-type, used by
+	// newer CoRIM drafts to mark OID profiles
+	ProfileOIDTagNumber uint64 = 111
+	// ProfileURITagNumber is the CBOR tag number of a tagged URI (RFC 8949)
+	ProfileURITagNumber uint64 = 32
+)
+
+// profileKey is the key of the profile entry of the unsigned-corim-map
+const profileKey = 3
+
+// EncodeOptions specifies how ToCBORWithOptions serializes an unsigned CoRIM
+type EncodeOptions struct {
+	// TaggedProfile causes the profile to be wrapped in a CBOR tag, i.e.,
+	// #6.111 for an OID and #6.32 for a URI, as per newer CoRIM drafts.  By
+	// default, the legacy bare form is emitted.
+	TaggedProfile bool
+	// IndefiniteLengthTags causes the tags array to be emitted as an
+	// indefinite-length array, as produced by streaming encoders that do not
+	// know the number of tags in advance.  FromCBOR accepts both forms.
+	IndefiniteLengthTags bool
+}
+
+// ToCBORWithOptions is like ToCBOR, but serializes the target unsigned CoRIM
+// according to the supplied options
+func (o UnsignedCorim) ToCBORWithOptions(opts EncodeOptions) ([]byte, error) {
+	src, unknown := o.cborSource()
+
+	if opts.TaggedProfile && src.Profile != nil {
+		data, err := taggedProfile(*src.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		if unknown == nil {
+			unknown = make(map[int]cbor.RawMessage, 1)
+		}
+
+		unknown[profileKey] = data
+		src.Profile = nil
+	}
+
+	data, err := encoding.SerializeStructToCBORWithUnknown(em, src, unknown)
+	if err != nil || !opts.IndefiniteLengthTags {
+		return data, err
+	}
+
+	return indefiniteTags(data)
+}
+
+func taggedProfile(p eat.Profile) ([]byte, error) {
+	data, err := p.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("encoding profile: %w", err)
+	}
+
+	if p.IsOID() {
+		return append(TagHeader(ProfileOIDTagNumber), data...), nil
+	}
+
+	return append(TagHeader(ProfileURITagNumber), data...), nil
+}
+
+// bareProfile returns the supplied unsigned-corim-map with its profile entry in
+// the bare form, i.e., with the CBOR tag (see EncodeOptions), if any,
+// stripped, and unwrapped if it is a one-element array.  data is returned
+// unchanged if its profile is already bare, or if it cannot be decoded as a
+// map (in which case the error is left to the caller to report).
+func bareProfile(dm cbor.DecMode, data []byte) ([]byte, error) {
+	var probe struct {
+		Profile cbor.RawMessage `cbor:"3,keyasint,omitempty"`
+	}
+
+	if err := dm.Unmarshal(data, &probe); err != nil {
+		return data, nil
+	}
+
+	const (
+		majorTypeMask  = 0xe0
+		majorTypeArray = 0x80
+		majorTypeTag   = 0xc0
+	)
+
+	if len(probe.Profile) == 0 {
+		return data, nil
+	}
+
+	profile := []byte(probe.Profile)
+
+	if profile[0]&majorTypeMask == majorTypeArray {
+		var profiles []cbor.RawMessage
+		if err := dm.Unmarshal(profile, &profiles); err != nil {
+			return nil, fmt.Errorf("decoding profile: %w", err)
+		}
+
+		if len(profiles) != 1 {
+			return nil, fmt.Errorf("decoding profile: expecting exactly one profile, got %d", len(profiles))
+		}
+
+		profile = profiles[0]
+	}
+
+	if profile[0]&majorTypeMask == majorTypeTag {
+		content, err := untagProfile(dm, profile)
+		if err != nil {
+			return nil, err
+		}
+
+		profile = content
+	}
+
+	if bytes.Equal(profile, probe.Profile) {
+		return data, nil
+	}
+
+	var m map[int]cbor.RawMessage
+	if err := dm.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	m[profileKey] = profile
+
+	return em.Marshal(m)
+}
+
+// untagProfile returns the content of the supplied tagged profile
+func untagProfile(dm cbor.DecMode, profile []byte) ([]byte, error) {
+	var tag cbor.RawTag
+	if err := dm.Unmarshal(profile, &tag); err != nil {
+		return nil, fmt.Errorf("decoding profile: %w", err)
+	}
+
+	// tagged OIDs wrap a byte string, tagged URIs a text string
+	var expected byte
+
+	switch tag.Number {
+	case ProfileOIDTagNumber:
+		expected = 0x40
+	case ProfileURITagNumber:
+		expected = 0x60
+	default:
+		return nil, fmt.Errorf("decoding profile: unexpected CBOR tag %d", tag.Number)
+	}
+
+	if len(tag.Content) == 0 || tag.Content[0]&0xe0 != expected {
+		return nil, fmt.Errorf("decoding profile: unexpected content for CBOR tag %d", tag.Number)
+	}
+
+	return tag.Content, nil
+}
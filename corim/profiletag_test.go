@@ -0,0 +1,124 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"testing"
+
+	cbor "github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsignedCorim_ToCBORWithOptions_TaggedProfile(t *testing.T) {
+	for _, tc := range []struct {
+		profile string
+		header  []byte
+	}{
+		{"1.2.3.4", []byte{0x03, 0xd8, 0x6f, 0x43}},            // 3: 111(h'...')
+		{"http://example.com", []byte{0x03, 0xd8, 0x20, 0x72}}, // 3: 32("...")
+	} {
+		t.Run(tc.profile, func(t *testing.T) {
+			c := NewTestCorim().SetProfile(tc.profile)
+			require.NotNil(t, c)
+
+			legacy, err := c.ToCBOR()
+			require.NoError(t, err)
+
+			opts, err := c.ToCBORWithOptions(EncodeOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, legacy, opts)
+
+			tagged, err := c.ToCBORWithOptions(EncodeOptions{TaggedProfile: true})
+			require.NoError(t, err)
+			assert.True(t, bytes.Contains(tagged, tc.header), "%x", tagged)
+
+			for _, data := range [][]byte{legacy, tagged} {
+				var actual UnsignedCorim
+				require.NoError(t, actual.FromCBOR(data))
+				require.NotNil(t, actual.Profile)
+				assert.True(t, actual.HasProfile(tc.profile))
+				assert.Equal(t, c.ID, actual.ID)
+				assert.Equal(t, c.Tags, actual.Tags)
+
+				// re-encoding uses the legacy form
+				reencoded, err := actual.ToCBOR()
+				require.NoError(t, err)
+				assert.Equal(t, legacy, reencoded)
+			}
+		})
+	}
+}
+
+func TestUnsignedCorim_ToCBORWithOptions_no_profile(t *testing.T) {
+	c := NewTestCorim()
+
+	legacy, err := c.ToCBOR()
+	require.NoError(t, err)
+
+	tagged, err := c.ToCBORWithOptions(EncodeOptions{TaggedProfile: true})
+	require.NoError(t, err)
+	assert.Equal(t, legacy, tagged)
+}
+
+func TestUnsignedCorim_FromCBOR_TaggedProfile_bad(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		profile  []byte
+		expected string
+	}{
+		{"OID tag wrapping a text string", []byte{0xd8, 0x6f, 0x61, 0x78}, "decoding profile: unexpected content for CBOR tag 111"},
+		{"URI tag wrapping a byte string", []byte{0xd8, 0x20, 0x41, 0x2a}, "decoding profile: unexpected content for CBOR tag 32"},
+		{"unknown tag", []byte{0xd8, 0x70, 0x41, 0x2a}, "decoding profile: unexpected CBOR tag 112"},
+		{"empty array", []byte{0x80}, "decoding profile: expecting exactly one profile, got 0"},
+		{"two-element array", []byte{0x82, 0x61, 0x78, 0x61, 0x79}, "decoding profile: expecting exactly one profile, got 2"},
+		{"array with a bad tagged profile", []byte{0x81, 0xd8, 0x70, 0x41, 0x2a}, "decoding profile: unexpected CBOR tag 112"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := []byte{
+				0xa2,
+				0x00, 0x62, 0x69, 0x64, // 0: "id"
+				0x03, // 3: profile
+			}
+			data = append(data, tc.profile...)
+
+			var actual UnsignedCorim
+			assert.EqualError(t, actual.FromCBOR(data), tc.expected)
+		})
+	}
+}
+
+func TestUnsignedCorim_FromCBOR_profile_array(t *testing.T) {
+	c := NewTestCorim().SetProfile("http://example.com")
+	require.NotNil(t, c)
+
+	bare, err := c.ToCBOR()
+	require.NoError(t, err)
+
+	tagged, err := c.ToCBORWithOptions(EncodeOptions{TaggedProfile: true})
+	require.NoError(t, err)
+
+	for _, data := range [][]byte{bare, tagged} {
+		// wrap the profile in a one-element array
+		var m map[int]cbor.RawMessage
+		require.NoError(t, dm.Unmarshal(data, &m))
+
+		wrapped, err := em.Marshal([]cbor.RawMessage{m[3]})
+		require.NoError(t, err)
+		m[3] = wrapped
+
+		data, err = em.Marshal(m)
+		require.NoError(t, err)
+
+		var actual UnsignedCorim
+		require.NoError(t, actual.FromCBOR(data))
+		assert.True(t, actual.HasProfile("http://example.com"))
+
+		// re-encoding uses the bare form
+		reencoded, err := actual.ToCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, bare, reencoded)
+	}
+}
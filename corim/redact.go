@@ -0,0 +1,143 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/veraison/corim/comid"
+)
+
+// RedactMeasurements removes the digests, raw values (and their masks) and
+// integrity registers from the reference and endorsed values of the CoMIDs
+// found in the tags array of the unsigned-corim-map, which are re-encoded in
+// place.  Environments, measurement keys and the remaining measurement values
+// (e.g., versions and SVNs) are preserved.  No measurement value is added:
+// since a measurement-values-map must not be empty, measurements that are
+// left with no values are removed, as are the triples that are left with no
+// measurements (see RedactMeasurementsReport to find out which).  An error is
+// returned if a CoMID would be left with no triples at all.  On failure, the
+// target is left unchanged.
+func (o *UnsignedCorim) RedactMeasurements() error {
+	_, err := o.RedactMeasurementsReport()
+	return err
+}
+
+// RedactedTriple identifies a value triple removed by RedactMeasurements
+// because none of its measurements had any value left
+type RedactedTriple struct {
+	// Tag is the position of the CoMID in the tags array
+	Tag int
+	// Triples is the name of the triples the triple belonged to, i.e.,
+	// "reference-values" or "endorsed-values"
+	Triples string
+	// TripleIndex is the position of the triple in the original CoMID
+	TripleIndex int
+	// Environment is the environment of the removed triple
+	Environment comid.Environment
+}
+
+// RedactMeasurementsReport is like RedactMeasurements, but also returns the
+// triples, and hence the environments, that have been removed
+func (o *UnsignedCorim) RedactMeasurementsReport() ([]RedactedTriple, error) {
+	if o == nil {
+		return nil, errors.New("nil UnsignedCorim")
+	}
+
+	var removed []RedactedTriple
+
+	redacted := make([]Tag, len(o.Tags))
+
+	for i, t := range o.Tags {
+		redacted[i] = t
+
+		if !bytes.HasPrefix(t, ComidTag) {
+			continue
+		}
+
+		var c comid.Comid
+		if err := c.FromCBOR(t[len(ComidTag):]); err != nil {
+			return nil, fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+		}
+
+		var r []RedactedTriple
+
+		c.Triples.ReferenceValues, r = redactValueTriples(c.Triples.ReferenceValues, i, "reference-values")
+		removed = append(removed, r...)
+
+		c.Triples.EndorsedValues, r = redactValueTriples(c.Triples.EndorsedValues, i, "endorsed-values")
+		removed = append(removed, r...)
+
+		if err := c.Valid(); err != nil {
+			return nil, fmt.Errorf("redacted CoMID at pos %d: %w", i, err)
+		}
+
+		comidCBOR, err := c.ToCBOR()
+		if err != nil {
+			return nil, fmt.Errorf("encoding redacted CoMID at pos %d: %w", i, err)
+		}
+
+		redacted[i] = append(TagHeader(ComidTagNumber), comidCBOR...)
+	}
+
+	o.Tags = redacted
+
+	return removed, nil
+}
+
+// redactValueTriples returns the supplied value triples with the measurement
+// values removed by RedactMeasurements, or nil if no triple is left, along
+// with the triples that have been removed
+func redactValueTriples(
+	triples *comid.ValueTriples,
+	tag int,
+	name string,
+) (*comid.ValueTriples, []RedactedTriple) {
+	if triples == nil {
+		return nil, nil
+	}
+
+	var (
+		kept    []comid.ValueTriple
+		removed []RedactedTriple
+	)
+
+	for i, vt := range triples.Values {
+		var measurements []comid.Measurement
+
+		for _, m := range vt.Measurements.Values {
+			m.Val.Digests = nil
+			m.Val.RawValue = nil
+			m.Val.RawValueMask = nil
+			m.Val.IntegrityRegisters = nil
+
+			if m.Val.Valid() == nil {
+				measurements = append(measurements, m)
+			}
+		}
+
+		if len(measurements) == 0 {
+			removed = append(removed, RedactedTriple{
+				Tag:         tag,
+				Triples:     name,
+				TripleIndex: i,
+				Environment: vt.Environment,
+			})
+			continue
+		}
+
+		vt.Measurements.Values = measurements
+		kept = append(kept, vt)
+	}
+
+	if len(kept) == 0 {
+		return nil, removed
+	}
+
+	triples.Values = kept
+
+	return triples, removed
+}
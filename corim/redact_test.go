@@ -0,0 +1,71 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestUnsignedCorim_RedactMeasurements(t *testing.T) {
+	refVals := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	keys := comidFromJSON(t, comid.PSAKeysJSONTemplate)
+
+	// the first measurement also carries an SVN, the others only digests
+	vt := &refVals.Triples.ReferenceValues.Values[0]
+	vt.Measurements.Values[0].SetSVN(7)
+
+	// a second environment whose measurements are all digests
+	other := comidFromJSON(t, comid.PSARefValJSONTemplate).Triples.ReferenceValues.Values[0]
+	vendor := "Other Vendor"
+	other.Environment.Class.Vendor = &vendor
+	refVals.Triples.ReferenceValues.Values = append(refVals.Triples.ReferenceValues.Values, other)
+	vt = &refVals.Triples.ReferenceValues.Values[0]
+
+	tv := NewUnsignedCorim().SetID("test corim id").AddComid(refVals).AddComid(keys)
+	require.NotNil(t, tv)
+	keysTag := tv.Tags[1]
+
+	removed, err := tv.RedactMeasurementsReport()
+	require.NoError(t, err)
+	require.NoError(t, tv.Valid())
+
+	// the digest-only environment is reported, not silently dropped
+	assert.Equal(t, []RedactedTriple{{
+		Tag:         0,
+		Triples:     "reference-values",
+		TripleIndex: 1,
+		Environment: other.Environment,
+	}}, removed)
+
+	redacted, err := tv.ComidAt(0)
+	require.NoError(t, err)
+
+	rvs := redacted.Triples.ReferenceValues.Values
+	require.Len(t, rvs, 1)
+	assert.Equal(t, vt.Environment, rvs[0].Environment)
+	require.Len(t, rvs[0].Measurements.Values, 1)
+
+	// no measurement value is fabricated: what is left is exactly the
+	// original measurement minus the redacted values
+	expected := vt.Measurements.Values[0]
+	expected.Val.Digests = nil
+	assert.Equal(t, expected.Key, rvs[0].Measurements.Values[0].Key)
+	assert.Equal(t, comid.Mval{SVN: expected.Val.SVN}, rvs[0].Measurements.Values[0].Val)
+
+	// key triples are unaffected
+	assert.Equal(t, keysTag, tv.Tags[1])
+
+	// nothing left to publish
+	tv = NewUnsignedCorim().SetID("test corim id").AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate))
+	require.NotNil(t, tv)
+	before := append([]Tag(nil), tv.Tags...)
+
+	err = tv.RedactMeasurements()
+	assert.EqualError(t, err, "redacted CoMID at pos 0: triples validation failed: triples struct must not be empty")
+	assert.Equal(t, before, tv.Tags)
+}
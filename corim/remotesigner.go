@@ -0,0 +1,80 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	cose "github.com/veraison/go-cose"
+)
+
+// RemoteSigner is implemented by signing keys that are not held in process
+// memory, such as keys in an HSM or a cloud KMS.  Sign is invoked with the
+// encoded COSE Sig_structure (RFC 9052, §4.4), which it must hash as required
+// by Algorithm, and must return the signature in COSE format (e.g., r || s for
+// ECDSA, rather than ASN.1 DER).
+type RemoteSigner interface {
+	Sign(ctx context.Context, data []byte) ([]byte, error)
+	Algorithm() cose.Algorithm
+}
+
+// NewCOSESigner returns a cose.Signer that delegates to the supplied
+// RemoteSigner using ctx, so that it can be used with Sign, SignDetached,
+// SignWithCerts and SignMulti
+func NewCOSESigner(ctx context.Context, s RemoteSigner) cose.Signer {
+	return &coseRemoteSigner{ctx: ctx, s: s}
+}
+
+type coseRemoteSigner struct {
+	ctx context.Context
+	s   RemoteSigner
+}
+
+func (o coseRemoteSigner) Algorithm() cose.Algorithm {
+	return o.s.Algorithm()
+}
+
+func (o coseRemoteSigner) Sign(_ io.Reader, content []byte) ([]byte, error) {
+	if err := o.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return o.s.Sign(o.ctx, content)
+}
+
+// SignWithContext is like Sign, but uses a RemoteSigner
+func (o *SignedCorim) SignWithContext(ctx context.Context, s RemoteSigner) ([]byte, error) {
+	if s == nil {
+		return nil, errors.New("nil signer")
+	}
+
+	return o.Sign(NewCOSESigner(ctx, s))
+}
+
+// NewLocalSigner returns a RemoteSigner backed by a local crypto.Signer, for
+// use where code is written against RemoteSigner but the key is in memory
+func NewLocalSigner(alg cose.Algorithm, key crypto.Signer) (RemoteSigner, error) {
+	s, err := cose.NewSigner(alg, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localSigner{s}, nil
+}
+
+type localSigner struct {
+	s cose.Signer
+}
+
+func (o localSigner) Algorithm() cose.Algorithm {
+	return o.s.Algorithm()
+}
+
+func (o localSigner) Sign(_ context.Context, data []byte) ([]byte, error) {
+	return o.s.Sign(rand.Reader, data)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+// testKMS mimics a KMS that signs digests with a key it never discloses
+type testKMS struct {
+	key   *ecdsa.PrivateKey
+	calls int
+}
+
+func (o *testKMS) Algorithm() cose.Algorithm {
+	return cose.AlgorithmES256
+}
+
+func (o *testKMS) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	o.calls++
+
+	digest := sha256.Sum256(data)
+
+	r, s, err := ecdsa.Sign(rand.Reader, o.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return sig, nil
+}
+
+func TestSignedCorim_SignWithContext(t *testing.T) {
+	_, key, err := getAlgAndKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	kms := &testKMS{key: key.(*ecdsa.PrivateKey)}
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	cbor, err := SignedCorimIn.SignWithContext(context.Background(), kms)
+	require.NoError(t, err)
+	assert.Equal(t, 1, kms.calls)
+
+	var SignedCorimOut SignedCorim
+	require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+	assert.NoError(t, SignedCorimOut.Verify(pk))
+
+	// the context is passed through
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var SignedCorimIn2 SignedCorim
+
+	SignedCorimIn2.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn2.Meta = *metaGood(t)
+
+	_, err = SignedCorimIn2.SignWithContext(ctx, kms)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, kms.calls)
+
+	_, err = SignedCorimIn2.SignWithContext(context.Background(), nil)
+	assert.EqualError(t, err, "nil signer")
+}
+
+func TestNewLocalSigner(t *testing.T) {
+	alg, key, err := getAlgAndKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	s, err := NewLocalSigner(alg, key)
+	require.NoError(t, err)
+	assert.Equal(t, cose.AlgorithmES256, s.Algorithm())
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	cbor, err := SignedCorimIn.SignWithContext(context.Background(), s)
+	require.NoError(t, err)
+
+	var SignedCorimOut SignedCorim
+	require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+	assert.NoError(t, SignedCorimOut.Verify(pk))
+
+	_, err = NewLocalSigner(cose.AlgorithmEd25519, key)
+	assert.Error(t, err)
+}
@@ -0,0 +1,312 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/veraison/swid"
+)
+
+// ErrDependencyCycle is returned by ResolveDependencies when a dependent RIM
+// (directly or indirectly) depends on one of the CoRIMs that reference it
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// ErrMaxDepthExceeded is returned by ResolveDependenciesWithOptions when a
+// dependent RIM is deeper than ResolveOptions.MaxDepth
+var ErrMaxDepthExceeded = errors.New("maximum dependency depth exceeded")
+
+// ThumbprintMismatchError is returned by ResolveDependencies when the digest
+// of a fetched dependent RIM does not match the thumbprint in its locator.  It
+// is also returned, with an empty Href, by UnsignedCorim.VerifyAgainstThumbprint.
+type ThumbprintMismatchError struct {
+	Href     string
+	Expected swid.HashEntry
+	Actual   []byte
+}
+
+func (o *ThumbprintMismatchError) Error() string {
+	if o.Href == "" {
+		return fmt.Sprintf(
+			"thumbprint mismatch: expecting %x, got %x",
+			o.Expected.HashValue, o.Actual,
+		)
+	}
+
+	return fmt.Sprintf(
+		"thumbprint mismatch for %q: expecting %x, got %x",
+		o.Href, o.Expected.HashValue, o.Actual,
+	)
+}
+
+// ResolveDependencies fetches, using the supplied fetch function, the CoRIMs
+// referenced by the dependent-rims of the target CoRIM, and recursively their
+// dependent-rims.  Where a locator carries a thumbprint, the fetched data is
+// checked against it, and a *ThumbprintMismatchError is returned if the
+// check fails.  Signed CoRIMs are decoded but their signature is not verified;
+// doing so is the responsibility of the caller.  The returned slice contains
+// each dependency once, in the order it was first encountered.  Cycles are
+// reported using ErrDependencyCycle.  If ctx is cancelled or its deadline
+// expires, ctx.Err() is returned without waiting for any pending fetch to
+// complete (fetch should nevertheless honour ctx, so that it does not keep
+// running in the background).
+func (o UnsignedCorim) ResolveDependencies(
+	ctx context.Context,
+	fetch func(ctx context.Context, href string) ([]byte, error),
+) ([]UnsignedCorim, error) {
+	return o.ResolveDependenciesWithOptions(ctx, fetch, ResolveOptions{})
+}
+
+// ResolveOptions specifies the limits enforced by
+// ResolveDependenciesWithOptions
+type ResolveOptions struct {
+	// MaxDepth is the maximum depth of the dependency graph, where the direct
+	// dependents of the target CoRIM are at depth 1.  Dependent RIMs beyond
+	// it are not fetched and cause ErrMaxDepthExceeded to be returned.  Zero
+	// means no limit.
+	MaxDepth int
+}
+
+// ResolveDependenciesWithOptions is like ResolveDependencies, but enforces the
+// supplied options
+func (o UnsignedCorim) ResolveDependenciesWithOptions(
+	ctx context.Context,
+	fetch func(ctx context.Context, href string) ([]byte, error),
+	opts ResolveOptions,
+) ([]UnsignedCorim, error) {
+	if fetch == nil {
+		return nil, errors.New("nil fetch function")
+	}
+
+	if opts.MaxDepth < 0 {
+		return nil, fmt.Errorf("invalid maximum depth %d", opts.MaxDepth)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r := resolver{
+		fetch:    fetch,
+		maxDepth: opts.MaxDepth,
+		seen:     make(map[string]bool),
+	}
+
+	if err := r.resolve(ctx, o, []string{o.ID.String()}); err != nil {
+		return nil, err
+	}
+
+	return r.resolved, nil
+}
+
+// ErrMissingDependentContent is returned by VerifyDependentContent when no
+// content is supplied for one of the dependent RIMs
+var ErrMissingDependentContent = errors.New("missing content for dependent RIM")
+
+// VerifyDependentContent checks the supplied, already fetched, dependent RIMs,
+// keyed by href, against the thumbprints in the dependent-rims of the target
+// CoRIM, without fetching anything.  Dependent RIMs without a thumbprint only
+// need to be present.  The problems found for all the dependent RIMs are
+// joined in the returned error: missing content is reported using
+// ErrMissingDependentContent, and mismatching content using a
+// *ThumbprintMismatchError.
+func (o UnsignedCorim) VerifyDependentContent(content map[string][]byte) error {
+	if o.DependentRims == nil {
+		return nil
+	}
+
+	var errs []error
+
+	for i, l := range *o.DependentRims {
+		href := string(l.Href)
+
+		data, ok := content[href]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w at pos %d (%s)", ErrMissingDependentContent, i, href))
+			continue
+		}
+
+		if l.Thumbprint == nil {
+			continue
+		}
+
+		if err := checkThumbprints(href, l.Thumbprints(), data); err != nil {
+			errs = append(errs, fmt.Errorf("dependent RIM at pos %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CountDirectDependents returns the number of dependent RIMs referenced by the
+// target CoRIM, without resolving them
+func (o UnsignedCorim) CountDirectDependents() int {
+	if o.DependentRims == nil {
+		return 0
+	}
+
+	return len(*o.DependentRims)
+}
+
+type resolver struct {
+	fetch    func(ctx context.Context, href string) ([]byte, error)
+	maxDepth int
+	seen     map[string]bool
+	resolved []UnsignedCorim
+}
+
+// resolve fetches the dependencies of c.  path holds the corim-ids of the
+// CoRIMs being resolved, from the root to c.
+func (o *resolver) resolve(ctx context.Context, c UnsignedCorim, path []string) error {
+	if c.DependentRims == nil {
+		return nil
+	}
+
+	for i, l := range *c.DependentRims {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		href := string(l.Href)
+
+		if o.seen[href] {
+			continue
+		}
+
+		// the dependents of c are one level deeper than c
+		if depth := len(path); o.maxDepth != 0 && depth > o.maxDepth {
+			return fmt.Errorf(
+				"%w: dependent RIM at pos %d (%s) is at depth %d, the maximum is %d",
+				ErrMaxDepthExceeded, i, href, depth, o.maxDepth,
+			)
+		}
+
+		data, err := o.fetchDependency(ctx, href)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return fmt.Errorf("fetching dependent RIM at pos %d (%s): %w", i, href, err)
+		}
+
+		if l.Thumbprint != nil {
+			if err := checkThumbprints(href, l.Thumbprints(), data); err != nil {
+				return err
+			}
+		}
+
+		dep, err := decodeDependency(data)
+		if err != nil {
+			return fmt.Errorf("decoding dependent RIM at pos %d (%s): %w", i, href, err)
+		}
+
+		id := dep.ID.String()
+		for _, p := range path {
+			if p == id {
+				return fmt.Errorf(
+					"%w: %s -> %s",
+					ErrDependencyCycle, strings.Join(path, " -> "), id,
+				)
+			}
+		}
+
+		o.seen[href] = true
+		o.resolved = append(o.resolved, *dep)
+
+		if err := o.resolve(ctx, *dep, append(path, id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchDependency calls fetch, but returns as soon as ctx is done, even if
+// fetch does not honour ctx
+func (o *resolver) fetchDependency(ctx context.Context, href string) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	ch := make(chan result, 1)
+
+	go func() {
+		data, err := o.fetch(ctx, href)
+		ch <- result{data, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.data, r.err
+	}
+}
+
+func decodeDependency(data []byte) (*UnsignedCorim, error) {
+	// a COSE_Sign1 or COSE_Sign (optionally wrapped in
+	// tagged-corim-type-choice) is a signed CoRIM, anything else must be an
+	// unsigned-corim-map
+	if bytes.HasPrefix(data, []byte{0xd2}) || bytes.HasPrefix(data, coseSignTag) ||
+		bytes.HasPrefix(data, []byte("\xd9\x01\xf4\xd9\x01\xf6")) {
+		var s SignedCorim
+		if err := s.FromCOSE(data); err != nil {
+			return nil, err
+		}
+		return &s.UnsignedCorim, nil
+	}
+
+	var u UnsignedCorim
+	if err := u.FromCBOR(data); err != nil {
+		return nil, err
+	}
+
+	if err := u.Valid(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return &u, nil
+}
+
+// checkThumbprints succeeds if data matches any of the supplied thumbprints
+// that use a supported hash algorithm
+func checkThumbprints(href string, tps []swid.HashEntry, data []byte) error {
+	var mismatch error
+
+	for _, tp := range tps {
+		digest, ok := thumbprintDigest(tp, data)
+		if !ok {
+			continue
+		}
+
+		if thumbprintMatches(&tp, tp.HashAlgID, digest) {
+			return nil
+		}
+
+		if mismatch == nil {
+			mismatch = &ThumbprintMismatchError{
+				Href:     href,
+				Expected: tp,
+				Actual:   digest,
+			}
+		}
+	}
+
+	if mismatch != nil {
+		return mismatch
+	}
+
+	return fmt.Errorf("unsupported thumbprint algorithm %d for %q", tps[0].HashAlgID, href)
+}
+
+// thumbprintDigest computes the digest of data using the hash algorithm of
+// the supplied thumbprint.  It returns false if the algorithm is not supported.
+func thumbprintDigest(tp swid.HashEntry, data []byte) ([]byte, bool) {
+	return digest(tp.HashAlgID, data)
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/swid"
+)
+
+func dependentCorim(t *testing.T, id string, deps ...string) *UnsignedCorim {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, c.SetID(id))
+
+	for _, d := range deps {
+		require.NotNil(t, c.AddDependentRim(d, nil))
+	}
+
+	return c
+}
+
+func mapFetcher(t *testing.T, rims map[string]*UnsignedCorim) func(context.Context, string) ([]byte, error) {
+	data := make(map[string][]byte, len(rims))
+	for href, c := range rims {
+		b, err := c.ToCBOR()
+		require.NoError(t, err)
+		data[href] = b
+	}
+
+	return func(_ context.Context, href string) ([]byte, error) {
+		b, ok := data[href]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return b, nil
+	}
+}
+
+func TestUnsignedCorim_ResolveDependencies_ok(t *testing.T) {
+	root := dependentCorim(t, "root", "https://example.com/a", "https://example.com/b")
+
+	fetch := mapFetcher(t, map[string]*UnsignedCorim{
+		"https://example.com/a": dependentCorim(t, "a", "https://example.com/c"),
+		"https://example.com/b": dependentCorim(t, "b", "https://example.com/c"),
+		"https://example.com/c": dependentCorim(t, "c"),
+	})
+
+	deps, err := root.ResolveDependencies(context.Background(), fetch)
+	require.NoError(t, err)
+	require.Len(t, deps, 3)
+	assert.Equal(t, "a", deps[0].GetID())
+	assert.Equal(t, "c", deps[1].GetID())
+	assert.Equal(t, "b", deps[2].GetID())
+}
+
+func TestUnsignedCorim_ResolveDependencies_no_dependencies(t *testing.T) {
+	root := dependentCorim(t, "root")
+
+	deps, err := root.ResolveDependencies(context.Background(), mapFetcher(t, nil))
+	require.NoError(t, err)
+	assert.Empty(t, deps)
+}
+
+func TestUnsignedCorim_ResolveDependencies_thumbprint(t *testing.T) {
+	dep := dependentCorim(t, "a")
+	data, err := dep.ToCBOR()
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(data)
+	goodTP := swid.HashEntry{HashAlgID: swid.Sha256, HashValue: digest[:]}
+	badTP := swid.HashEntry{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)}
+
+	fetch := mapFetcher(t, map[string]*UnsignedCorim{"https://example.com/a": dep})
+
+	root := dependentCorim(t, "root")
+	require.NotNil(t, root.AddDependentRim("https://example.com/a", &goodTP))
+
+	deps, err := root.ResolveDependencies(context.Background(), fetch)
+	require.NoError(t, err)
+	assert.Len(t, deps, 1)
+
+	root = dependentCorim(t, "root")
+	require.NotNil(t, root.AddDependentRim("https://example.com/a", &badTP))
+
+	_, err = root.ResolveDependencies(context.Background(), fetch)
+
+	var tpErr *ThumbprintMismatchError
+	require.ErrorAs(t, err, &tpErr)
+	assert.Equal(t, "https://example.com/a", tpErr.Href)
+	assert.Equal(t, digest[:], tpErr.Actual)
+}
+
+func TestUnsignedCorim_ResolveDependencies_cycle(t *testing.T) {
+	root := dependentCorim(t, "root", "https://example.com/a")
+
+	fetch := mapFetcher(t, map[string]*UnsignedCorim{
+		"https://example.com/a":    dependentCorim(t, "a", "https://example.com/b"),
+		"https://example.com/b":    dependentCorim(t, "b", "https://example.com/root"),
+		"https://example.com/root": root,
+	})
+
+	_, err := root.ResolveDependencies(context.Background(), fetch)
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+	assert.EqualError(t, err, "dependency cycle: root -> a -> b -> root")
+}
+
+func TestUnsignedCorim_ResolveDependencies_fetch_failure(t *testing.T) {
+	root := dependentCorim(t, "root", "https://example.com/missing")
+
+	_, err := root.ResolveDependencies(context.Background(), mapFetcher(t, nil))
+	assert.EqualError(t, err, "fetching dependent RIM at pos 0 (https://example.com/missing): not found")
+
+	_, err = root.ResolveDependencies(context.Background(), nil)
+	assert.EqualError(t, err, "nil fetch function")
+}
+
+func TestUnsignedCorim_ResolveDependencies_any_thumbprint(t *testing.T) {
+	dep := dependentCorim(t, "a")
+	data, err := dep.ToCBOR()
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(data)
+	fetch := mapFetcher(t, map[string]*UnsignedCorim{"https://example.com/a": dep})
+
+	// the SHA-384 thumbprint is stale, but the SHA-256 one matches
+	root := dependentCorim(t, "root")
+	require.NotNil(t, root.AddDependentRim("https://example.com/a",
+		&swid.HashEntry{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)},
+		&swid.HashEntry{HashAlgID: swid.Sha256, HashValue: digest[:]},
+	))
+
+	deps, err := root.ResolveDependencies(context.Background(), fetch)
+	require.NoError(t, err)
+	assert.Len(t, deps, 1)
+}
+
+func TestUnsignedCorim_VerifyDependentContent(t *testing.T) {
+	a := []byte("content of a")
+	b := []byte("content of b")
+
+	digestA := sha256.Sum256(a)
+	digestB := sha256.Sum256(b)
+
+	root := dependentCorim(t, "root", "https://example.com/no-thumbprint")
+	require.NotNil(t, root.
+		AddDependentRim("https://example.com/a", &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: digestA[:]}).
+		AddDependentRim("https://example.com/b", &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: digestB[:]}))
+
+	content := map[string][]byte{
+		"https://example.com/no-thumbprint": []byte("anything"),
+		"https://example.com/a":             a,
+		"https://example.com/b":             b,
+	}
+
+	assert.NoError(t, root.VerifyDependentContent(content))
+
+	// mismatch
+	content["https://example.com/b"] = a
+
+	err := root.VerifyDependentContent(content)
+
+	var tpErr *ThumbprintMismatchError
+	require.ErrorAs(t, err, &tpErr)
+	assert.Equal(t, "https://example.com/b", tpErr.Href)
+	assert.Equal(t, digestA[:], tpErr.Actual)
+	assert.NotErrorIs(t, err, ErrMissingDependentContent)
+
+	// missing, reported along with the mismatch
+	delete(content, "https://example.com/a")
+
+	err = root.VerifyDependentContent(content)
+	assert.ErrorIs(t, err, ErrMissingDependentContent)
+	assert.ErrorAs(t, err, &tpErr)
+	assert.EqualError(t, err,
+		"missing content for dependent RIM at pos 1 (https://example.com/a)\n"+
+			"dependent RIM at pos 2: "+tpErr.Error())
+
+	// no dependent RIMs
+	assert.NoError(t, dependentCorim(t, "root").VerifyDependentContent(nil))
+}
+
+func TestUnsignedCorim_ResolveDependenciesWithOptions_MaxDepth(t *testing.T) {
+	root := dependentCorim(t, "root", "https://example.com/a")
+
+	fetched := 0
+	fetch := mapFetcher(t, map[string]*UnsignedCorim{
+		"https://example.com/a": dependentCorim(t, "a", "https://example.com/b"),
+		"https://example.com/b": dependentCorim(t, "b", "https://example.com/c"),
+		"https://example.com/c": dependentCorim(t, "c"),
+	})
+	countingFetch := func(ctx context.Context, href string) ([]byte, error) {
+		fetched++
+		return fetch(ctx, href)
+	}
+
+	deps, err := root.ResolveDependenciesWithOptions(context.Background(), countingFetch, ResolveOptions{MaxDepth: 3})
+	require.NoError(t, err)
+	assert.Len(t, deps, 3)
+
+	deps, err = root.ResolveDependenciesWithOptions(context.Background(), countingFetch, ResolveOptions{})
+	require.NoError(t, err)
+	assert.Len(t, deps, 3)
+
+	fetched = 0
+	_, err = root.ResolveDependenciesWithOptions(context.Background(), countingFetch, ResolveOptions{MaxDepth: 2})
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+	assert.EqualError(t, err, "maximum dependency depth exceeded: dependent RIM at pos 0 (https://example.com/c) is at depth 3, the maximum is 2")
+	assert.Equal(t, 2, fetched)
+
+	_, err = root.ResolveDependenciesWithOptions(context.Background(), countingFetch, ResolveOptions{MaxDepth: -1})
+	assert.EqualError(t, err, "invalid maximum depth -1")
+}
+
+func TestUnsignedCorim_CountDirectDependents(t *testing.T) {
+	assert.Equal(t, 0, dependentCorim(t, "root").CountDirectDependents())
+	assert.Equal(t, 2, dependentCorim(t, "root", "https://example.com/a", "https://example.com/b").CountDirectDependents())
+}
+
+func TestUnsignedCorim_ResolveDependencies_cancelled(t *testing.T) {
+	root := dependentCorim(t, "root", "https://example.com/a", "https://example.com/b")
+	fetch := mapFetcher(t, map[string]*UnsignedCorim{
+		"https://example.com/a": dependentCorim(t, "a"),
+		"https://example.com/b": dependentCorim(t, "b"),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	deps, err := root.ResolveDependencies(ctx, fetch)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, deps)
+
+	// cancelled while the first fetch is in flight: the fetched data is
+	// discarded and the second fetch is not attempted
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var hrefs []string
+	cancelling := func(ctx context.Context, href string) ([]byte, error) {
+		hrefs = append(hrefs, href)
+		cancel()
+		return fetch(ctx, href)
+	}
+
+	deps, err = root.ResolveDependencies(ctx, cancelling)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, deps)
+	assert.Equal(t, []string{"https://example.com/a"}, hrefs)
+}
+
+func TestUnsignedCorim_ResolveDependencies_deadline(t *testing.T) {
+	root := dependentCorim(t, "root", "https://example.com/a")
+
+	// a fetch function that ignores ctx and never returns
+	release := make(chan struct{})
+	defer close(release)
+
+	hanging := func(context.Context, string) ([]byte, error) {
+		<-release
+		return nil, errors.New("released")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	deps, err := root.ResolveDependencies(ctx, hanging)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, deps)
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrSelfTest is returned by SelfTest when the round-trip fails
+var ErrSelfTest = errors.New("self-test failed")
+
+// SelfTest encodes the target unsigned CoRIM to CBOR, decodes the result into
+// a fresh UnsignedCorim (with the extensions registered for its profile, see
+// UnmarshalUnsignedCorimFromCBOR), and checks that the decoded CoRIM is valid,
+// that it is equal to the target (see Equal), and that it re-encodes to the
+// same bytes.  It can be used as a cheap check of the CBOR environment.
+func (o UnsignedCorim) SelfTest() error {
+	data, err := o.ToCBOR()
+	if err != nil {
+		return fmt.Errorf("%w: encoding: %w", ErrSelfTest, err)
+	}
+
+	decoded, err := UnmarshalUnsignedCorimFromCBOR(data)
+	if err != nil {
+		return fmt.Errorf("%w: decoding: %w", ErrSelfTest, err)
+	}
+
+	if err := decoded.Valid(); err != nil {
+		return fmt.Errorf("%w: validation: %w", ErrSelfTest, err)
+	}
+
+	if !o.Equal(*decoded) {
+		return fmt.Errorf("%w: decoded CoRIM differs from the original", ErrSelfTest)
+	}
+
+	reencoded, err := decoded.ToCBOR()
+	if err != nil {
+		return fmt.Errorf("%w: re-encoding: %w", ErrSelfTest, err)
+	}
+
+	if !bytes.Equal(data, reencoded) {
+		return fmt.Errorf("%w: re-encoded CoRIM differs from the original encoding", ErrSelfTest)
+	}
+
+	return nil
+}
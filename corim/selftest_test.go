@@ -0,0 +1,39 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsignedCorim_SelfTest(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	assert.NoError(t, c.SelfTest())
+
+	c = NewTestCorim().
+		SetProfile("http://example.com/profile").
+		AddDependentRim("https://example.com/dep.cbor", nil).
+		AddEntity("ACME Ltd.", nil, RoleManifestCreator).
+		SetExtension(-1, "raw extension")
+	require.NotNil(t, c)
+	assert.NoError(t, c.SelfTest())
+
+	// an entity extension that is not registered for the profile is lost
+	// when decoding
+	type lossy struct {
+		Extra string `cbor:"-70000,keyasint,omitempty" json:"extra,omitempty"`
+	}
+
+	c.Entities.Values[0].Extensions.Register(&lossy{Extra: "x"})
+	err := c.SelfTest()
+	assert.ErrorIs(t, err, ErrSelfTest)
+	assert.EqualError(t, err, "self-test failed: decoded CoRIM differs from the original")
+
+	err = NewUnsignedCorim().SetID("no tags").SelfTest()
+	assert.ErrorIs(t, err, ErrSelfTest)
+	assert.ErrorContains(t, err, "self-test failed: validation: ")
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	cbor "github.com/fxamacker/cbor/v2"
+)
+
+// EncodeCorimSequence writes the supplied unsigned CoRIMs to w as a CBOR
+// sequence (RFC 8742), i.e., as the concatenation of their CBOR encodings.
+// The CoRIMs are not validated.
+func EncodeCorimSequence(w io.Writer, corims ...UnsignedCorim) error {
+	for i, c := range corims {
+		data, err := c.ToCBOR()
+		if err != nil {
+			return fmt.Errorf("encoding CoRIM at pos %d: %w", i, err)
+		}
+
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("writing CoRIM at pos %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// DecodeCorimSequence reads a CBOR sequence (RFC 8742) of unsigned CoRIMs from
+// r until EOF.  The CoRIMs are not validated.  If an item cannot be decoded
+// (e.g., because the sequence is truncated), the CoRIMs decoded up to that
+// point are returned together with an error that reports the offset of the
+// failing item.
+func DecodeCorimSequence(r io.Reader) ([]UnsignedCorim, error) {
+	var corims []UnsignedCorim
+
+	dec := dm.NewDecoder(r)
+
+	for {
+		offset := dec.NumBytesRead()
+
+		var raw cbor.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return corims, nil
+			}
+			return corims, fmt.Errorf("decoding CoRIM at offset %d: %w", offset, err)
+		}
+
+		var c UnsignedCorim
+		if err := c.FromCBOR(raw); err != nil {
+			return corims, fmt.Errorf("decoding CoRIM at offset %d: %w", offset, err)
+		}
+
+		corims = append(corims, c)
+	}
+}
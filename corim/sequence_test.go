@@ -0,0 +1,61 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorimSequence_round_trip(t *testing.T) {
+	a := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	b := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).SetID("another corim id")
+	require.NotNil(t, b)
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeCorimSequence(&buf, *a, *b))
+
+	expected := append(bytes.Clone(testGoodUnsignedCorimCBOR), mustToCBOR(t, b)...)
+	assert.Equal(t, expected, buf.Bytes())
+
+	actual, err := DecodeCorimSequence(&buf)
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+	assert.Equal(t, "test corim id", actual[0].GetID())
+	assert.Equal(t, "another corim id", actual[1].GetID())
+}
+
+func TestDecodeCorimSequence_empty(t *testing.T) {
+	actual, err := DecodeCorimSequence(bytes.NewReader(nil))
+	require.NoError(t, err)
+	assert.Empty(t, actual)
+}
+
+func TestDecodeCorimSequence_truncated(t *testing.T) {
+	data := append(bytes.Clone(testGoodUnsignedCorimCBOR), testGoodUnsignedCorimCBOR[:10]...)
+
+	actual, err := DecodeCorimSequence(bytes.NewReader(data))
+	assert.Len(t, actual, 1)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.ErrorContains(t, err,
+		"decoding CoRIM at offset 440: ")
+}
+
+func TestDecodeCorimSequence_not_a_corim(t *testing.T) {
+	data := append(bytes.Clone(testGoodUnsignedCorimCBOR), 0x01)
+
+	actual, err := DecodeCorimSequence(bytes.NewReader(data))
+	assert.Len(t, actual, 1)
+	assert.ErrorContains(t, err, "decoding CoRIM at offset 440: expected map")
+}
+
+func mustToCBOR(t *testing.T, c *UnsignedCorim) []byte {
+	data, err := c.ToCBOR()
+	require.NoError(t, err)
+	return data
+}
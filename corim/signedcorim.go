@@ -7,14 +7,43 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/rand"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/veraison/corim/extensions"
 	cose "github.com/veraison/go-cose"
 )
 
+var (
+	// ErrSignatureVerification is returned by VerifyStrict when the
+	// signature of the signed-corim does not verify
+	ErrSignatureVerification = errors.New("signature verification failed")
+	// ErrUnsignedCorimValidation is returned by VerifyStrict when the
+	// signature verifies, but the embedded unsigned-corim is not valid
+	ErrUnsignedCorimValidation = errors.New("unsigned CoRIM validation failed")
+	// ErrProfileMismatch is returned when the profile advertised in the
+	// corim-meta-map of the protected header differs from the profile of the
+	// unsigned CoRIM
+	ErrProfileMismatch = errors.New("profile mismatch")
+)
+
+// ContentTypeMismatchError is returned when decoding a signed CoRIM whose
+// content type differs from the expected one (see SignedCorim.ContentType)
+type ContentTypeMismatchError struct {
+	Expected string
+	Actual   interface{}
+}
+
+func (o *ContentTypeMismatchError) Error() string {
+	return fmt.Sprintf("expecting content type %q, got %q instead", o.Expected, o.Actual)
+}
+
+// coseSignTag is the CBOR encoding of the head of a COSE_Sign_Tagged message
+var coseSignTag = []byte{0xd8, 0x62}
+
 var (
 	ContentType          = "application/rim+cbor"
 	NoExternalData       = []byte("")
@@ -26,7 +55,15 @@ var (
 type SignedCorim struct {
 	UnsignedCorim UnsignedCorim
 	Meta          Meta
-	message       *cose.Sign1Message
+	// ContentType is the content type in the protected header.  If set, it
+	// is used by the signing methods in place of the registered ContentType,
+	// and it is the content type expected when decoding.  Messages with
+	// a different content type are rejected with a
+	// *ContentTypeMismatchError.
+	ContentType  string
+	message      *cose.Sign1Message
+	multiMessage *cose.SignMessage
+	unverified   bool
 }
 
 // NewSignedCorim instantiates an empty SignedCorim
@@ -34,6 +71,22 @@ func NewSignedCorim() *SignedCorim {
 	return &SignedCorim{}
 }
 
+// Unsigned returns a copy of the unsigned CoRIM wrapped by the target
+// SignedCorim.  Changes to the returned value do not affect the SignedCorim.
+func (o SignedCorim) Unsigned() UnsignedCorim {
+	return *o.UnsignedCorim.Clone()
+}
+
+// ToSigned wraps a copy of the target unsigned CoRIM in a new SignedCorim with
+// the supplied metadata.  The returned SignedCorim is not signed until Sign
+// (or one of its variants) is called on it.
+func (o UnsignedCorim) ToSigned(meta Meta) *SignedCorim {
+	return &SignedCorim{
+		UnsignedCorim: *o.Clone(),
+		Meta:          meta,
+	}
+}
+
 func (o *SignedCorim) RegisterExtensions(exts extensions.Map) error {
 	unsignedExts := extensions.NewMap()
 
@@ -52,9 +105,17 @@ func (o *SignedCorim) RegisterExtensions(exts extensions.Map) error {
 	return o.UnsignedCorim.RegisterExtensions(unsignedExts)
 }
 
-func (o *SignedCorim) processHdrs() error {
-	var hdr = o.message.Headers
+// contentType returns the content type to use in, or to expect from, the
+// protected header
+func (o SignedCorim) contentType() string {
+	if o.ContentType != "" {
+		return o.ContentType
+	}
+
+	return ContentType
+}
 
+func (o *SignedCorim) processHdrs(hdr cose.Headers) error {
 	if hdr.Protected == nil {
 		return errors.New("missing mandatory protected header")
 	}
@@ -64,8 +125,8 @@ func (o *SignedCorim) processHdrs() error {
 		return errors.New("missing mandatory content type")
 	}
 
-	if v != ContentType {
-		return fmt.Errorf("expecting content type %q, got %q instead", ContentType, v)
+	if expected := o.contentType(); v != expected {
+		return &ContentTypeMismatchError{Expected: expected, Actual: v}
 	}
 
 	// TODO(tho) key id is apparently mandatory, which doesn't look right.
@@ -99,81 +160,425 @@ func (o *SignedCorim) processHdrs() error {
 // On success, the unsigned-corim-map is made available via the UnsignedCorim
 // field while the corim-meta-map is decoded into the Meta field.
 func (o *SignedCorim) FromCOSE(buf []byte) error {
-	o.message = cose.NewSign1Message()
+	return o.fromCOSE(buf, nil, true)
+}
 
-	// If a tagged-corim-type-choice #6.500 of tagged-signed-corim #6.502, strip the prefix.
-	// This is a remnant of an older draft of the specification before
-	// https://github.com/ietf-rats-wg/draft-ietf-rats-corim/pull/337
-	corimTypeChoice := []byte("\xd9\x01\xf4\xd9\x01\xf6")
-	buf, _ = bytes.CutPrefix(buf, corimTypeChoice)
+// FromCBORUnverified decodes the supplied signed-corim message into the target
+// SignedCorim for inspection purposes.  Neither the signature nor the validity
+// of the embedded unsigned-corim and its profile are checked, and the target
+// is marked as unverified (see IsUnverified) until one of its signatures is
+// successfully verified.
+func (o *SignedCorim) FromCBORUnverified(buf []byte) error {
+	if err := o.fromCOSE(buf, nil, false); err != nil {
+		return err
+	}
+
+	o.unverified = true
+
+	return nil
+}
 
-	if err := o.message.UnmarshalCBOR(buf); err != nil {
-		return fmt.Errorf("failed CBOR decoding for COSE-Sign1 signed CoRIM: %w", err)
+// IsUnverified returns true if the target SignedCorim was decoded using
+// FromCBORUnverified and its signature has not been verified since.  The
+// contents of an unverified SignedCorim must not be trusted.
+func (o SignedCorim) IsUnverified() bool {
+	return o.unverified
+}
+
+// FromDetachedCOSE is like FromCOSE, but for a COSE_Sign1 message whose payload
+// is detached (see SignDetached).  The supplied payload is the detached
+// unsigned-corim.
+func (o *SignedCorim) FromDetachedCOSE(buf, payload []byte) error {
+	if len(payload) == 0 {
+		return errors.New("empty detached payload")
+	}
+
+	return o.fromCOSE(buf, payload, true)
+}
+
+func (o *SignedCorim) fromCOSE(buf, detached []byte, validate bool) error {
+	o.unverified = false
+
+	buf = stripCorimTypeChoice(buf)
+
+	var (
+		hdr     cose.Headers
+		payload []byte
+	)
+
+	if bytes.HasPrefix(buf, coseSignTag) {
+		if detached != nil {
+			return errors.New("detached payloads are only supported for COSE-Sign1 signed CoRIMs")
+		}
+
+		o.message = nil
+		o.multiMessage = cose.NewSignMessage()
+
+		if err := o.multiMessage.UnmarshalCBOR(buf); err != nil {
+			return fmt.Errorf("failed CBOR decoding for COSE-Sign signed CoRIM: %w", err)
+		}
+
+		hdr, payload = o.multiMessage.Headers, o.multiMessage.Payload
+	} else {
+		o.multiMessage = nil
+		o.message = cose.NewSign1Message()
+
+		if err := o.message.UnmarshalCBOR(buf); err != nil {
+			return fmt.Errorf("failed CBOR decoding for COSE-Sign1 signed CoRIM: %w", err)
+		}
+
+		if detached != nil {
+			if o.message.Payload != nil {
+				return errors.New("COSE-Sign1 payload is not detached")
+			}
+			o.message.Payload = detached
+		}
+
+		hdr, payload = o.message.Headers, o.message.Payload
 	}
 
-	if err := o.processHdrs(); err != nil {
+	if err := o.processHdrs(hdr); err != nil {
 		return fmt.Errorf("processing COSE headers: %w", err)
 	}
 
-	if err := o.UnsignedCorim.FromCBOR(o.message.Payload); err != nil {
+	if err := o.UnsignedCorim.FromCBOR(payload); err != nil {
 		return fmt.Errorf("failed CBOR decoding of unsigned CoRIM: %w", err)
 	}
 
+	if !validate {
+		return nil
+	}
+
 	if err := o.UnsignedCorim.Valid(); err != nil {
 		return fmt.Errorf("failed validation of unsigned CoRIM: %w", err)
 	}
 
+	return o.checkProfile()
+}
+
+// stripCorimTypeChoice returns the supplied signed-corim with the
+// tagged-corim-type-choice #6.500 of tagged-signed-corim #6.502 prefix, if any,
+// stripped.  This is a remnant of an older draft of the specification before
+// https://github.com/ietf-rats-wg/draft-ietf-rats-corim/pull/337
+func stripCorimTypeChoice(buf []byte) []byte {
+	corimTypeChoice := []byte("\xd9\x01\xf4\xd9\x01\xf6")
+	buf, _ = bytes.CutPrefix(buf, corimTypeChoice)
+	return buf
+}
+
+// signedPayload returns the payload of the supplied COSE_Sign1 or COSE_Sign
+// signed-corim message, without verifying its signatures
+func signedPayload(buf []byte) ([]byte, error) {
+	buf = stripCorimTypeChoice(buf)
+
+	if bytes.HasPrefix(buf, coseSignTag) {
+		message := cose.NewSignMessage()
+
+		if err := message.UnmarshalCBOR(buf); err != nil {
+			return nil, fmt.Errorf("failed CBOR decoding for COSE-Sign signed CoRIM: %w", err)
+		}
+
+		return message.Payload, nil
+	}
+
+	message := cose.NewSign1Message()
+
+	if err := message.UnmarshalCBOR(buf); err != nil {
+		return nil, fmt.Errorf("failed CBOR decoding for COSE-Sign1 signed CoRIM: %w", err)
+	}
+
+	return message.Payload, nil
+}
+
+// checkProfile verifies that the profile advertised in the corim-meta-map, if
+// any, is the same as the profile of the unsigned CoRIM
+func (o SignedCorim) checkProfile() error {
+	if o.Meta.Profile == nil {
+		return nil
+	}
+
+	advertised, err := profileString(o.Meta.Profile)
+	if err != nil {
+		return fmt.Errorf("corim-meta profile: %w", err)
+	}
+
+	declared, err := profileString(o.UnsignedCorim.Profile)
+	if err != nil {
+		return fmt.Errorf("unsigned CoRIM profile: %w", err)
+	}
+
+	if advertised != declared {
+		return fmt.Errorf(
+			"%w: protected header has %q, payload has %q",
+			ErrProfileMismatch, advertised, declared,
+		)
+	}
+
+	return nil
+}
+
+// syncProfile copies the profile of the unsigned CoRIM into the
+// corim-meta-map, so that it is advertised in the protected header
+func (o *SignedCorim) syncProfile() error {
+	if err := o.checkProfile(); err != nil {
+		return err
+	}
+
+	o.Meta.Profile = o.UnsignedCorim.Profile
+
 	return nil
 }
 
+// SigningPayload returns the CBOR-encoded unsigned-corim-map exactly as it
+// appears in the payload of the COSE message produced by Sign and SignMulti,
+// e.g., for computing the Sig_structure outside of this package.  The payload
+// always uses Core Deterministic Encoding, regardless of the setting of
+// SetDeterministicEncoding.
+func (o UnsignedCorim) SigningPayload() ([]byte, error) {
+	return o.toCBOR(modes.detEnc)
+}
+
 // Sign returns the serialized signed-corim, signed by the supplied cose Signer.
 // The target SignedCorim must have its UnsignedCorim field correctly
 // populated.
 func (o *SignedCorim) Sign(signer cose.Signer) ([]byte, error) {
+	if err := o.sign1(signer, nil); err != nil {
+		return nil, err
+	}
+
+	wrap, err := o.message.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("signed-corim marshaling failed: %w", err)
+	}
+
+	return wrap, nil
+}
+
+// SignDetached is like Sign, but the payload of the returned COSE_Sign1 is
+// detached, i.e., set to nil (RFC 8152, Section 4.1).  The unsigned-corim
+// covered by the signature is returned separately, and must be supplied
+// alongside the COSE_Sign1 to VerifyDetached.
+func (o *SignedCorim) SignDetached(signer cose.Signer) ([]byte, []byte, error) {
+	if err := o.sign1(signer, nil); err != nil {
+		return nil, nil, err
+	}
+
+	payload := o.message.Payload
+
+	o.message.Payload = nil
+	wrap, err := o.message.MarshalCBOR()
+	o.message.Payload = payload
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("signed-corim marshaling failed: %w", err)
+	}
+
+	return wrap, payload, nil
+}
+
+// sign1 populates and signs the COSE_Sign1 message of the target SignedCorim
+func (o *SignedCorim) sign1(signer cose.Signer, chain [][]byte) error {
 	if signer == nil {
-		return nil, errors.New("nil signer")
+		return errors.New("nil signer")
 	}
 
 	if err := o.UnsignedCorim.Valid(); err != nil {
-		return nil, fmt.Errorf("failed validation of unsigned CoRIM: %w", err)
+		return fmt.Errorf("failed validation of unsigned CoRIM: %w", err)
+	}
+
+	if err := o.syncProfile(); err != nil {
+		return err
 	}
 
 	o.message = cose.NewSign1Message()
+	o.multiMessage = nil
 
 	var err error
-	o.message.Payload, err = o.UnsignedCorim.ToCBOR()
+	o.message.Payload, err = o.UnsignedCorim.SigningPayload()
 	if err != nil {
-		return nil, fmt.Errorf("failed CBOR encoding of unsigned CoRIM: %w", err)
+		return fmt.Errorf("failed CBOR encoding of unsigned CoRIM: %w", err)
 	}
 
 	metaCBOR, err := o.Meta.ToCBOR()
 	if err != nil {
-		return nil, fmt.Errorf("failed CBOR encoding of CoRIM Meta: %w", err)
+		return fmt.Errorf("failed CBOR encoding of CoRIM Meta: %w", err)
 	}
 
 	alg := signer.Algorithm()
 
 	if strings.Contains(alg.String(), "unknown algorithm value") {
-		return nil, errors.New("signer has no algorithm")
+		return errors.New("signer has no algorithm")
 	}
 
 	o.message.Headers.Protected.SetAlgorithm(alg)
-	o.message.Headers.Protected[cose.HeaderLabelContentType] = ContentType
+	o.message.Headers.Protected[cose.HeaderLabelContentType] = o.contentType()
 	o.message.Headers.Protected[HeaderLabelCorimMeta] = metaCBOR
 
+	if chain != nil {
+		o.message.Headers.Protected[cose.HeaderLabelX5Chain] = x5chainHeader(chain)
+	}
+
 	err = o.message.Sign(rand.Reader, NoExternalData, signer)
 	if err != nil {
-		return nil, fmt.Errorf("COSE Sign1 signature failed: %w", err)
+		return fmt.Errorf("COSE Sign1 signature failed: %w", err)
 	}
 
-	wrap, err := o.message.MarshalCBOR()
+	return nil
+}
+
+// SignMulti returns the serialized signed-corim, signed by each of the supplied
+// cose Signers, as a COSE_Sign message (tag 98).  The algorithm of each signer
+// is carried in the protected header of the corresponding COSE_Signature.  The
+// target SignedCorim must have its UnsignedCorim field correctly populated.
+func (o *SignedCorim) SignMulti(signers []cose.Signer) ([]byte, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("no signers")
+	}
+
+	if err := o.UnsignedCorim.Valid(); err != nil {
+		return nil, fmt.Errorf("failed validation of unsigned CoRIM: %w", err)
+	}
+
+	if err := o.syncProfile(); err != nil {
+		return nil, err
+	}
+
+	msg := cose.NewSignMessage()
+
+	var err error
+	msg.Payload, err = o.UnsignedCorim.SigningPayload()
+	if err != nil {
+		return nil, fmt.Errorf("failed CBOR encoding of unsigned CoRIM: %w", err)
+	}
+
+	metaCBOR, err := o.Meta.ToCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("failed CBOR encoding of CoRIM Meta: %w", err)
+	}
+
+	msg.Headers.Protected[cose.HeaderLabelContentType] = o.contentType()
+	msg.Headers.Protected[HeaderLabelCorimMeta] = metaCBOR
+
+	for i, signer := range signers {
+		if signer == nil {
+			return nil, fmt.Errorf("nil signer at pos %d", i)
+		}
+
+		alg := signer.Algorithm()
+
+		if strings.Contains(alg.String(), "unknown algorithm value") {
+			return nil, fmt.Errorf("signer at pos %d has no algorithm", i)
+		}
+
+		sig := cose.NewSignature()
+		sig.Headers.Protected.SetAlgorithm(alg)
+
+		msg.Signatures = append(msg.Signatures, sig)
+	}
+
+	if err := msg.Sign(rand.Reader, NoExternalData, signers...); err != nil {
+		return nil, fmt.Errorf("COSE Sign signature failed: %w", err)
+	}
+
+	wrap, err := msg.MarshalCBOR()
 	if err != nil {
 		return nil, fmt.Errorf("signed-corim marshaling failed: %w", err)
 	}
 
+	o.message = nil
+	o.multiMessage = msg
+
 	return wrap, nil
 }
 
+// VerifyMulti verifies the signatures of the target SignedCorim object, which
+// must have been obtained from a COSE_Sign message, using the supplied public
+// keys.  It succeeds if at least threshold signatures verify, each with a
+// different key.  Keys that appear more than once in keys are only counted
+// once.
+func (o *SignedCorim) VerifyMulti(keys []crypto.PublicKey, threshold int) error {
+	if o.multiMessage == nil {
+		return errors.New("no Sign message found")
+	}
+
+	if threshold < 1 {
+		return fmt.Errorf("invalid threshold %d", threshold)
+	}
+
+	keys, err := uniqueKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	protected, err := o.multiMessage.Headers.MarshalProtected()
+	if err != nil {
+		return fmt.Errorf("unable to encode protected header: %w", err)
+	}
+
+	used := make([]bool, len(keys))
+	verified := 0
+
+	for _, sig := range o.multiMessage.Signatures {
+		alg, err := sig.Headers.Protected.Algorithm()
+		if err != nil {
+			continue
+		}
+
+		for i, pk := range keys {
+			if used[i] {
+				continue
+			}
+
+			verifier, err := cose.NewVerifier(alg, pk)
+			if err != nil {
+				continue
+			}
+
+			err = sig.Verify(verifier, protected, o.multiMessage.Payload, NoExternalData)
+			if err == nil {
+				used[i] = true
+				verified++
+				break
+			}
+		}
+	}
+
+	if verified < threshold {
+		return fmt.Errorf(
+			"%d of %d signatures verified, %d required",
+			verified, len(o.multiMessage.Signatures), threshold,
+		)
+	}
+
+	o.unverified = false
+
+	return nil
+}
+
+// uniqueKeys returns the supplied public keys with duplicates removed.  Keys
+// are compared by their PKIX encoding, so that the same key obtained twice
+// is recognised as such.
+func uniqueKeys(keys []crypto.PublicKey) ([]crypto.PublicKey, error) {
+	seen := make(map[string]bool, len(keys))
+	ret := make([]crypto.PublicKey, 0, len(keys))
+
+	for i, pk := range keys {
+		der, err := x509.MarshalPKIXPublicKey(pk)
+		if err != nil {
+			return nil, fmt.Errorf("public key at pos %d: %w", i, err)
+		}
+
+		if seen[string(der)] {
+			continue
+		}
+
+		seen[string(der)] = true
+		ret = append(ret, pk)
+	}
+
+	return ret, nil
+}
+
 // Verify verifies the signature of the target SignedCorim object using the
 // supplied public key
 func (o *SignedCorim) Verify(pk crypto.PublicKey) error {
@@ -198,5 +603,50 @@ func (o *SignedCorim) Verify(pk crypto.PublicKey) error {
 		return err
 	}
 
+	o.unverified = false
+
+	return nil
+}
+
+// VerifyAt is like Verify, but it additionally checks that the supplied time
+// falls within the validity period of the corim-meta-map, if one is present
+func (o *SignedCorim) VerifyAt(pk crypto.PublicKey, now time.Time) error {
+	if err := o.Verify(pk); err != nil {
+		return err
+	}
+
+	if o.Meta.Validity != nil {
+		if err := o.Meta.Validity.CheckTime(now); err != nil {
+			return fmt.Errorf("CoRIM Meta: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// VerifyDetached decodes the supplied COSE_Sign1 message with detached payload
+// into the target SignedCorim (see FromDetachedCOSE), and verifies its
+// signature, computed over the supplied payload, using the supplied public key
+func (o *SignedCorim) VerifyDetached(buf, payload []byte, pk crypto.PublicKey) error {
+	if err := o.FromDetachedCOSE(buf, payload); err != nil {
+		return err
+	}
+
+	return o.Verify(pk)
+}
+
+// VerifyStrict is like Verify, but it additionally checks the validity of the
+// embedded unsigned CoRIM once the signature has been verified.  A signature
+// failure is reported as ErrSignatureVerification, while an invalid unsigned
+// CoRIM is reported as ErrUnsignedCorimValidation.
+func (o *SignedCorim) VerifyStrict(pk crypto.PublicKey) error {
+	if err := o.Verify(pk); err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureVerification, err)
+	}
+
+	if err := o.UnsignedCorim.Valid(); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnsignedCorimValidation, err)
+	}
+
 	return nil
 }
@@ -4,13 +4,19 @@
 package corim
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/veraison/corim/extensions"
+	"github.com/veraison/eat"
+	cose "github.com/veraison/go-cose"
 )
 
 var (
@@ -397,6 +403,62 @@ func TestSignedCorim_FromCOSE_fail_unexpected_content_type(t *testing.T) {
 	err := actual.FromCOSE(tv)
 
 	assert.EqualError(t, err, `processing COSE headers: expecting content type "application/rim+cbor", got "application/cbor" instead`)
+
+	var ctErr *ContentTypeMismatchError
+	require.ErrorAs(t, err, &ctErr)
+	assert.Equal(t, "application/rim+cbor", ctErr.Expected)
+	assert.Equal(t, "application/cbor", ctErr.Actual)
+}
+
+func TestSignedCorim_ContentType_override(t *testing.T) {
+	const vendorType = "application/vnd.example.rim+cbor"
+
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	SignedCorimIn := SignedCorim{
+		UnsignedCorim: *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR),
+		Meta:          *metaGood(t),
+		ContentType:   vendorType,
+	}
+
+	for _, sign := range []func() ([]byte, error){
+		func() ([]byte, error) { return SignedCorimIn.Sign(signer) },
+		func() ([]byte, error) { return SignedCorimIn.SignMulti([]cose.Signer{signer}) },
+	} {
+		wrap, err := sign()
+		require.NoError(t, err)
+
+		// the registered content type is expected by default
+		var SignedCorimOut SignedCorim
+		err = SignedCorimOut.FromCOSE(wrap)
+
+		var ctErr *ContentTypeMismatchError
+		require.ErrorAs(t, err, &ctErr)
+		assert.Equal(t, ContentType, ctErr.Expected)
+		assert.Equal(t, vendorType, ctErr.Actual)
+
+		SignedCorimOut = SignedCorim{ContentType: vendorType}
+		require.NoError(t, SignedCorimOut.FromCOSE(wrap))
+	}
+
+	wrap, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	SignedCorimOut := SignedCorim{ContentType: vendorType}
+	require.NoError(t, SignedCorimOut.FromCOSE(wrap))
+	assert.NoError(t, SignedCorimOut.Verify(pk))
+
+	// the default is the registered content type
+	SignedCorimIn.ContentType = ""
+	wrap, err = SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	SignedCorimOut = SignedCorim{}
+	require.NoError(t, SignedCorimOut.FromCOSE(wrap))
 }
 
 func unsignedCorimFromCBOR(t *testing.T, cbor []byte) *UnsignedCorim {
@@ -530,3 +592,411 @@ func TestSignedCorim_extensions(t *testing.T) {
 	err = s.RegisterExtensions(badMap)
 	assert.EqualError(t, err, `unexpected extension point: "test"`)
 }
+
+func TestSignedCorim_VerifyStrict(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	cbor, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	var SignedCorimOut SignedCorim
+
+	err = SignedCorimOut.FromCOSE(cbor)
+	require.NoError(t, err)
+
+	assert.NoError(t, SignedCorimOut.VerifyStrict(pk))
+
+	// the signature is still good, but the unsigned CoRIM is no longer valid
+	SignedCorimOut.UnsignedCorim.Tags = nil
+
+	err = SignedCorimOut.VerifyStrict(pk)
+	assert.ErrorIs(t, err, ErrUnsignedCorimValidation)
+	assert.NotErrorIs(t, err, ErrSignatureVerification)
+	assert.EqualError(t, err, "unsigned CoRIM validation failed: tags validation failed: no tags")
+
+	// flip the last byte in the signature field
+	cbor[len(cbor)-1] ^= 0xff
+
+	err = SignedCorimOut.FromCOSE(cbor)
+	require.NoError(t, err)
+
+	err = SignedCorimOut.VerifyStrict(pk)
+	assert.ErrorIs(t, err, ErrSignatureVerification)
+	assert.EqualError(t, err, "signature verification failed: verification error")
+}
+
+func TestSignedCorim_VerifyAt(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	meta := NewMeta().
+		SetSigner("ACME Ltd.", nil).
+		SetValidity(notAfter, &notBefore)
+	require.NotNil(t, meta)
+
+	SignedCorimIn := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).ToSigned(*meta)
+
+	cbor, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	var SignedCorimOut SignedCorim
+
+	err = SignedCorimOut.FromCOSE(cbor)
+	require.NoError(t, err)
+	require.NotNil(t, SignedCorimOut.Meta.Validity)
+	assert.True(t, notAfter.Equal(SignedCorimOut.Meta.Validity.NotAfter))
+
+	assert.NoError(t, SignedCorimOut.VerifyAt(pk, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	err = SignedCorimOut.VerifyAt(pk, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrNotYetValid)
+
+	err = SignedCorimOut.VerifyAt(pk, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.EqualError(t, err, "CoRIM Meta: validity period expired: not-after is 2025-01-01T00:00:00Z")
+
+	// without a validity period only the signature is checked
+	meta.Validity = nil
+	SignedCorimIn.Meta = *meta
+
+	cbor, err = SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	err = SignedCorimOut.FromCOSE(cbor)
+	require.NoError(t, err)
+
+	assert.NoError(t, SignedCorimOut.VerifyAt(pk, time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestSignedCorim_FromCBORUnverified(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	otherPK, err := NewPublicKeyFromJWK(testES384Key)
+	require.NoError(t, err)
+
+	unsigned := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	cbor, err := unsigned.ToSigned(*metaGood(t)).Sign(signer)
+	require.NoError(t, err)
+
+	// corrupt the signature
+	cbor[len(cbor)-1] ^= 0xff
+
+	var actual SignedCorim
+
+	require.NoError(t, actual.FromCBORUnverified(cbor))
+	assert.True(t, actual.IsUnverified())
+	assert.Equal(t, unsigned.ID, actual.UnsignedCorim.ID)
+	assert.Equal(t, "ACME Ltd.", actual.Meta.Signer.Name)
+
+	assert.Error(t, actual.Verify(pk))
+	assert.True(t, actual.IsUnverified())
+
+	// FromCOSE resets the flag
+	require.NoError(t, actual.FromCOSE(cbor))
+	assert.False(t, actual.IsUnverified())
+
+	// the embedded unsigned CoRIM is not validated
+	metaCBOR, err := metaGood(t).ToCBOR()
+	require.NoError(t, err)
+
+	msg := cose.NewSign1Message()
+	msg.Headers.Protected.SetAlgorithm(signer.Algorithm())
+	msg.Headers.Protected[cose.HeaderLabelContentType] = ContentType
+	msg.Headers.Protected[HeaderLabelCorimMeta] = metaCBOR
+	msg.Payload = []byte{0xa2, 0x00, 0x62, 0x69, 0x64, 0x01, 0x80} // {0: "id", 1: []}
+	require.NoError(t, msg.Sign(rand.Reader, NoExternalData, signer))
+
+	cbor, err = msg.MarshalCBOR()
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, actual.FromCOSE(cbor), "failed validation of unsigned CoRIM")
+
+	require.NoError(t, actual.FromCBORUnverified(cbor))
+	assert.True(t, actual.IsUnverified())
+	assert.Empty(t, actual.UnsignedCorim.Tags)
+
+	assert.Error(t, actual.Verify(otherPK))
+	assert.True(t, actual.IsUnverified())
+
+	require.NoError(t, actual.Verify(pk))
+	assert.False(t, actual.IsUnverified())
+
+	assert.ErrorContains(t, actual.FromCBORUnverified([]byte{0x00}), "failed CBOR decoding")
+}
+
+func TestSignedCorim_SignMulti_VerifyMulti(t *testing.T) {
+	es256Signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+	es384Signer, err := NewSignerFromJWK(testES384Key)
+	require.NoError(t, err)
+
+	es256PK, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+	es384PK, err := NewPublicKeyFromJWK(testES384Key)
+	require.NoError(t, err)
+	edPK, err := NewPublicKeyFromJWK(testEdDSAKey)
+	require.NoError(t, err)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	_, err = SignedCorimIn.SignMulti(nil)
+	assert.EqualError(t, err, "no signers")
+
+	cbor, err := SignedCorimIn.SignMulti([]cose.Signer{es256Signer, es384Signer})
+	require.NoError(t, err)
+
+	// COSE_Sign_Tagged
+	assert.Equal(t, []byte{0xd8, 0x62}, cbor[:2])
+
+	var SignedCorimOut SignedCorim
+
+	require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+	assert.Equal(t, SignedCorimIn.UnsignedCorim.GetID(), SignedCorimOut.UnsignedCorim.GetID())
+	assert.Equal(t, "ACME Ltd.", SignedCorimOut.Meta.Signer.Name)
+
+	keys := []crypto.PublicKey{edPK, es384PK, es256PK}
+
+	assert.NoError(t, SignedCorimOut.VerifyMulti(keys, 2))
+	assert.NoError(t, SignedCorimOut.VerifyMulti(keys[:2], 1))
+	assert.EqualError(t, SignedCorimOut.VerifyMulti(keys[:2], 2),
+		"1 of 2 signatures verified, 2 required")
+
+	// a key can only vouch for one signature
+	assert.EqualError(t, SignedCorimOut.VerifyMulti([]crypto.PublicKey{es256PK, es256PK}, 2),
+		"1 of 2 signatures verified, 2 required")
+
+	// ... even if the same signer signed twice, and the key is supplied
+	// twice
+	twice, err := SignedCorimIn.SignMulti([]cose.Signer{es256Signer, es256Signer})
+	require.NoError(t, err)
+
+	var SignedCorimTwice SignedCorim
+	require.NoError(t, SignedCorimTwice.FromCOSE(twice))
+
+	es256PKCopy, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	assert.EqualError(t, SignedCorimTwice.VerifyMulti([]crypto.PublicKey{es256PK, es256PKCopy}, 2),
+		"1 of 2 signatures verified, 2 required")
+	assert.NoError(t, SignedCorimTwice.VerifyMulti([]crypto.PublicKey{es256PK, es256PKCopy}, 1))
+
+	assert.ErrorContains(t, SignedCorimOut.VerifyMulti([]crypto.PublicKey{es256PK, "bogus"}, 1),
+		"public key at pos 1: ")
+
+	// COSE_Sign messages are also accepted by the profile-aware decoder
+	decoded, err := UnmarshalSignedCorimFromCBOR(cbor)
+	require.NoError(t, err)
+	assert.NoError(t, decoded.VerifyMulti(keys, 2))
+
+	assert.EqualError(t, SignedCorimOut.VerifyMulti(keys, 0), "invalid threshold 0")
+	assert.EqualError(t, SignedCorimOut.Verify(es256PK), "no Sign1 message found")
+
+	var single SignedCorim
+	assert.EqualError(t, single.VerifyMulti(keys, 1), "no Sign message found")
+}
+
+func TestSignedCorim_SignDetached_VerifyDetached(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	sig, payload, err := SignedCorimIn.SignDetached(signer)
+	require.NoError(t, err)
+
+	expectedPayload, err := SignedCorimIn.UnsignedCorim.ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, expectedPayload, payload)
+
+	// the payload is not embedded in the COSE_Sign1
+	assert.False(t, bytes.Contains(sig, payload))
+	assert.Less(t, len(sig), len(payload))
+
+	var SignedCorimOut SignedCorim
+
+	require.NoError(t, SignedCorimOut.VerifyDetached(sig, payload, pk))
+	assert.Equal(t, "test corim id", SignedCorimOut.UnsignedCorim.GetID())
+	assert.Equal(t, "ACME Ltd.", SignedCorimOut.Meta.Signer.Name)
+
+	// a detached COSE_Sign1 cannot be decoded without its payload...
+	assert.Error(t, SignedCorimOut.FromCOSE(sig))
+	assert.EqualError(t, SignedCorimOut.FromDetachedCOSE(sig, nil), "empty detached payload")
+
+	// ... nor verified with a different one
+	other := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, other.SetID("other corim id"))
+	otherPayload, err := other.ToCBOR()
+	require.NoError(t, err)
+
+	assert.EqualError(t, SignedCorimOut.VerifyDetached(sig, otherPayload, pk), "verification error")
+
+	// an attached COSE_Sign1 is rejected
+	attached, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+	assert.EqualError(t, SignedCorimOut.VerifyDetached(attached, payload, pk),
+		"COSE-Sign1 payload is not detached")
+}
+
+func TestSignedCorim_Sign_profile_in_protected_header(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, SignedCorimIn.UnsignedCorim.SetProfile("https://example.com/profile"))
+	SignedCorimIn.Meta = *metaGood(t)
+
+	data, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	var SignedCorimOut SignedCorim
+
+	require.NoError(t, SignedCorimOut.FromCOSE(data))
+	require.NotNil(t, SignedCorimOut.Meta.Profile)
+	assert.Equal(t, *SignedCorimIn.UnsignedCorim.Profile, *SignedCorimOut.Meta.Profile)
+
+	// a different profile set in Meta is rejected when signing
+	other, err := eat.NewProfile("1.2.3.4")
+	require.NoError(t, err)
+	SignedCorimIn.Meta.Profile = other
+
+	_, err = SignedCorimIn.Sign(signer)
+	assert.ErrorIs(t, err, ErrProfileMismatch)
+	assert.EqualError(t, err,
+		`profile mismatch: protected header has "1.2.3.4", payload has "https://example.com/profile"`)
+}
+
+func TestSignedCorim_FromCOSE_profile_mismatch(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	uc := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, uc.SetProfile("https://example.com/payload-profile"))
+	payload, err := uc.ToCBOR()
+	require.NoError(t, err)
+
+	meta := metaGood(t)
+	meta.Profile, err = eat.NewProfile("https://example.com/header-profile")
+	require.NoError(t, err)
+	metaCBOR, err := meta.ToCBOR()
+	require.NoError(t, err)
+
+	msg := cose.NewSign1Message()
+	msg.Payload = payload
+	msg.Headers.Protected.SetAlgorithm(signer.Algorithm())
+	msg.Headers.Protected[cose.HeaderLabelContentType] = ContentType
+	msg.Headers.Protected[HeaderLabelCorimMeta] = metaCBOR
+	require.NoError(t, msg.Sign(rand.Reader, NoExternalData, signer))
+
+	data, err := msg.MarshalCBOR()
+	require.NoError(t, err)
+
+	var SignedCorimOut SignedCorim
+
+	err = SignedCorimOut.FromCOSE(data)
+	assert.ErrorIs(t, err, ErrProfileMismatch)
+}
+
+func TestSignedCorim_ToSigned_Unsigned(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	uc := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	sc := uc.ToSigned(*metaGood(t))
+	require.NotNil(t, sc)
+
+	// the SignedCorim holds a copy
+	require.NotNil(t, uc.SetID("changed"))
+	assert.Equal(t, "test corim id", sc.UnsignedCorim.GetID())
+
+	cbor, err := sc.Sign(signer)
+	require.NoError(t, err)
+
+	var out SignedCorim
+	require.NoError(t, out.FromCOSE(cbor))
+	require.NoError(t, out.Verify(pk))
+
+	// mutating the unwrapped CoRIM does not change the SignedCorim
+	inner := out.Unsigned()
+	assert.Equal(t, "test corim id", inner.GetID())
+	require.NotNil(t, inner.SetID("changed"))
+	inner.Tags[0] = Tag{0xd9, 0x01, 0xf8, 0x00}
+
+	assert.Equal(t, "test corim id", out.UnsignedCorim.GetID())
+	assert.NoError(t, out.UnsignedCorim.Tags[0].Valid())
+	assert.NoError(t, out.Verify(pk))
+}
+
+func TestUnsignedCorim_SigningPayload(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.UnsignedCorim.RawExtensions = map[int64]cbor.RawMessage{
+		-1:  {0xf5},
+		-10: {0x62, 'e', 'n'}, // LanguageKey
+		6:   {0x01},
+		24:  {0xf4},
+	}
+	SignedCorimIn.Meta = *metaGood(t)
+
+	payload, err := SignedCorimIn.UnsignedCorim.SigningPayload()
+	require.NoError(t, err)
+
+	// the keys are in Core Deterministic Encoding order: 6, 24, -1, -10
+	canonical, err := canonicalCBOR(payload)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(canonical, payload))
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(payload))
+	assert.True(t, SignedCorimIn.UnsignedCorim.Equal(actual))
+
+	sig, detached, err := SignedCorimIn.SignDetached(signer)
+	require.NoError(t, err)
+	assert.NotNil(t, sig)
+	assert.Equal(t, payload, detached)
+
+	wrap, err := SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+
+	var msg cose.Sign1Message
+	require.NoError(t, msg.UnmarshalCBOR(wrap))
+	assert.Equal(t, payload, msg.Payload)
+}
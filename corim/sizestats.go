@@ -0,0 +1,62 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"fmt"
+
+	cbor "github.com/fxamacker/cbor/v2"
+)
+
+// SizeStats is the breakdown of the CBOR encoding size of an unsigned CoRIM,
+// as returned by UnsignedCorim.SizeStats.  All sizes are in bytes and, except
+// for Tags, include the encoding of the corresponding map value only (not of
+// its key).
+type SizeStats struct {
+	// Total is the size of the whole unsigned-corim-map
+	Total int
+	// Tags is the size of each entry of the tags array, in order, including
+	// the byte string wrapping of the tag
+	Tags []int
+	// TagsTotal is the size of the tags array
+	TagsTotal int
+	// Profile is the size of the profile, or zero if absent
+	Profile int
+	// DependentRims is the size of the dependent-rims array, or zero if
+	// absent
+	DependentRims int
+}
+
+// SizeStats encodes the target unsigned CoRIM to CBOR (see ToCBOR) and
+// measures the size of the resulting encoding and of its sections
+func (o UnsignedCorim) SizeStats() (SizeStats, error) {
+	data, err := o.ToCBOR()
+	if err != nil {
+		return SizeStats{}, fmt.Errorf("encoding CoRIM: %w", err)
+	}
+
+	var m map[int64]cbor.RawMessage
+	if err := dm.Unmarshal(data, &m); err != nil {
+		return SizeStats{}, fmt.Errorf("decoding CoRIM: %w", err)
+	}
+
+	stats := SizeStats{
+		Total:         len(data),
+		TagsTotal:     len(m[1]),
+		DependentRims: len(m[2]),
+		Profile:       len(m[3]),
+	}
+
+	var tags []cbor.RawMessage
+	if err := dm.Unmarshal(m[1], &tags); err != nil {
+		return SizeStats{}, fmt.Errorf("decoding tags: %w", err)
+	}
+
+	stats.Tags = make([]int, len(tags))
+	for i, t := range tags {
+		stats.Tags[i] = len(t)
+	}
+
+	return stats, nil
+}
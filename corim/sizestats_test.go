@@ -0,0 +1,59 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestUnsignedCorim_SizeStats(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	stats, err := tv.SizeStats()
+	require.NoError(t, err)
+
+	assert.Equal(t, len(testGoodUnsignedCorimCBOR), stats.Total)
+	require.Len(t, stats.Tags, 1)
+
+	tag, err := em.Marshal(tv.Tags[0])
+	require.NoError(t, err)
+	assert.Equal(t, len(tag), stats.Tags[0])
+
+	tags, err := em.Marshal(tv.Tags)
+	require.NoError(t, err)
+	assert.Equal(t, len(tags), stats.TagsTotal)
+
+	assert.Zero(t, stats.Profile)
+	assert.Zero(t, stats.DependentRims)
+
+	require.NotNil(t, tv.
+		AddComid(comidFromJSON(t, comid.PSAKeysJSONTemplate)).
+		SetProfile("http://example.com/p").
+		AddDependentRim("https://endorser.example/dep.corim"))
+
+	stats, err = tv.SizeStats()
+	require.NoError(t, err)
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	assert.Equal(t, len(data), stats.Total)
+	require.Len(t, stats.Tags, 2)
+
+	tag, err = em.Marshal(tv.Tags[1])
+	require.NoError(t, err)
+	assert.Equal(t, len(tag), stats.Tags[1])
+
+	profile, err := em.Marshal(tv.Profile)
+	require.NoError(t, err)
+	assert.Equal(t, len(profile), stats.Profile)
+
+	rims, err := em.Marshal(tv.DependentRims)
+	require.NoError(t, err)
+	assert.Equal(t, len(rims), stats.DependentRims)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SplitBySize splits the target unsigned CoRIM into CoRIMs whose CBOR encoding
+// (see ToCBOR) is at most maxBytes long.  Every chunk carries the same
+// corim-id, profile, dependent RIMs and other fields as the target, and a
+// subset of its tags, which are packed greedily in their original order.  An
+// error is returned if a tag does not fit in a chunk on its own, or if any of
+// the chunks is not valid.
+func (o UnsignedCorim) SplitBySize(maxBytes int) ([]UnsignedCorim, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("invalid size limit %d", maxBytes)
+	}
+
+	if len(o.Tags) == 0 {
+		return nil, errors.New("no tags to split")
+	}
+
+	empty := o
+	empty.Tags = []Tag{}
+
+	data, err := empty.ToCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("encoding unsigned CoRIM: %w", err)
+	}
+
+	// the size of everything but the tags array, whose head is one byte
+	// long when empty
+	base := len(data) - 1
+
+	var (
+		chunks [][]Tag
+		cur    []Tag
+		curLen int
+	)
+
+	for i, t := range o.Tags {
+		tagLen := cborHeadLen(uint64(len(t))) + len(t)
+
+		if base+cborHeadLen(1)+tagLen > maxBytes {
+			return nil, fmt.Errorf(
+				"tag at pos %d does not fit in %d bytes (needs %d)",
+				i, maxBytes, base+cborHeadLen(1)+tagLen,
+			)
+		}
+
+		if len(cur) != 0 && base+cborHeadLen(uint64(len(cur)+1))+curLen+tagLen > maxBytes {
+			chunks = append(chunks, cur)
+			cur, curLen = nil, 0
+		}
+
+		cur = append(cur, t)
+		curLen += tagLen
+	}
+
+	chunks = append(chunks, cur)
+
+	ret := make([]UnsignedCorim, len(chunks))
+
+	for i, tags := range chunks {
+		c := o
+		c.Tags = tags
+		ret[i] = *c.Clone()
+
+		if err := ret[i].Valid(); err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i, err)
+		}
+	}
+
+	return ret, nil
+}
+
+// cborHeadLen returns the length of the head of a CBOR data item with the
+// supplied argument (e.g., the length of a byte string)
+func cborHeadLen(n uint64) int {
+	switch {
+	case n < 24:
+		return 1
+	case n <= 0xff:
+		return 2
+	case n <= 0xffff:
+		return 3
+	case n <= 0xffffffff:
+		return 5
+	default:
+		return 9
+	}
+}
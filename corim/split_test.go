@@ -0,0 +1,90 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+)
+
+func TestUnsignedCorim_SplitBySize(t *testing.T) {
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate)).
+		AddComid(comidFromJSON(t, comid.PSAKeysJSONTemplate)).
+		AddComid(comidFromJSON(t, comid.CCARealmRefValJSONTemplate)).
+		AddDependentRim("https://example.com/dep.cbor")
+	require.NotNil(t, tv)
+	require.NotNil(t, tv.SetProfile("http://example.com/split"))
+
+	whole, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	// everything fits in one chunk
+	chunks, err := tv.SplitBySize(len(whole))
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.True(t, tv.Equal(chunks[0]))
+
+	// one byte less forces a split
+	chunks, err = tv.SplitBySize(len(whole) - 1)
+	require.NoError(t, err)
+	assert.Greater(t, len(chunks), 1)
+
+	largest := 0
+	for _, tag := range tv.Tags {
+		largest = max(largest, len(tag))
+	}
+
+	for _, limit := range []int{len(whole) - 1, len(whole) - largest} {
+		chunks, err = tv.SplitBySize(limit)
+		require.NoError(t, err)
+
+		var tags []Tag
+
+		for _, c := range chunks {
+			data, err := c.ToCBOR()
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(data), limit)
+
+			assert.NoError(t, c.Valid())
+			assert.Equal(t, tv.GetID(), c.GetID())
+			assert.Equal(t, tv.Profile, c.Profile)
+			assert.Equal(t, tv.DependentRims, c.DependentRims)
+
+			tags = append(tags, c.Tags...)
+		}
+
+		assert.Equal(t, tv.Tags, tags)
+	}
+
+	// the size computation is exact: each chunk is as large as it can be
+	chunks, err = tv.SplitBySize(len(whole) - 1)
+	require.NoError(t, err)
+	first, err := chunks[0].ToCBOR()
+	require.NoError(t, err)
+	next := tv.Tags[len(chunks[0].Tags)]
+	assert.Greater(t, len(first)+len(next)+3, len(whole)-1)
+
+	// chunks do not share memory with the original
+	chunks[0].Tags[0][0] ^= 0xff
+	assert.NotEqual(t, chunks[0].Tags[0][0], tv.Tags[0][0])
+
+	_, err = tv.SplitBySize(100)
+	assert.ErrorContains(t, err, "tag at pos 0 does not fit in 100 bytes")
+
+	_, err = tv.SplitBySize(0)
+	assert.EqualError(t, err, "invalid size limit 0")
+
+	_, err = NewUnsignedCorim().SplitBySize(100)
+	assert.EqualError(t, err, "no tags to split")
+
+	invalid := *tv
+	invalid.ID = NewUnsignedCorim().ID
+	_, err = invalid.SplitBySize(len(whole))
+	assert.ErrorContains(t, err, "chunk 0: ")
+}
@@ -0,0 +1,315 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	cbor "github.com/fxamacker/cbor/v2"
+)
+
+const (
+	cborMajorTypeArray = 4
+	cborMajorTypeMap   = 5
+	cborMajorTypeTag   = 6
+)
+
+// DecodeUnsignedCorimStream incrementally decodes the CBOR-encoded unsigned
+// CoRIM read from r.  Each entry of the tags array is handed to fn, together
+// with its CBOR tag number, as soon as it has been read, so that only one tag
+// is held in memory at any time.  If fn returns an error, decoding stops and the
+// error is returned to the caller.
+//
+// On success, the remaining fields of the unsigned-corim-map (corim-id,
+// profile, dependent RIMs, etc.) are returned as an UnsignedCorim with an empty
+// Tags array.  Note that these fields are only available once the whole stream
+// has been consumed, since the profile and other fields appear after the tags
+// in the encoded map.
+//
+// The default limits of DecodeUnsignedCorimStreamWithOptions apply.
+func DecodeUnsignedCorimStream(
+	r io.Reader,
+	fn func(tagNumber uint64, payload []byte) error,
+) (*UnsignedCorim, error) {
+	return DecodeUnsignedCorimStreamWithOptions(r, fn, DecodeOptions{})
+}
+
+// DecodeUnsignedCorimStreamWithOptions is like DecodeUnsignedCorimStream, but
+// enforces the limits specified by the supplied options.  Since the stream
+// may be arbitrarily long, MaxSize applies to each entry of the tags array,
+// and to each other entry of the unsigned-corim-map, rather than to the
+// whole input.  MaxNestedLevels is the maximum nesting depth of CBOR arrays,
+// maps and tags, counting the unsigned-corim-map itself.  The other options
+// are ignored.
+func DecodeUnsignedCorimStreamWithOptions(
+	r io.Reader,
+	fn func(tagNumber uint64, payload []byte) error,
+	opts DecodeOptions,
+) (*UnsignedCorim, error) {
+	if fn == nil {
+		return nil, errors.New("nil tag handler")
+	}
+
+	lim := itemLimits{maxSize: opts.MaxSize, maxNestedLevels: opts.MaxNestedLevels}
+	if lim.maxSize == 0 {
+		lim.maxSize = DefaultMaxSize
+	}
+	if lim.maxNestedLevels == 0 {
+		lim.maxNestedLevels = DefaultMaxNestedLevels
+	}
+
+	br := bufio.NewReader(r)
+
+	major, n, err := readCBORHead(br, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// skip the optional tagged-unsigned-corim-map wrapper
+	if major == cborMajorTypeTag {
+		if n != UnsignedCorimTagNumber {
+			return nil, fmt.Errorf("unexpected CBOR tag %d", n)
+		}
+
+		if major, n, err = readCBORHead(br, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if major != cborMajorTypeMap {
+		return nil, fmt.Errorf("expecting unsigned-corim-map, got CBOR major type %d", major)
+	}
+
+	entries := make(map[int64]cbor.RawMessage)
+
+	for i := uint64(0); i < n; i++ {
+		var kb bytes.Buffer
+
+		if err := readCBORItem(br, &kb, lim, 1); err != nil {
+			return nil, fmt.Errorf("reading key: %w", err)
+		}
+
+		var key int64
+		if err := dm.Unmarshal(kb.Bytes(), &key); err != nil {
+			return nil, fmt.Errorf("decoding key: %w", err)
+		}
+
+		if key == 1 {
+			if err := streamTags(br, fn, lim); err != nil {
+				return nil, err
+			}
+			// the tags have already been consumed; leave an empty
+			// array behind so that the decoded map is complete
+			entries[key] = cbor.RawMessage{0x80}
+			continue
+		}
+
+		var vb bytes.Buffer
+
+		if err := readCBORItem(br, &vb, lim, 1); err != nil {
+			return nil, fmt.Errorf("reading value for key %d: %w", key, err)
+		}
+
+		entries[key] = vb.Bytes()
+	}
+
+	data, err := em.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	var o UnsignedCorim
+
+	if err := o.FromCBOR(data); err != nil {
+		return nil, err
+	}
+
+	o.Tags = nil
+
+	return &o, nil
+}
+
+// streamTags hands each entry of the tags array read from br to fn.  The array
+// may be encoded as an indefinite-length array (see EncodeOptions).
+func streamTags(br *bufio.Reader, fn func(uint64, []byte) error, lim itemLimits) error {
+	var n uint64
+
+	indefinite, err := consumeByte(br, cborIndefiniteArray)
+	if err != nil {
+		return fmt.Errorf("reading tags: %w", err)
+	}
+
+	if !indefinite {
+		var major byte
+
+		if major, n, err = readCBORHead(br, nil); err != nil {
+			return fmt.Errorf("reading tags: %w", err)
+		}
+
+		if major != cborMajorTypeArray {
+			return fmt.Errorf("expecting tags array, got CBOR major type %d", major)
+		}
+	}
+
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			end, err := consumeByte(br, cborBreak)
+			if err != nil {
+				return fmt.Errorf("reading tag at pos %d: %w", i, err)
+			}
+			if end {
+				break
+			}
+		}
+
+		var b bytes.Buffer
+
+		if err := readCBORItem(br, &b, lim, 2); err != nil {
+			return fmt.Errorf("reading tag at pos %d: %w", i, err)
+		}
+
+		var t Tag
+		if err := dm.Unmarshal(b.Bytes(), &t); err != nil {
+			return fmt.Errorf("decoding tag at pos %d: %w", i, err)
+		}
+
+		num, content, err := t.split()
+		if err != nil {
+			return fmt.Errorf("tag at pos %d: %w", i, err)
+		}
+
+		if err := fn(num, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consumeByte consumes the next byte read from br if it is b (e.g., the head
+// of an indefinite-length array or the "break" stop code), and reports whether
+// it did
+func consumeByte(br *bufio.Reader, b byte) (bool, error) {
+	next, err := br.Peek(1)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return false, err
+	}
+
+	if next[0] != b {
+		return false, nil
+	}
+
+	_, err = br.ReadByte()
+
+	return true, err
+}
+
+// readCBORHead reads the head of a CBOR data item from br, returning its major
+// type and argument.  If buf is not nil, the raw bytes that have been read are
+// appended to it.  Indefinite-length items are rejected.
+func readCBORHead(br *bufio.Reader, buf *bytes.Buffer) (byte, uint64, error) {
+	ib, err := br.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if buf != nil {
+		buf.WriteByte(ib)
+	}
+
+	major, ai := ib>>5, ib&0x1f
+
+	var size int
+
+	switch {
+	case ai < 24:
+		return major, uint64(ai), nil
+	case ai == 24:
+		size = 1
+	case ai == 25:
+		size = 2
+	case ai == 26:
+		size = 4
+	case ai == 27:
+		size = 8
+	case ai == 31:
+		return 0, 0, errors.New("indefinite-length items are not supported")
+	default:
+		return 0, 0, fmt.Errorf("invalid additional information %d", ai)
+	}
+
+	var arg [8]byte
+
+	if _, err := io.ReadFull(br, arg[8-size:]); err != nil {
+		return 0, 0, err
+	}
+
+	if buf != nil {
+		buf.Write(arg[8-size:])
+	}
+
+	return major, binary.BigEndian.Uint64(arg[:]), nil
+}
+
+// itemLimits are the limits enforced by readCBORItem
+type itemLimits struct {
+	maxSize         int
+	maxNestedLevels int
+}
+
+// readCBORItem reads a complete CBOR data item from br and appends its raw
+// encoding to buf.  level is the number of arrays, maps and tags enclosing the
+// item.  An error is returned if the item is nested deeper than, or buf grows
+// larger than, the supplied limits allow.
+func readCBORItem(br *bufio.Reader, buf *bytes.Buffer, lim itemLimits, level int) error {
+	if buf.Len() >= lim.maxSize {
+		return fmt.Errorf("CBOR data item exceeds the maximum of %d bytes", lim.maxSize)
+	}
+
+	major, n, err := readCBORHead(br, buf)
+	if err != nil {
+		return err
+	}
+
+	switch major {
+	case cborMajorTypeArray, cborMajorTypeMap, cborMajorTypeTag:
+		if level++; level > lim.maxNestedLevels {
+			return fmt.Errorf("exceeded max nested level %d", lim.maxNestedLevels)
+		}
+	}
+
+	switch major {
+	case 2, 3: // byte and text strings
+		if n > uint64(lim.maxSize-buf.Len()) {
+			return fmt.Errorf("CBOR data item exceeds the maximum of %d bytes", lim.maxSize)
+		}
+		if _, err := io.CopyN(buf, br, int64(n)); err != nil {
+			return err
+		}
+	case cborMajorTypeArray:
+		for i := uint64(0); i < n; i++ {
+			if err := readCBORItem(br, buf, lim, level); err != nil {
+				return err
+			}
+		}
+	case cborMajorTypeMap:
+		for i := uint64(0); i < 2*n; i++ {
+			if err := readCBORItem(br, buf, lim, level); err != nil {
+				return err
+			}
+		}
+	case cborMajorTypeTag:
+		return readCBORItem(br, buf, lim, level)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/corim/cots"
+)
+
+func TestDecodeUnsignedCorimStream(t *testing.T) {
+	c := comid.Comid{}
+	require.NoError(t, c.FromJSON([]byte(comid.PSARefValJSONTemplate)))
+
+	s := cots.ConciseTaStore{}
+	require.NoError(t, s.FromJSON([]byte(cots.ConciseTaStoreTemplateSingleOrg)))
+
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddComid(c).
+		AddCots(s).
+		AddComid(c).
+		AddDependentRim("https://endorser.example/addon.corim", nil).
+		SetProfile("https://arm.com/psa/iot/2.0.0")
+	require.NotNil(t, tv)
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	var (
+		numbers  []uint64
+		payloads [][]byte
+	)
+
+	actual, err := DecodeUnsignedCorimStream(bytes.NewReader(data),
+		func(tagNumber uint64, payload []byte) error {
+			numbers = append(numbers, tagNumber)
+			payloads = append(payloads, payload)
+			return nil
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint64{506, 507, 506}, numbers)
+	for i, p := range payloads {
+		assert.Equal(t, []byte(tv.Tags[i][3:]), p)
+	}
+
+	assert.Equal(t, "test corim id", actual.GetID())
+	assert.Equal(t, tv.Profile, actual.Profile)
+	assert.Equal(t, tv.DependentRims, actual.DependentRims)
+	assert.Nil(t, actual.Tags)
+
+	// the tagged-unsigned-corim-map form is also accepted
+	tagged := append(append([]byte{}, UnsignedCorimTag...), data...)
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader(tagged),
+		func(uint64, []byte) error { return nil })
+	assert.NoError(t, err)
+}
+
+func TestDecodeUnsignedCorimStream_indefinite_length_tags(t *testing.T) {
+	c := comid.Comid{}
+	require.NoError(t, c.FromJSON([]byte(comid.PSARefValJSONTemplate)))
+
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddComid(c).
+		AddComid(c).
+		SetProfile("https://arm.com/psa/iot/2.0.0")
+	require.NotNil(t, tv)
+
+	for _, opts := range []EncodeOptions{
+		{IndefiniteLengthTags: true},
+		{IndefiniteLengthTags: true, TaggedProfile: true},
+	} {
+		data, err := tv.ToCBORWithOptions(opts)
+		require.NoError(t, err)
+		require.Contains(t, string(data), string([]byte{0x01, cborIndefiniteArray}))
+
+		var payloads [][]byte
+
+		actual, err := DecodeUnsignedCorimStream(bytes.NewReader(data),
+			func(tagNumber uint64, payload []byte) error {
+				assert.Equal(t, ComidTagNumber, tagNumber)
+				payloads = append(payloads, payload)
+				return nil
+			})
+		require.NoError(t, err)
+
+		require.Len(t, payloads, 2)
+		for i, p := range payloads {
+			assert.Equal(t, []byte(tv.Tags[i][3:]), p)
+		}
+
+		assert.Equal(t, "test corim id", actual.GetID())
+		assert.Equal(t, tv.Profile, actual.Profile)
+	}
+
+	// the break stop code must be there
+	data, err := tv.ToCBORWithOptions(EncodeOptions{IndefiniteLengthTags: true})
+	require.NoError(t, err)
+	i := bytes.LastIndexByte(data, cborBreak)
+	truncated := data[:i]
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader(truncated),
+		func(uint64, []byte) error { return nil })
+	assert.ErrorContains(t, err, "reading tag at pos 2: unexpected EOF")
+}
+
+func TestDecodeUnsignedCorimStream_errors(t *testing.T) {
+	nop := func(uint64, []byte) error { return nil }
+
+	_, err := DecodeUnsignedCorimStream(bytes.NewReader(testGoodUnsignedCorimCBOR), nil)
+	assert.EqualError(t, err, "nil tag handler")
+
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader([]byte{0x80}), nop)
+	assert.EqualError(t, err, "expecting unsigned-corim-map, got CBOR major type 4")
+
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader([]byte{0xbf}), nop)
+	assert.EqualError(t, err, "indefinite-length items are not supported")
+
+	truncated := testGoodUnsignedCorimCBOR[:len(testGoodUnsignedCorimCBOR)-1]
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader(truncated), nop)
+	assert.ErrorContains(t, err, "reading tag at pos 0: ")
+
+	// deeply nested items are rejected early, and do not exhaust the stack
+	nested := append([]byte{0xa1, 0x00}, bytes.Repeat([]byte{0x81}, 1<<20)...)
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader(nested), nop)
+	assert.EqualError(t, err, "reading value for key 0: exceeded max nested level 32")
+
+	// {0: "x", 1: [], 99: [[[[0]]]]}
+	nested = []byte{0xa3, 0x00, 0x61, 0x78, 0x01, 0x80, 0x18, 0x63, 0x81, 0x81, 0x81, 0x81, 0x00}
+	_, err = DecodeUnsignedCorimStreamWithOptions(bytes.NewReader(nested), nop,
+		DecodeOptions{MaxNestedLevels: 5})
+	assert.NoError(t, err)
+
+	_, err = DecodeUnsignedCorimStreamWithOptions(bytes.NewReader(nested), nop,
+		DecodeOptions{MaxNestedLevels: 4})
+	assert.EqualError(t, err, "reading value for key 99: exceeded max nested level 4")
+
+	// so are oversized items
+	_, err = DecodeUnsignedCorimStreamWithOptions(bytes.NewReader(testGoodUnsignedCorimCBOR), nop,
+		DecodeOptions{MaxSize: 16})
+	assert.EqualError(t, err, "reading tag at pos 0: CBOR data item exceeds the maximum of 16 bytes")
+
+	huge := []byte{0xa1, 0x01, 0x81, 0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader(huge), nop)
+	assert.EqualError(t, err, fmt.Sprintf(
+		"reading tag at pos 0: CBOR data item exceeds the maximum of %d bytes", DefaultMaxSize))
+
+	many := append([]byte{0xa1, 0x00, 0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		bytes.Repeat([]byte{0x00}, 64)...)
+	_, err = DecodeUnsignedCorimStreamWithOptions(bytes.NewReader(many), nop,
+		DecodeOptions{MaxSize: 32})
+	assert.EqualError(t, err, "reading value for key 0: CBOR data item exceeds the maximum of 32 bytes")
+
+	errStop := errors.New("stop")
+	_, err = DecodeUnsignedCorimStream(bytes.NewReader(testGoodUnsignedCorimCBOR),
+		func(uint64, []byte) error { return errStop })
+	assert.ErrorIs(t, err, errStop)
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/veraison/swid"
+)
+
+// comidTagIDOnly is the subset of a concise-mid-tag decoded by TagIDs
+type comidTagIDOnly struct {
+	TagIdentity *struct {
+		TagID *swid.TagID `cbor:"0,keyasint"`
+	} `cbor:"1,keyasint"`
+}
+
+// coswidTagIDOnly is the subset of a concise-swid-tag decoded by TagIDs
+type coswidTagIDOnly struct {
+	TagID *swid.TagID `cbor:"0,keyasint"`
+}
+
+// TagIDs returns, in order, the tag-ids of the CoMIDs and CoSWIDs found in the
+// tags array of the unsigned-corim-map.  Only the tag-id of each tag is
+// decoded, which is considerably cheaper than GetComids or GetCoswids.  Tags
+// of other types are skipped.
+func (o UnsignedCorim) TagIDs() ([]swid.TagID, error) {
+	var ids []swid.TagID
+
+	for i, t := range o.Tags {
+		_, id, err := tagIDAt(i, t)
+		if err != nil {
+			return nil, err
+		}
+
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	return ids, nil
+}
+
+// tagIDAt returns the CBOR tag number and, for CoMIDs and CoSWIDs, the tag-id
+// of the supplied tag, found at position i of the tags array.  The tag-id is
+// nil for tags of other types.
+func tagIDAt(i int, t Tag) (uint64, *swid.TagID, error) {
+	num, content, err := t.split()
+	if err != nil {
+		return 0, nil, fmt.Errorf("tag at pos %d: %w", i, err)
+	}
+
+	var id *swid.TagID
+
+	switch num {
+	case ComidTagNumber:
+		var c comidTagIDOnly
+		if err := dm.Unmarshal(content, &c); err != nil {
+			return 0, nil, fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+		}
+
+		if c.TagIdentity != nil {
+			id = c.TagIdentity.TagID
+		}
+	case CoswidTagNumber:
+		var c coswidTagIDOnly
+		if err := dm.Unmarshal(content, &c); err != nil {
+			return 0, nil, fmt.Errorf("decoding CoSWID at pos %d: %w", i, err)
+		}
+
+		id = c.TagID
+	default:
+		return num, nil, nil
+	}
+
+	if id == nil {
+		return 0, nil, fmt.Errorf("tag at pos %d: %w", i, errors.New("missing tag-id"))
+	}
+
+	return num, id, nil
+}
+
+// ErrDuplicateTagID is the Kind of the ValidationErrors reported by
+// ValidateTagIDUniqueness
+var ErrDuplicateTagID = errors.New("duplicate tag-id")
+
+// ValidateTagIDUniqueness checks that no two CoMIDs, and no two CoSWIDs, in the
+// tags array of the unsigned-corim-map have the same tag-id.  Each duplicate is
+// reported as a *ValidationError with Kind ErrDuplicateTagID and the position
+// of the duplicate, whose cause names the position of the first occurrence.
+// An error is also returned if a CoMID or CoSWID cannot be decoded.  The same
+// check can be added to Valid using ValidationOptions.UniqueTagIDs.
+func (o UnsignedCorim) ValidateTagIDUniqueness() error {
+	dups, err := o.duplicateTagIDs(false)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(dups))
+	for i, d := range dups {
+		errs[i] = d
+	}
+
+	return errors.Join(errs...)
+}
+
+// duplicateTagIDs returns the duplicates reported by ValidateTagIDUniqueness.
+// If skipUndecodable is set, tags that cannot be decoded are ignored rather
+// than reported as an error.
+func (o UnsignedCorim) duplicateTagIDs(skipUndecodable bool) ([]*ValidationError, error) {
+	type key struct {
+		num uint64
+		id  string
+	}
+
+	var (
+		dups []*ValidationError
+		seen = make(map[key]int)
+	)
+
+	for i, t := range o.Tags {
+		num, id, err := tagIDAt(i, t)
+		if err != nil {
+			if skipUndecodable {
+				continue
+			}
+			return nil, err
+		}
+
+		if id == nil {
+			continue
+		}
+
+		k := key{num, id.String()}
+
+		first, ok := seen[k]
+		if !ok {
+			seen[k] = i
+			continue
+		}
+
+		typ := "CoMID"
+		if num == CoswidTagNumber {
+			typ = "CoSWID"
+		}
+
+		dups = append(dups, &ValidationError{
+			Kind: ErrDuplicateTagID,
+			Pos:  i,
+			Err:  fmt.Errorf("%s tag-id %q already at pos %d", typ, k.id, first),
+		})
+	}
+
+	return dups, nil
+}
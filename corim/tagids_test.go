@@ -0,0 +1,110 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/swid"
+)
+
+func TestUnsignedCorim_TagIDs(t *testing.T) {
+	coswidCorim := unsignedCorimFromCBOR(t, comid.MustHexDecode(t, "a20078197465737420636f72696d206964207769746820436f53574944018159017cd901f9a8007820636f6d2e61636d652e727264323031332d63652d7370312d76342d312d352d300c0001783041434d4520526f616472756e6e6572204465746563746f72203230313320436f796f74652045646974696f6e205350310d65342e312e3505a5182b65747269616c182d6432303133182f66636f796f7465183473526f616472756e6e6572204465746563746f721836637370310282a3181f745468652041434d4520436f72706f726174696f6e18206861636d652e636f6d1821820102a3181f75436f796f74652053657276696365732c20496e632e18206c6d79636f796f74652e636f6d18210404a21826781c7777772e676e752e6f72672f6c6963656e7365732f67706c2e7478741828676c6963656e736506a110a318186a72726465746563746f7218196d2570726f6772616d6461746125181aa111a318186e72726465746563746f722e657865141a000820e80782015820a314fc2dc663ae7a6b6bc6787594057396e6b3f569cd50fd5ddb4d1bbafd2b6a"))
+	cotsCorim := unsignedCorimFromCBOR(t, comid.MustHexDecode(t, "a200777465737420636f72696d206964207769746820436f545301815899d901fba301a20050ab0f44b1bfdc4604ab4a30f80407ebcc01050281a101a100a10173576f7274686c657373205365612c20496e632e06a100818202585b3059301306072a8648ce3d020106082a8648ce3d03010703420004ad8a0c01da9eda0253dc2bc27227d9c7213df8df13e89cb9cdb7a8e4b62d9ce8a99a2d705c0f7f80db65c006d1091422b47fc611cbd46869733d9c483884d5fe"))
+
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).
+		MergeTags(*cotsCorim).
+		MergeTags(*coswidCorim)
+	require.NotNil(t, c)
+
+	ids, err := c.TagIDs()
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", ids[0].String())
+	assert.Equal(t, "com.acme.rrd2013-ce-sp1-v4-1-5-0", ids[1].String())
+
+	// consistent with the full decode
+	comids, err := c.GetComids()
+	require.NoError(t, err)
+	coswids, err := c.GetCoswids()
+	require.NoError(t, err)
+	assert.Equal(t, []swid.TagID{comids[0].TagIdentity.TagID, coswids[0].TagID}, ids)
+
+	ids, err = NewUnsignedCorim().TagIDs()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestUnsignedCorim_TagIDs_errors(t *testing.T) {
+	for _, tc := range []struct {
+		tag      Tag
+		expected string
+	}{
+		{Tag{0xff}, "tag at pos 0: decoding tag: "},
+		{Tag(append(bytes.Clone(ComidTag), 0xa0)), "tag at pos 0: missing tag-id"},
+		{Tag(append(bytes.Clone(ComidTag), 0xa1, 0x01, 0xa0)), "tag at pos 0: missing tag-id"},
+		{Tag(append(bytes.Clone(CoswidTag), 0xa1, 0x01, 0x61, 0x78)), "tag at pos 0: missing tag-id"},
+		{Tag(append(bytes.Clone(ComidTag), 0x80)), "decoding CoMID at pos 0: "},
+		{Tag(append(bytes.Clone(CoswidTag), 0xa1, 0x00, 0xf5)), "decoding CoSWID at pos 0: "},
+	} {
+		c := UnsignedCorim{Tags: []Tag{tc.tag}}
+
+		_, err := c.TagIDs()
+		assert.ErrorContains(t, err, tc.expected)
+	}
+}
+
+func TestUnsignedCorim_ValidateTagIDUniqueness(t *testing.T) {
+	var c swid.SoftwareIdentity
+	err := c.FromXML([]byte(`<SoftwareIdentity xmlns="http://standards.iso.org/iso/19770/-2/2015/schema.xsd" tagId="com.acme.rrd2013-ce-sp1-v4-1-5-0" name="ACME Roadrunner Detector 2013 Coyote Edition SP1" version="4.1.5"><Entity name="The ACME Corporation" regid="acme.com" role="tagCreator softwareCreator"></Entity></SoftwareIdentity>`))
+	require.NoError(t, err)
+
+	refVals := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	keys := comidFromJSON(t, comid.PSAKeysJSONTemplate)
+
+	tv := NewUnsignedCorim().SetID("test corim id").AddComid(refVals).AddComid(keys).AddCoswid(c)
+	require.NotNil(t, tv)
+
+	assert.NoError(t, tv.ValidateTagIDUniqueness())
+	assert.NoError(t, tv.ValidWithOptions(ValidationOptions{UniqueTagIDs: true}))
+
+	// a CoMID and a CoSWID with the same tag-id are not duplicates
+	c2 := c
+	c2.TagID = *swid.NewTagID(refVals.TagIdentity.TagID.String())
+	require.NotNil(t, tv.AddCoswid(c2))
+	assert.NoError(t, tv.ValidateTagIDUniqueness())
+
+	// tags at pos 4 and 5 duplicate those at pos 0 and 2
+	require.NotNil(t, tv.AddComid(refVals).AddCoswid(c))
+
+	err = tv.ValidateTagIDUniqueness()
+	assert.ErrorIs(t, err, ErrDuplicateTagID)
+	assert.EqualError(t, err,
+		`duplicate tag-id at pos 4: CoMID tag-id "`+refVals.TagIdentity.TagID.String()+`" already at pos 0`+"\n"+
+			`duplicate tag-id at pos 5: CoSWID tag-id "com.acme.rrd2013-ce-sp1-v4-1-5-0" already at pos 2`)
+
+	// opt-in in Valid
+	assert.NoError(t, tv.Valid())
+
+	opts := ValidationOptions{UniqueTagIDs: true}
+	assert.ErrorIs(t, tv.ValidWithOptions(opts), ErrDuplicateTagID)
+
+	issues := tv.ValidateWithOptions(opts)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "tags[4]", issues[0].Path)
+	assert.Equal(t, CodeDuplicateTagID, issues[0].Code)
+	assert.Equal(t, ErrDuplicateTagID, issues[0].Kind)
+	assert.Equal(t, 4, issues[0].Pos)
+	assert.Equal(t, 5, issues[1].Pos)
+
+	// undecodable tags are reported by ValidateTagIDUniqueness, but not by the
+	// Valid option
+	tv.Tags = append(tv.Tags, append(bytes.Clone(ComidTag), 0x80))
+	assert.ErrorContains(t, tv.ValidateTagIDUniqueness(), "decoding CoMID at pos 6: ")
+	assert.Len(t, tv.ValidateWithOptions(opts), 2)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"fmt"
+
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/corim/cots"
+	"github.com/veraison/swid"
+)
+
+// TagVisitor is implemented by the handlers passed to UnsignedCorim.WalkTags.
+// OnUnknown is invoked, with the untagged content, for any tag that is neither
+// a CoMID nor a CoSWID, including CoTS tags unless the visitor also
+// implements CotsVisitor.
+type TagVisitor interface {
+	OnComid(c comid.Comid) error
+	OnCoswid(c swid.SoftwareIdentity) error
+	OnUnknown(tagNumber uint64, payload []byte) error
+}
+
+// CotsVisitor can be implemented by a TagVisitor that wants CoTS tags to be
+// decoded, rather than passed to OnUnknown
+type CotsVisitor interface {
+	OnCots(c cots.ConciseTaStore) error
+}
+
+// WalkTags decodes, in order, each of the tags in the tags array of the
+// unsigned-corim-map, and invokes the visitor handler corresponding to its
+// type.  The walk stops at the first decoding or handler error, which is
+// returned together with the position of the tag.
+func (o UnsignedCorim) WalkTags(visitor TagVisitor) error {
+	for i, t := range o.Tags {
+		if err := walkTag(t, visitor); err != nil {
+			return fmt.Errorf("tag at pos %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func walkTag(t Tag, visitor TagVisitor) error {
+	num, content, err := t.split()
+	if err != nil {
+		return err
+	}
+
+	switch num {
+	case ComidTagNumber:
+		var c comid.Comid
+		if err := c.FromCBOR(content); err != nil {
+			return fmt.Errorf("decoding CoMID: %w", err)
+		}
+		return visitor.OnComid(c)
+	case CoswidTagNumber:
+		var c swid.SoftwareIdentity
+		if err := c.FromCBOR(content); err != nil {
+			return fmt.Errorf("decoding CoSWID: %w", err)
+		}
+		return visitor.OnCoswid(c)
+	case CotsTagNumber:
+		if v, ok := visitor.(CotsVisitor); ok {
+			var c cots.ConciseTaStore
+			if err := c.FromCBOR(content); err != nil {
+				return fmt.Errorf("decoding CoTS: %w", err)
+			}
+			return v.OnCots(c)
+		}
+	}
+
+	return visitor.OnUnknown(num, content)
+}
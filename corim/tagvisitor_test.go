@@ -0,0 +1,89 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/corim/comid"
+	"github.com/veraison/corim/cots"
+	"github.com/veraison/swid"
+)
+
+type recordingVisitor struct {
+	visits []string
+	fail   string
+}
+
+func (o *recordingVisitor) record(v string) error {
+	o.visits = append(o.visits, v)
+	if v == o.fail {
+		return errors.New("handler failed")
+	}
+	return nil
+}
+
+func (o *recordingVisitor) OnComid(c comid.Comid) error {
+	return o.record("comid " + c.TagIdentity.TagID.String())
+}
+
+func (o *recordingVisitor) OnCoswid(c swid.SoftwareIdentity) error {
+	return o.record("coswid " + c.TagID.String())
+}
+
+func (o *recordingVisitor) OnUnknown(tagNumber uint64, payload []byte) error {
+	return o.record(fmt.Sprintf("unknown %d %x", tagNumber, payload))
+}
+
+type recordingCotsVisitor struct {
+	recordingVisitor
+}
+
+func (o *recordingCotsVisitor) OnCots(c cots.ConciseTaStore) error {
+	return o.record("cots")
+}
+
+func walkTagsTestCorim(t *testing.T) *UnsignedCorim {
+	s := cots.ConciseTaStore{}
+	require.NoError(t, s.FromJSON([]byte(cots.ConciseTaStoreTemplateSingleOrg)))
+
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).
+		AddCots(s).
+		AddComid(comidFromJSON(t, comid.PSAKeysJSONTemplate))
+	require.NotNil(t, c)
+
+	return c
+}
+
+func TestUnsignedCorim_WalkTags(t *testing.T) {
+	tv := walkTagsTestCorim(t)
+
+	v := &recordingVisitor{}
+	require.NoError(t, tv.WalkTags(v))
+	require.Len(t, v.visits, 3)
+	assert.Equal(t, "comid 43bbe37f-2e61-4b33-aed3-53cff1428b16", v.visits[0])
+	assert.Contains(t, v.visits[1], "unknown 507 ")
+	assert.Equal(t, "comid 366d0a0a-5988-45ed-8488-2f2a544f6242", v.visits[2])
+
+	cv := &recordingCotsVisitor{}
+	require.NoError(t, tv.WalkTags(cv))
+	assert.Equal(t, "cots", cv.visits[1])
+}
+
+func TestUnsignedCorim_WalkTags_errors(t *testing.T) {
+	tv := walkTagsTestCorim(t)
+
+	v := &recordingVisitor{fail: "comid 43bbe37f-2e61-4b33-aed3-53cff1428b16"}
+	assert.EqualError(t, tv.WalkTags(v), "tag at pos 0: handler failed")
+	assert.Len(t, v.visits, 1)
+
+	tv.Tags = append(tv.Tags, append(TagHeader(CoswidTagNumber), 0xa1, 0x00, 0x00))
+	v = &recordingVisitor{}
+	assert.ErrorContains(t, tv.WalkTags(v), "tag at pos 3: decoding CoSWID: ")
+	assert.Len(t, v.visits, 3)
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ApplyTemplate copies the profile and dependent RIMs of template into target,
+// leaving the corim-id, tags and any other field of target untouched.  A
+// dependent RIM is only copied if target has no dependent RIM with the same
+// href.  Since an unsigned CoRIM carries at most one profile, the template's
+// profile is only copied if target has none; if target already declares a
+// different profile (compared in normalized form, see NormalizeProfile), an
+// error is returned and target is not modified.
+func ApplyTemplate(target *UnsignedCorim, template UnsignedCorim) error {
+	if target == nil {
+		return errors.New("nil target")
+	}
+
+	tmpl := template.Clone()
+
+	if tmpl.Profile != nil && target.Profile != nil {
+		want, err := NormalizeProfile(*tmpl.Profile)
+		if err != nil {
+			return fmt.Errorf("template profile: %w", err)
+		}
+
+		have, err := NormalizeProfile(*target.Profile)
+		if err != nil {
+			return fmt.Errorf("target profile: %w", err)
+		}
+
+		if want != have {
+			return fmt.Errorf("conflicting profiles: target has %q, template has %q", have, want)
+		}
+	} else if tmpl.Profile != nil {
+		if err := ValidProfile(*tmpl.Profile); err != nil {
+			return fmt.Errorf("template profile: %w", err)
+		}
+		target.Profile = tmpl.Profile
+	}
+
+	if tmpl.DependentRims == nil {
+		return nil
+	}
+
+	for _, l := range *tmpl.DependentRims {
+		if target.DependentRims == nil {
+			target.DependentRims = new([]Locator)
+		}
+
+		found := false
+		for _, existing := range *target.DependentRims {
+			if existing.Href == l.Href {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			*target.DependentRims = append(*target.DependentRims, l)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/swid"
+)
+
+func TestApplyTemplate_ok(t *testing.T) {
+	tp := swid.HashEntry{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)}
+
+	template := NewUnsignedCorim().
+		SetProfile("https://example.com/house-style").
+		AddDependentRim("https://example.com/a", &tp).
+		AddDependentRim("https://example.com/b", nil)
+	require.NotNil(t, template)
+
+	target := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, target.AddDependentRim("https://example.com/b", nil))
+	tags := target.Tags
+
+	require.NoError(t, ApplyTemplate(target, *template))
+
+	assert.Equal(t, "test corim id", target.GetID())
+	assert.Equal(t, tags, target.Tags)
+	assert.True(t, target.HasProfile("https://example.com/house-style"))
+	require.NotNil(t, target.DependentRims)
+	require.Len(t, *target.DependentRims, 2)
+	assert.Equal(t, "https://example.com/b", string((*target.DependentRims)[0].Href))
+	assert.Equal(t, "https://example.com/a", string((*target.DependentRims)[1].Href))
+
+	// applying the template again is a no-op
+	require.NoError(t, ApplyTemplate(target, *template))
+	assert.Len(t, *target.DependentRims, 2)
+
+	// the template's thumbprints are not shared with the target
+	(*template.DependentRims)[0].Thumbprint.HashValue[0] = 0xff
+	assert.Equal(t, byte(0), (*target.DependentRims)[1].Thumbprint.HashValue[0])
+}
+
+func TestApplyTemplate_same_profile(t *testing.T) {
+	template := NewUnsignedCorim().SetProfile("https://example.com/house-style")
+	target := NewUnsignedCorim().SetProfile("HTTPS://EXAMPLE.COM/house-style")
+
+	assert.NoError(t, ApplyTemplate(target, *template))
+}
+
+func TestApplyTemplate_NOK(t *testing.T) {
+	template := NewUnsignedCorim().
+		SetProfile("https://example.com/house-style").
+		AddDependentRim("https://example.com/a", nil)
+	target := NewUnsignedCorim().SetProfile("1.2.3.4")
+
+	err := ApplyTemplate(target, *template)
+	assert.EqualError(t, err,
+		`conflicting profiles: target has "1.2.3.4", template has "https://example.com/house-style"`)
+	assert.Nil(t, target.DependentRims)
+
+	assert.EqualError(t, ApplyTemplate(nil, *template), "nil target")
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"github.com/google/uuid"
+	"github.com/veraison/corim/comid"
+)
+
+var (
+	TestCorimID = uuid.MustParse("5d1a7d9e-3f4b-4c6a-8e2f-0b9c1d2e3f40")
+	TestComidID = uuid.MustParse("b3d0f1a2-5c6e-4f7a-9b8c-1d2e3f405162")
+)
+
+// NewTestCorim returns a minimal unsigned CoRIM, with TestCorimID as its
+// corim-id and a single CoMID (with TestComidID as its tag-id) carrying one
+// attestation verification key.  The returned CoRIM passes Valid, and its CBOR
+// encoding is the same every time.
+func NewTestCorim() *UnsignedCorim {
+	c := comid.NewComid().
+		SetTagIdentity(TestComidID, 0).
+		AddAttestVerifKey(
+			comid.KeyTriple{
+				Environment: comid.Environment{
+					Instance: comid.MustNewUUIDInstance(comid.TestUUID),
+				},
+				VerifKeys: *comid.NewCryptoKeys().
+					Add(
+						comid.MustNewPKIXBase64Key(comid.TestECPubKey),
+					),
+			},
+		)
+	if c == nil {
+		panic("failed to construct test CoMID")
+	}
+
+	ret := NewUnsignedCorim().
+		SetID(TestCorimID).
+		AddComid(*c)
+	if ret == nil {
+		panic("failed to construct test CoRIM")
+	}
+
+	return ret
+}
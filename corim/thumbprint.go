@@ -0,0 +1,83 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/veraison/swid"
+)
+
+// Thumbprint returns the thumbprint of the target unsigned CoRIM computed
+// using the supplied hash algorithm identifier (e.g., swid.Sha256), ready to
+// be passed to AddDependentRim.  The digest is computed over the Core
+// Deterministic Encoding of the unsigned-corim-map, regardless of the setting
+// of SetDeterministicEncoding, so that the thumbprint does not depend on the
+// producer.  Tags are hashed as they are, so the CoRIM that is published must
+// be the one encoded by ToCBOR with deterministic encoding enabled.  SHA-256
+// (including its truncated variants), SHA-384 and SHA-512 are supported.
+func (o UnsignedCorim) Thumbprint(alg uint64) (*swid.HashEntry, error) {
+	data, err := o.toCBOR(modes.detEnc)
+	if err != nil {
+		return nil, fmt.Errorf("encoding unsigned CoRIM: %w", err)
+	}
+
+	value, ok := digest(alg, data)
+	if !ok {
+		return nil, fmt.Errorf("unsupported thumbprint algorithm %d", alg)
+	}
+
+	return &swid.HashEntry{HashAlgID: alg, HashValue: value}, nil
+}
+
+// digest computes the digest of data using the supplied hash algorithm
+// identifier.  For the truncated variants of SHA-256, the leftmost bytes of the
+// digest are returned.  It returns false if the algorithm is not supported.
+func digest(alg uint64, data []byte) ([]byte, bool) {
+	switch alg {
+	case swid.Sha256, swid.Sha256_128, swid.Sha256_120, swid.Sha256_96,
+		swid.Sha256_64, swid.Sha256_32:
+		d := sha256.Sum256(data)
+		return d[:sha256TruncatedLen[alg]], true
+	case swid.Sha384:
+		d := sha512.Sum384(data)
+		return d[:], true
+	case swid.Sha512:
+		d := sha512.Sum512(data)
+		return d[:], true
+	default:
+		return nil, false
+	}
+}
+
+// sha256TruncatedLen maps the SHA-256 algorithm identifiers to the length in
+// bytes of their output
+var sha256TruncatedLen = map[uint64]int{
+	swid.Sha256:     32,
+	swid.Sha256_128: 16,
+	swid.Sha256_120: 15,
+	swid.Sha256_96:  12,
+	swid.Sha256_64:  8,
+	swid.Sha256_32:  4,
+}
+
+// VerifyAgainstThumbprint recomputes the thumbprint of the target unsigned
+// CoRIM, as done by Thumbprint, using the hash algorithm of expected, and
+// checks that it matches.  This is the check a consumer performs on a CoRIM
+// it already holds, against the thumbprint of the locator referencing it.  A
+// mismatch is reported using a *ThumbprintMismatchError.
+func (o UnsignedCorim) VerifyAgainstThumbprint(expected swid.HashEntry) error {
+	actual, err := o.Thumbprint(expected.HashAlgID)
+	if err != nil {
+		return err
+	}
+
+	if !thumbprintMatches(&expected, actual.HashAlgID, actual.HashValue) {
+		return &ThumbprintMismatchError{Expected: expected, Actual: actual.HashValue}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/swid"
+)
+
+func TestUnsignedCorim_Thumbprint(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, tv.AddDependentRim("https://example.com/a", nil))
+
+	require.NoError(t, SetDeterministicEncoding(true))
+	data, err := tv.ToCBOR()
+	require.NoError(t, SetDeterministicEncoding(false))
+	require.NoError(t, err)
+
+	d256 := sha256.Sum256(data)
+	d384 := sha512.Sum384(data)
+	d512 := sha512.Sum512(data)
+
+	for _, tc := range []struct {
+		alg      uint64
+		expected []byte
+	}{
+		{swid.Sha256, d256[:]},
+		{swid.Sha256_128, d256[:16]},
+		{swid.Sha256_32, d256[:4]},
+		{swid.Sha384, d384[:]},
+		{swid.Sha512, d512[:]},
+	} {
+		tp, err := tv.Thumbprint(tc.alg)
+		require.NoError(t, err)
+		assert.Equal(t, tc.alg, tp.HashAlgID)
+		assert.Equal(t, tc.expected, tp.HashValue)
+		assert.NoError(t, swid.ValidHashEntry(tp.HashAlgID, tp.HashValue))
+	}
+
+	// the thumbprint does not depend on the encoding setting
+	require.NoError(t, SetDeterministicEncoding(true))
+	tp, err := tv.Thumbprint(swid.Sha256)
+	require.NoError(t, SetDeterministicEncoding(false))
+	require.NoError(t, err)
+	assert.Equal(t, d256[:], tp.HashValue)
+
+	// ... and can be used to reference the CoRIM
+	root := NewTestCorim().AddDependentRim("https://example.com/dep", tp)
+	require.NoError(t, root.Valid())
+	assert.NotNil(t, root.FindDependentRim(swid.Sha256, d256[:]))
+
+	// mixed-sign extension keys are hashed in Core Deterministic Encoding
+	// order (6, -1, -10), whatever the encoding setting
+	ext := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	require.NotNil(t, ext.SetExtension(-1, true))
+	require.NotNil(t, ext.SetExtension(LanguageKey, "en"))
+	require.NotNil(t, ext.SetExtension(6, 1))
+
+	data, err = ext.ToCBOR()
+	require.NoError(t, err)
+
+	canonical, err := canonicalCBOR(data)
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(canonical, data))
+
+	d := sha256.Sum256(canonical)
+	tp, err = ext.Thumbprint(swid.Sha256)
+	require.NoError(t, err)
+	assert.Equal(t, d[:], tp.HashValue)
+
+	require.NoError(t, SetDeterministicEncoding(true))
+	data, err = ext.ToCBOR()
+	require.NoError(t, SetDeterministicEncoding(false))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(canonical, data))
+}
+
+func TestUnsignedCorim_Thumbprint_NOK(t *testing.T) {
+	_, err := NewTestCorim().Thumbprint(swid.Sha3_256)
+	assert.EqualError(t, err, "unsupported thumbprint algorithm 10")
+}
+
+func TestUnsignedCorim_VerifyAgainstThumbprint(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	tp, err := tv.Thumbprint(swid.Sha384)
+	require.NoError(t, err)
+
+	assert.NoError(t, tv.VerifyAgainstThumbprint(*tp))
+
+	bad := swid.HashEntry{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)}
+	err = tv.VerifyAgainstThumbprint(bad)
+	assert.EqualError(t, err,
+		"thumbprint mismatch: expecting "+strings.Repeat("00", 48)+", got "+hex.EncodeToString(tp.HashValue))
+
+	var mismatch *ThumbprintMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, bad, mismatch.Expected)
+	assert.Equal(t, tp.HashValue, mismatch.Actual)
+	assert.Empty(t, mismatch.Href)
+
+	// a different CoRIM
+	require.NotNil(t, tv.SetID("other"))
+	assert.Error(t, tv.VerifyAgainstThumbprint(*tp))
+
+	err = tv.VerifyAgainstThumbprint(swid.HashEntry{HashAlgID: swid.Sha3_256})
+	assert.EqualError(t, err, "unsupported thumbprint algorithm 10")
+}
@@ -4,10 +4,22 @@
 package corim
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	cbor "github.com/fxamacker/cbor/v2"
+	"github.com/google/uuid"
 	"github.com/veraison/corim/cots"
 	"github.com/veraison/corim/encoding"
 	"github.com/veraison/corim/extensions"
@@ -34,6 +46,11 @@ type UnsignedCorim struct {
 	Profile       *eat.Profile `cbor:"3,keyasint,omitempty" json:"profile,omitempty"`
 	RimValidity   *Validity    `cbor:"4,keyasint,omitempty" json:"validity,omitempty"`
 	Entities      *Entities    `cbor:"5,keyasint,omitempty" json:"entities,omitempty"`
+	// RawExtensions carries unsigned-corim-map entries that are neither
+	// standard fields nor part of registered Extensions, keyed by their
+	// integer map key.  They are preserved through a CBOR decode/encode
+	// round-trip, but are not serialized to JSON.
+	RawExtensions map[int64]cbor.RawMessage `cbor:"-" json:"-"`
 
 	Extensions
 }
@@ -87,275 +104,2261 @@ func (o *UnsignedCorim) SetID(v interface{}) *UnsignedCorim {
 	return o
 }
 
+// SetRandomID sets the corim-id to a freshly generated random (version 4) UUID,
+// using crypto/rand as the source of entropy
+func (o *UnsignedCorim) SetRandomID() *UnsignedCorim {
+	if o != nil {
+		id, err := uuid.NewRandomFromReader(rand.Reader)
+		if err != nil {
+			return nil
+		}
+
+		return o.SetID(id)
+	}
+	return o
+}
+
+// SetContentID sets the corim-id to a name-based (version 5) UUID, in the
+// supplied namespace, derived from the tags and the profile of the target
+// CoRIM.  Tags are compared by decoded content irrespective of their order,
+// and the profile in its normalized form (see Equal), so that CoRIMs with the
+// same content get the same corim-id regardless of how they were encoded.  It
+// returns nil if any of the tags cannot be decoded.
+func (o *UnsignedCorim) SetContentID(namespace uuid.UUID) *UnsignedCorim {
+	if o != nil {
+		tags, err := canonicalTags(o.Tags)
+		if err != nil {
+			return nil
+		}
+
+		profile, err := profileString(o.Profile)
+		if err != nil {
+			return nil
+		}
+
+		content := struct {
+			Tags    []string `cbor:"1,keyasint"`
+			Profile string   `cbor:"3,keyasint,omitempty"`
+		}{tags, profile}
+
+		data, err := modes.detEnc.Marshal(content)
+		if err != nil {
+			return nil
+		}
+
+		return o.SetID(uuid.NewSHA1(namespace, data))
+	}
+	return o
+}
+
 // GetID retrieves the corim-id from the unsigned-corim-map as a string
 func (o UnsignedCorim) GetID() string {
 	return o.ID.String()
 }
 
+// SetTagID sets the corim-id in the unsigned-corim-map to the supplied
+// swid.TagID, which is used as-is
+func (o *UnsignedCorim) SetTagID(id swid.TagID) *UnsignedCorim {
+	if o != nil {
+		o.ID = id
+	}
+	return o
+}
+
+// GetTagID retrieves the corim-id from the unsigned-corim-map
+func (o UnsignedCorim) GetTagID() swid.TagID {
+	return o.ID
+}
+
 // AddComid appends the CBOR encoded (and appropriately tagged) CoMID to the
 // tags array of the unsigned-corim-map
 func (o *UnsignedCorim) AddComid(c comid.Comid) *UnsignedCorim {
 	if o != nil {
-		if c.Valid() != nil {
+		if o.AddComidErr(c) != nil {
 			return nil
 		}
+	}
+	return o
+}
 
-		comidCBOR, err := c.ToCBOR()
-		if err != nil {
-			return nil
-		}
+// AddComidErr is like AddComid, but returns an error describing the reason
+// for failing to add the supplied CoMID
+func (o *UnsignedCorim) AddComidErr(c comid.Comid) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
 
-		taggedComid := append(ComidTag, comidCBOR...)
+	if err := c.Valid(); err != nil {
+		return fmt.Errorf("invalid CoMID: %w", err)
+	}
 
-		o.Tags = append(o.Tags, taggedComid)
+	comidCBOR, err := c.ToCBOR()
+	if err != nil {
+		return fmt.Errorf("encoding CoMID: %w", err)
 	}
-	return o
+
+	taggedComid := append(TagHeader(ComidTagNumber), comidCBOR...)
+
+	o.Tags = append(o.Tags, taggedComid)
+
+	return nil
 }
 
 // AddCots appends the CBOR encoded (and appropriately tagged) CoTS to the
 // tags array of the unsigned-corim-map
 func (o *UnsignedCorim) AddCots(c cots.ConciseTaStore) *UnsignedCorim {
 	if o != nil {
-		if c.Valid() != nil {
+		if o.AddCotsErr(c) != nil {
 			return nil
 		}
+	}
+	return o
+}
 
-		cotsCBOR, err := c.ToCBOR()
-		if err != nil {
-			return nil
-		}
+// AddCotsErr is like AddCots, but returns an error describing the reason for
+// failing to add the supplied CoTS
+func (o *UnsignedCorim) AddCotsErr(c cots.ConciseTaStore) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
 
-		taggedCots := append(cots.CotsTag, cotsCBOR...)
+	if err := c.Valid(); err != nil {
+		return fmt.Errorf("invalid CoTS: %w", err)
+	}
 
-		o.Tags = append(o.Tags, taggedCots)
+	cotsCBOR, err := c.ToCBOR()
+	if err != nil {
+		return fmt.Errorf("encoding CoTS: %w", err)
 	}
-	return o
+
+	taggedCots := append(TagHeader(CotsTagNumber), cotsCBOR...)
+
+	o.Tags = append(o.Tags, taggedCots)
+
+	return nil
 }
 
 // AddCoswid appends the CBOR encoded (and appropriately tagged) CoSWID to the
 // tags array of the unsigned-corim-map
 func (o *UnsignedCorim) AddCoswid(c swid.SoftwareIdentity) *UnsignedCorim {
 	if o != nil {
-		// Currently the swid package doesn't offer an interface
-		// for validating the supplied CoSWID, so -- for now --
-		// we take any input for granted and pass it to the encoder.
-		// See also https://github.com/veraison/swid/issues/23.
-
-		coswidCBOR, err := c.ToCBOR()
-		if err != nil {
+		if o.AddCoswidErr(c) != nil {
 			return nil
 		}
+	}
+	return o
+}
+
+// AddCoswidErr is like AddCoswid, but returns an error describing the reason
+// for failing to add the supplied CoSWID
+func (o *UnsignedCorim) AddCoswidErr(c swid.SoftwareIdentity) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
 
-		taggedCoswid := append(CoswidTag, coswidCBOR...)
+	// Currently the swid package doesn't offer an interface
+	// for validating the supplied CoSWID, so -- for now --
+	// we take any input for granted and pass it to the encoder.
+	// See also https://github.com/veraison/swid/issues/23.
 
-		o.Tags = append(o.Tags, taggedCoswid)
+	coswidCBOR, err := c.ToCBOR()
+	if err != nil {
+		return fmt.Errorf("encoding CoSWID: %w", err)
 	}
-	return o
+
+	taggedCoswid := append(TagHeader(CoswidTagNumber), coswidCBOR...)
+
+	o.Tags = append(o.Tags, taggedCoswid)
+
+	return nil
 }
 
-// AddDependentRim creates a corim-locator-map from the supplied arguments and
-// appends it to the dependent RIMs in the unsigned-corim-map
-func (o *UnsignedCorim) AddDependentRim(href string, thumbprint *swid.HashEntry) *UnsignedCorim {
+// AddCoswidRaw appends the supplied CBOR-encoded CoSWID to the tags array of
+// the unsigned-corim-map without re-encoding it, so that its exact bytes are
+// preserved.  The data can be either a tagged CoSWID (505) or an untagged
+// one, in which case the tag is added.  The payload must be a CBOR map, but it
+// is otherwise not decoded.
+func (o *UnsignedCorim) AddCoswidRaw(taggedOrUntagged []byte) *UnsignedCorim {
 	if o != nil {
-		l := Locator{
-			Href:       comid.TaggedURI(href),
-			Thumbprint: thumbprint,
+		if o.AddCoswidRawErr(taggedOrUntagged) != nil {
+			return nil
 		}
+	}
+	return o
+}
 
-		if o.DependentRims == nil {
-			o.DependentRims = new([]Locator)
-		}
+// AddCoswidRawErr is like AddCoswidRaw, but returns an error describing the
+// reason for failing to add the supplied CoSWID
+func (o *UnsignedCorim) AddCoswidRawErr(taggedOrUntagged []byte) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
 
-		*o.DependentRims = append(*o.DependentRims, l)
+	if len(taggedOrUntagged) == 0 {
+		return errors.New("empty CoSWID")
+	}
 
+	payload, tagged := bytes.CutPrefix(taggedOrUntagged, CoswidTag)
+
+	const (
+		majorTypeMask = 0xe0
+		majorTypeMap  = 0xa0
+		majorTypeTag  = 0xc0
+	)
+
+	if !tagged && payload[0]&majorTypeMask == majorTypeTag {
+		return errors.New("CoSWID wrapped in an unexpected CBOR tag")
 	}
-	return o
+
+	if err := dm.Wellformed(payload); err != nil {
+		return fmt.Errorf("malformed CoSWID: %w", err)
+	}
+
+	if payload[0]&majorTypeMask != majorTypeMap {
+		return errors.New("CoSWID is not a CBOR map")
+	}
+
+	if tagged {
+		o.Tags = append(o.Tags, bytes.Clone(taggedOrUntagged))
+	} else {
+		o.Tags = append(o.Tags, append(TagHeader(CoswidTagNumber), payload...))
+	}
+
+	return nil
 }
 
-// SetProfile sets the supplied profile identifier (either a URL or OID) as
-// the profile in the unsigned-corim-map
-func (o *UnsignedCorim) SetProfile(urlOrOID string) *UnsignedCorim {
+// AddRawTag wraps the supplied CBOR-encoded payload in the CBOR tag identified by
+// tagNumber and appends it to the tags array of the unsigned-corim-map.  The
+// tagNumber must be that of a CoSWID (505), CoMID (506) or CoTS (507).  The
+// payload must be well-formed CBOR, but it is otherwise appended as is, i.e.,
+// it is not decoded or re-encoded.
+func (o *UnsignedCorim) AddRawTag(tagNumber uint64, payload []byte) *UnsignedCorim {
 	if o != nil {
-		p, err := eat.NewProfile(urlOrOID)
-		if err != nil {
+		if o.AddRawTagErr(tagNumber, payload) != nil {
 			return nil
 		}
+	}
+	return o
+}
 
-		o.Profile = p
+// AddRawTagErr is like AddRawTag, but returns an error describing the reason
+// for failing to add the supplied payload
+func (o *UnsignedCorim) AddRawTagErr(tagNumber uint64, payload []byte) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
 
+	switch tagNumber {
+	case CoswidTagNumber, ComidTagNumber, CotsTagNumber:
+	default:
+		return fmt.Errorf("unsupported tag number %d", tagNumber)
 	}
-	return o
+
+	if err := dm.Wellformed(payload); err != nil {
+		return fmt.Errorf("malformed payload: %w", err)
+	}
+
+	tagged, err := em.Marshal(cbor.RawTag{
+		Number:  tagNumber,
+		Content: payload,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding tag: %w", err)
+	}
+
+	o.Tags = append(o.Tags, tagged)
+
+	return nil
 }
 
-// SetRimValidity can be used to set the validity period of the CoRIM.
-// The caller must supply a "not-after" timestamp and optionally a "not-before"
-// timestamp.
-func (o *UnsignedCorim) SetRimValidity(notAfter time.Time, notBefore *time.Time) *UnsignedCorim {
+// MergeTags appends the tags of the supplied unsigned CoRIM to the tags array
+// of the target unsigned-corim-map as they are, i.e., without decoding and
+// re-encoding them
+func (o *UnsignedCorim) MergeTags(from UnsignedCorim) *UnsignedCorim {
 	if o != nil {
-		v := NewValidity().Set(notAfter, notBefore)
-		if v == nil {
+		if o.MergeTagsErr(from) != nil {
 			return nil
 		}
-
-		o.RimValidity = v
 	}
 	return o
 }
 
-// AddEntity adds an organizational entity, together with the roles this entity
-// claims with regards to the CoRIM, to the target UnsignerCorim.  name is the entity
-// name, regID is a URI that uniquely identifies the entity.  For the moment, roles
-// can only be RoleManifestCreator.
-func (o *UnsignedCorim) AddEntity(name string, regID *string, roles ...Role) *UnsignedCorim {
-	if o != nil {
-		e := NewEntity().
-			SetName(name).
-			SetRoles(roles...)
+// MergeTagsErr is like MergeTags, but returns an error describing the reason
+// for failing to merge the supplied tags.  Each tag is checked with Tag.Valid
+// and, if any of them is invalid, none is appended.
+func (o *UnsignedCorim) MergeTagsErr(from UnsignedCorim) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
 
-		if regID != nil {
-			e = e.SetRegID(*regID)
+	for i, t := range from.Tags {
+		if err := t.Valid(); err != nil {
+			return &ValidationError{Kind: ErrInvalidTag, Pos: i, Err: err}
 		}
+	}
 
-		if e == nil {
-			return nil
-		}
+	for _, t := range from.Tags {
+		o.Tags = append(o.Tags, bytes.Clone(t))
+	}
 
-		if o.Entities == nil {
-			o.Entities = new(Entities)
-		}
+	return nil
+}
 
-		if o.Entities.Add(e) == nil {
+// RemoveTag removes the tag at the supplied index from the tags array of the
+// unsigned-corim-map.  It returns nil if index is out of range.
+func (o *UnsignedCorim) RemoveTag(index int) *UnsignedCorim {
+	if o != nil {
+		if index < 0 || index >= len(o.Tags) {
 			return nil
 		}
+
+		tags := make([]Tag, 0, len(o.Tags)-1)
+		tags = append(tags, o.Tags[:index]...)
+		tags = append(tags, o.Tags[index+1:]...)
+
+		o.Tags = tags
 	}
 	return o
 }
 
-// Valid checks the validity (according to the spec) of the target unsigned CoRIM
-func (o UnsignedCorim) Valid() error {
-	if o.ID == (swid.TagID{}) {
-		return fmt.Errorf("empty id")
-	}
+// FilterTags rebuilds the tags array of the unsigned-corim-map keeping only the
+// tags for which keep, invoked with the CBOR tag number and the (untagged)
+// content of each tag, returns true.  It returns nil, leaving the tags
+// untouched, if any of the tags cannot be decoded.  Note that removing all the
+// tags makes the unsigned CoRIM invalid (see ErrNoTags).
+func (o *UnsignedCorim) FilterTags(keep func(tagNumber uint64, payload []byte) bool) *UnsignedCorim {
+	if o != nil {
+		kept := make([]Tag, 0, len(o.Tags))
 
-	if len(o.Tags) == 0 {
-		return errors.New("tags validation failed: no tags")
-	}
+		for _, t := range o.Tags {
+			num, content, err := t.split()
+			if err != nil {
+				return nil
+			}
 
-	for i, t := range o.Tags {
-		if err := t.Valid(); err != nil {
-			return fmt.Errorf("tag validation failed at pos %d: %w", i, err)
+			if keep(num, content) {
+				kept = append(kept, t)
+			}
 		}
+
+		o.Tags = kept
 	}
+	return o
+}
 
-	if o.DependentRims != nil {
-		for i, r := range *o.DependentRims {
-			if err := r.Valid(); err != nil {
-				return fmt.Errorf("dependent RIM validation failed at pos %d: %w", i, err)
-			}
+// KeepOnlyComids removes all the tags that are not CoMIDs from the tags array
+// of the unsigned-corim-map (see FilterTags)
+func (o *UnsignedCorim) KeepOnlyComids() *UnsignedCorim {
+	return o.FilterTags(func(tagNumber uint64, _ []byte) bool {
+		return tagNumber == ComidTagNumber
+	})
+}
+
+// SortTags reorders the tags array of the unsigned-corim-map deterministically:
+// by CBOR tag number first, then by the tag-id of CoMIDs and CoSWIDs, and then
+// by the raw tag bytes.  This makes the CBOR encoding, and therefore the
+// signature, independent of the order in which tags were added.  Note that
+// sorting changes the encoded bytes, so all the producers that need to agree
+// on a signature must sort consistently.  It returns nil, leaving the tags
+// untouched, if any of the tags cannot be decoded.
+func (o *UnsignedCorim) SortTags() *UnsignedCorim {
+	if o != nil {
+		type sortableTag struct {
+			num uint64
+			id  string
+			raw Tag
 		}
-	}
 
-	if o.Profile != nil {
-		if err := ValidProfile(*o.Profile); err != nil {
-			return fmt.Errorf("profile validation failed: %w", err)
+		tags := make([]sortableTag, 0, len(o.Tags))
+
+		for _, t := range o.Tags {
+			num, content, err := t.split()
+			if err != nil {
+				return nil
+			}
+
+			st := sortableTag{num: num, raw: t}
+
+			switch num {
+			case ComidTagNumber:
+				var c comid.Comid
+				if err := c.FromCBOR(content); err != nil {
+					return nil
+				}
+				st.id = c.TagIdentity.TagID.String()
+			case CoswidTagNumber:
+				var c swid.SoftwareIdentity
+				if err := c.FromCBOR(content); err != nil {
+					return nil
+				}
+				st.id = c.TagID.String()
+			}
+
+			tags = append(tags, st)
 		}
-	}
 
-	if o.RimValidity != nil {
-		if err := o.RimValidity.Valid(); err != nil {
-			return fmt.Errorf("RIM validity validation failed: %w", err)
+		sort.SliceStable(tags, func(i, j int) bool {
+			if tags[i].num != tags[j].num {
+				return tags[i].num < tags[j].num
+			}
+			if tags[i].id != tags[j].id {
+				return tags[i].id < tags[j].id
+			}
+			return bytes.Compare(tags[i].raw, tags[j].raw) < 0
+		})
+
+		for i, st := range tags {
+			o.Tags[i] = st.raw
 		}
 	}
+	return o
+}
 
-	if o.Entities != nil {
-		for i, e := range o.Entities.Values {
-			if err := e.Valid(); err != nil {
-				return fmt.Errorf("entity validation failed at pos %d: %w", i, err)
+// RemoveComidByID removes the CoMID whose tag-id matches the supplied id from
+// the tags array of the unsigned-corim-map.  It returns nil if no matching CoMID
+// is found or if a CoMID tag cannot be decoded.
+func (o *UnsignedCorim) RemoveComidByID(id swid.TagID) *UnsignedCorim {
+	if o != nil {
+		for i, t := range o.Tags {
+			if !bytes.HasPrefix(t, ComidTag) {
+				continue
+			}
+
+			var c comid.Comid
+			if err := c.FromCBOR(t[len(ComidTag):]); err != nil {
+				return nil
+			}
+
+			if c.TagIdentity.TagID == id {
+				return o.RemoveTag(i)
 			}
 		}
-	}
 
-	return o.Extensions.validCorim(&o)
+		return nil
+	}
+	return o
 }
 
-// ToCBOR serializes the target unsigned CoRIM to CBOR
-func (o UnsignedCorim) ToCBOR() ([]byte, error) {
-	// If extensions have been registered, the collection will exist, but
-	// might be empty. If that is the case, set it to nil to avoid
-	// marshaling an empty list (and let the marshaller omit the claim
-	// instead). Note that since the receiver was passed by value, we do not
-	// need to worry about saving the field's value before setting it to
-	// nil.
-	if o.Entities != nil && o.Entities.IsEmpty() {
-		o.Entities = nil
+// ReplaceComid replaces, in place, the CoMID whose tag-id matches that of the
+// supplied CoMID with the supplied CoMID, leaving the position of all tags
+// unchanged.  It returns false if no CoMID with a matching tag-id is found.
+// An error is returned if the supplied CoMID is invalid or if an existing
+// CoMID tag cannot be decoded.
+func (o *UnsignedCorim) ReplaceComid(c comid.Comid) (bool, error) {
+	if o == nil {
+		return false, errors.New("nil UnsignedCorim")
 	}
 
-	return encoding.SerializeStructToCBOR(em, o)
-}
+	if err := c.Valid(); err != nil {
+		return false, fmt.Errorf("invalid CoMID: %w", err)
+	}
 
-// FromCBOR deserializes a CBOR-encoded unsigned CoRIM into the target UnsignedCorim
-func (o *UnsignedCorim) FromCBOR(data []byte) error {
-	return encoding.PopulateStructFromCBOR(dm, data, o)
-}
+	comidCBOR, err := c.ToCBOR()
+	if err != nil {
+		return false, fmt.Errorf("encoding CoMID: %w", err)
+	}
 
-// ToJSON serializes the target unsigned CoRIM to JSON
-func (o UnsignedCorim) ToJSON() ([]byte, error) {
-	// If extensions have been registered, the collection will exist, but
-	// might be empty. If that is the case, set it to nil to avoid
-	// marshaling an empty list (and let the marshaller omit the claim
-	// instead). Note that since the receiver was passed by value, we do not
-	// need to worry about saving the field's value before setting it to
-	// nil.
-	if o.Entities != nil && o.Entities.IsEmpty() {
-		o.Entities = nil
+	for i, t := range o.Tags {
+		if !bytes.HasPrefix(t, ComidTag) {
+			continue
+		}
+
+		var existing comid.Comid
+		if err := existing.FromCBOR(t[len(ComidTag):]); err != nil {
+			return false, fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+		}
+
+		if existing.TagIdentity.TagID == c.TagIdentity.TagID {
+			o.Tags[i] = append(TagHeader(ComidTagNumber), comidCBOR...)
+			return true, nil
+		}
 	}
 
-	return encoding.SerializeStructToJSON(o)
+	return false, nil
 }
 
-// FromJSON deserializes a JSON-encoded unsigned CoRIM into the target UnsignedCorim
-func (o *UnsignedCorim) FromJSON(data []byte) error {
-	return encoding.PopulateStructFromJSON(data, o)
-}
+// GetComids decodes and returns the CoMIDs found in the tags array of the
+// unsigned-corim-map.  Tags that are not CoMIDs are skipped.
+func (o UnsignedCorim) GetComids() ([]comid.Comid, error) {
+	var comids []comid.Comid
 
-// Tag is either a CBOR-encoded CoMID, CoSWID or CoTS
-type Tag []byte
+	for i, t := range o.Tags {
+		num, content, err := t.split()
+		if err != nil {
+			return nil, fmt.Errorf("tag at pos %d: %w", i, err)
+		}
 
-func (o Tag) Valid() error {
-	// there is no much we can check here, except making sure that the tag is
-	// not zero-length
-	if len(o) == 0 {
-		return errors.New("empty tag")
+		if num != ComidTagNumber {
+			continue
+		}
+
+		var c comid.Comid
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+		}
+
+		comids = append(comids, c)
 	}
-	return nil
-}
 
-// Locator is the internal representation of the corim-locator-map with CBOR and
-// JSON serialization.
-type Locator struct {
-	Href       comid.TaggedURI `cbor:"0,keyasint" json:"href"`
-	Thumbprint *swid.HashEntry `cbor:"1,keyasint,omitempty" json:"thumbprint,omitempty"`
+	return comids, nil
 }
 
-func (o Locator) Valid() error {
-	if o.Href.Empty() {
-		return errors.New("empty href")
-	}
+// NumComids returns the number of CoMIDs found in the tags array of the
+// unsigned-corim-map.  Tags that are not CoMIDs, or that cannot be split, are
+// not counted.
+func (o UnsignedCorim) NumComids() int {
+	n := 0
 
-	if tp := o.Thumbprint; tp != nil {
-		if err := swid.ValidHashEntry(tp.HashAlgID, tp.HashValue); err != nil {
-			return fmt.Errorf("invalid locator thumbprint: %w", err)
+	for _, t := range o.Tags {
+		if num, _, err := t.split(); err == nil && num == ComidTagNumber {
+			n++
 		}
 	}
 
-	return nil
+	return n
 }
 
-// ValidProfile checks that the supplied profile is in one of the supported
-// formats (i.e., URI or OID)
+// ComidAt decodes and returns the index-th CoMID found in the tags array of the
+// unsigned-corim-map.  Only CoMIDs are counted, so that index ranges over
+// [0, NumComids()).  Unlike GetComids, only the selected tag is decoded.
+func (o UnsignedCorim) ComidAt(index int) (*comid.Comid, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("CoMID index %d out of range", index)
+	}
+
+	n := 0
+
+	for i, t := range o.Tags {
+		num, content, err := t.split()
+		if err != nil || num != ComidTagNumber {
+			continue
+		}
+
+		if n != index {
+			n++
+			continue
+		}
+
+		var c comid.Comid
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoMID at pos %d: %w", i, err)
+		}
+
+		return &c, nil
+	}
+
+	return nil, fmt.Errorf("CoMID index %d out of range", index)
+}
+
+// GetCoswids decodes and returns the CoSWIDs found in the tags array of the
+// unsigned-corim-map.  Tags that are not CoSWIDs are skipped.
+func (o UnsignedCorim) GetCoswids() ([]swid.SoftwareIdentity, error) {
+	var coswids []swid.SoftwareIdentity
+
+	for i, t := range o.Tags {
+		num, content, err := t.split()
+		if err != nil {
+			return nil, fmt.Errorf("tag at pos %d: %w", i, err)
+		}
+
+		if num != CoswidTagNumber {
+			continue
+		}
+
+		var c swid.SoftwareIdentity
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoSWID at pos %d: %w", i, err)
+		}
+
+		coswids = append(coswids, c)
+	}
+
+	return coswids, nil
+}
+
+// GetCots decodes and returns the CoTS found in the tags array of the
+// unsigned-corim-map.  Tags that are not CoTS are skipped.
+func (o UnsignedCorim) GetCots() ([]cots.ConciseTaStore, error) {
+	var stores []cots.ConciseTaStore
+
+	for i, t := range o.Tags {
+		num, content, err := t.split()
+		if err != nil {
+			return nil, fmt.Errorf("tag at pos %d: %w", i, err)
+		}
+
+		if num != CotsTagNumber {
+			continue
+		}
+
+		var c cots.ConciseTaStore
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoTS at pos %d: %w", i, err)
+		}
+
+		stores = append(stores, c)
+	}
+
+	return stores, nil
+}
+
+// ReferenceValue is a reference-value triple, together with the tag-id of the
+// CoMID it is found in
+type ReferenceValue struct {
+	ComidTagID swid.TagID
+	Triple     comid.ValueTriple
+}
+
+// CollectReferenceValues returns the reference-value triples of all the CoMIDs
+// in the tags array, in the order they appear.  Tags other than CoMIDs are
+// skipped.
+func (o UnsignedCorim) CollectReferenceValues() ([]ReferenceValue, error) {
+	comids, err := o.GetComids()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []ReferenceValue
+
+	for _, c := range comids {
+		if c.Triples.ReferenceValues == nil {
+			continue
+		}
+
+		for _, rv := range c.Triples.ReferenceValues.Values {
+			ret = append(ret, ReferenceValue{
+				ComidTagID: c.TagIdentity.TagID,
+				Triple:     rv,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// AddDependentRim creates a corim-locator-map from the supplied arguments and
+// appends it to the dependent RIMs in the unsigned-corim-map.  Any number of
+// thumbprints (computed using different hash algorithms) can be supplied; nil
+// thumbprints are ignored.
+func (o *UnsignedCorim) AddDependentRim(href string, thumbprints ...*swid.HashEntry) *UnsignedCorim {
+	if o != nil {
+		l := Locator{
+			Href: comid.TaggedURI(href),
+		}
+
+		for _, tp := range thumbprints {
+			if tp == nil {
+				continue
+			}
+
+			if l.Thumbprint == nil {
+				l.Thumbprint = tp
+			} else {
+				l.AltThumbprints = append(l.AltThumbprints, *tp)
+			}
+		}
+
+		if o.DependentRims == nil {
+			o.DependentRims = new([]Locator)
+		}
+
+		*o.DependentRims = append(*o.DependentRims, l)
+
+	}
+	return o
+}
+
+// AddLocator appends a copy of the supplied corim-locator-map, including any
+// alternative thumbprints and hints, to the dependent RIMs in the
+// unsigned-corim-map.  It returns nil if the locator is not valid.
+func (o *UnsignedCorim) AddLocator(l Locator) *UnsignedCorim {
+	if o != nil {
+		if err := l.Valid(); err != nil {
+			return nil
+		}
+
+		if o.DependentRims == nil {
+			o.DependentRims = new([]Locator)
+		}
+
+		*o.DependentRims = append(*o.DependentRims, l.clone())
+	}
+	return o
+}
+
+// RewriteLocators replaces the href of each dependent RIM with the value
+// returned by fn when invoked with the current href, e.g. to redirect
+// dependent RIMs to a mirror.  Thumbprints are left untouched.  It is up to fn
+// to return absolute URIs, or the unsigned CoRIM will fail validation.
+func (o *UnsignedCorim) RewriteLocators(fn func(href string) string) *UnsignedCorim {
+	if o != nil && o.DependentRims != nil {
+		for i := range *o.DependentRims {
+			l := &(*o.DependentRims)[i]
+			l.Href = comid.TaggedURI(fn(string(l.Href)))
+		}
+	}
+	return o
+}
+
+// FindDependentRim returns the first dependent RIM whose thumbprint matches
+// the supplied hash algorithm identifier and value, or nil if there is none.
+func (o UnsignedCorim) FindDependentRim(alg uint64, value []byte) *Locator {
+	if o.DependentRims == nil {
+		return nil
+	}
+
+	for i, l := range *o.DependentRims {
+		if l.ThumbprintMatches(alg, value) {
+			return &(*o.DependentRims)[i]
+		}
+	}
+
+	return nil
+}
+
+// SetProfile sets the supplied profile identifier (either a URL or OID) as
+// the profile in the unsigned-corim-map
+func (o *UnsignedCorim) SetProfile(urlOrOID string) *UnsignedCorim {
+	if o != nil {
+		if o.SetProfileErr(urlOrOID) != nil {
+			return nil
+		}
+	}
+	return o
+}
+
+// SetProfileErr is like SetProfile, but returns an error describing the
+// reason for failing to set the supplied profile
+func (o *UnsignedCorim) SetProfileErr(urlOrOID string) error {
+	if o == nil {
+		return errors.New("nil UnsignedCorim")
+	}
+
+	p, err := eat.NewProfile(urlOrOID)
+	if err != nil {
+		return fmt.Errorf("invalid profile %q: %w", urlOrOID, err)
+	}
+
+	o.Profile = p
+
+	return nil
+}
+
+// GetProfiles returns the normalized string form (see NormalizeProfile) of the
+// profiles declared by the target unsigned CoRIM, in declaration order.  Since
+// an unsigned-corim-map carries at most one profile, the returned slice has at
+// most one element.  It is empty if no valid profile is declared.
+func (o UnsignedCorim) GetProfiles() []string {
+	ret := []string{}
+
+	if o.Profile == nil {
+		return ret
+	}
+
+	if s, err := NormalizeProfile(*o.Profile); err == nil {
+		ret = append(ret, s)
+	}
+
+	return ret
+}
+
+// HasProfile reports whether the target unsigned CoRIM declares the supplied
+// profile (either a URL or OID).  Profiles are compared in their normalized
+// form (see NormalizeProfile), additionally ignoring a trailing slash in the
+// path of URIs.  It returns false if no profile is declared or if the supplied
+// profile is invalid.
+func (o UnsignedCorim) HasProfile(urlOrOID string) bool {
+	if o.Profile == nil {
+		return false
+	}
+
+	p, err := eat.NewProfile(urlOrOID)
+	if err != nil {
+		return false
+	}
+
+	want, err := NormalizeProfile(*p)
+	if err != nil {
+		return false
+	}
+
+	have, err := NormalizeProfile(*o.Profile)
+	if err != nil {
+		return false
+	}
+
+	if p.IsURI() {
+		want = strings.TrimSuffix(want, "/")
+		have = strings.TrimSuffix(have, "/")
+	}
+
+	return want == have
+}
+
+// SetRimValidity can be used to set the validity period of the CoRIM.
+// The caller must supply a "not-after" timestamp and optionally a "not-before"
+// timestamp.
+func (o *UnsignedCorim) SetRimValidity(notAfter time.Time, notBefore *time.Time) *UnsignedCorim {
+	if o != nil {
+		v := NewValidity().Set(notAfter, notBefore)
+		if v == nil {
+			return nil
+		}
+
+		o.RimValidity = v
+	}
+	return o
+}
+
+// AddEntity adds an organizational entity, together with the roles this entity
+// claims with regards to the CoRIM, to the target UnsignerCorim.  name is the entity
+// name, regID is a URI that uniquely identifies the entity.  For the moment, roles
+// can only be RoleManifestCreator.
+func (o *UnsignedCorim) AddEntity(name string, regID *string, roles ...Role) *UnsignedCorim {
+	if o != nil {
+		e := NewEntity().
+			SetName(name).
+			SetRoles(roles...)
+
+		if regID != nil {
+			e = e.SetRegID(*regID)
+		}
+
+		if e == nil {
+			return nil
+		}
+
+		if o.Entities == nil {
+			o.Entities = new(Entities)
+		}
+
+		if o.Entities.Add(e) == nil {
+			return nil
+		}
+	}
+	return o
+}
+
+// Clone returns a deep copy of the target UnsignedCorim.  Tags, dependent RIMs,
+// profile, validity, entities and raw extensions are copied so that changes
+// made to the clone are not reflected in the original.  Registered extensions
+// are shared between the two.
+func (o UnsignedCorim) Clone() *UnsignedCorim {
+	c := o
+
+	if o.Tags != nil {
+		c.Tags = make([]Tag, len(o.Tags))
+		for i, t := range o.Tags {
+			c.Tags[i] = bytes.Clone(t)
+		}
+	}
+
+	if o.DependentRims != nil {
+		rims := make([]Locator, len(*o.DependentRims))
+		for i, l := range *o.DependentRims {
+			rims[i] = l.clone()
+		}
+		c.DependentRims = &rims
+	}
+
+	if o.Profile != nil {
+		if s, err := o.Profile.Get(); err == nil {
+			c.Profile, _ = eat.NewProfile(s)
+		} else {
+			p := *o.Profile
+			c.Profile = &p
+		}
+	}
+
+	if o.RimValidity != nil {
+		v := *o.RimValidity
+		if v.NotBefore != nil {
+			nb := *v.NotBefore
+			v.NotBefore = &nb
+		}
+		c.RimValidity = &v
+	}
+
+	if o.Entities != nil {
+		es := *o.Entities
+		es.Values = make([]Entity, len(o.Entities.Values))
+		for i, e := range o.Entities.Values {
+			es.Values[i] = e
+			if e.Name != nil {
+				n := *e.Name
+				es.Values[i].Name = &n
+			}
+			if e.RegID != nil {
+				r := *e.RegID
+				es.Values[i].RegID = &r
+			}
+			es.Values[i].Roles = slices.Clone(e.Roles)
+		}
+		c.Entities = &es
+	}
+
+	if o.RawExtensions != nil {
+		c.RawExtensions = make(map[int64]cbor.RawMessage, len(o.RawExtensions))
+		for k, v := range o.RawExtensions {
+			c.RawExtensions[k] = bytes.Clone(v)
+		}
+	}
+
+	return &c
+}
+
+// SetExtension CBOR-encodes the supplied value and sets it as the raw extension
+// entry with the supplied key in the unsigned-corim-map.  Keys used by the
+// standard unsigned-corim-map fields (0 to 5) are rejected.
+func (o *UnsignedCorim) SetExtension(key int64, value interface{}) *UnsignedCorim {
+	if o != nil {
+		if key >= 0 && key <= 5 {
+			return nil
+		}
+
+		data, err := em.Marshal(value)
+		if err != nil {
+			return nil
+		}
+
+		if o.RawExtensions == nil {
+			o.RawExtensions = make(map[int64]cbor.RawMessage)
+		}
+
+		o.RawExtensions[key] = data
+	}
+	return o
+}
+
+// GetExtension decodes the raw extension entry with the supplied key into out
+func (o UnsignedCorim) GetExtension(key int64, out interface{}) error {
+	data, ok := o.RawExtensions[key]
+	if !ok {
+		return fmt.Errorf("%w: %d", extensions.ErrExtensionNotFound, key)
+	}
+
+	return dm.Unmarshal(data, out)
+}
+
+var (
+	// ErrEmptyID is returned by Valid when the corim-id is not set
+	ErrEmptyID = errors.New("empty id")
+	// ErrNoTags is returned by Valid when the tags array is empty
+	ErrNoTags = errors.New("no tags")
+	// ErrInvalidTag is reported (via a *ValidationError) by Valid for each
+	// invalid entry of the tags array
+	ErrInvalidTag = errors.New("tag validation failed")
+	// ErrInvalidDependentRim is reported (via a *ValidationError) by Valid for
+	// each invalid entry of the dependent-rims array
+	ErrInvalidDependentRim = errors.New("dependent RIM validation failed")
+	// ErrInvalidProfile is returned by Valid when the profile is malformed or
+	// fails the checks of its registered validator
+	ErrInvalidProfile = errors.New("profile validation failed")
+)
+
+// ValidationError is returned by Valid when one of the elements of an array
+// (e.g., a tag) is invalid.  Kind is one of the Err* sentinels above, Pos is the
+// index of the offending element, and Err is the cause.  Both Kind and Err can
+// be matched with errors.Is and errors.As.
+//
+// The ValidationErrors returned by Validate describe any kind of problem:
+// Path locates the offending element (e.g., "tags[3]"), Code identifies the
+// problem, and Err is the error Valid would report for it.  Kind is only set
+// for the problems that have a sentinel, and Pos for those with a position.
+type ValidationError struct {
+	Kind error
+	Pos  int
+	Err  error
+	Path string
+	Code ValidationCode
+}
+
+func (o *ValidationError) Error() string {
+	if o.Path != "" {
+		return fmt.Sprintf("%s: %s", o.Path, o.Err)
+	}
+
+	return fmt.Sprintf("%s at pos %d: %s", o.Kind, o.Pos, o.Err)
+}
+
+func (o *ValidationError) Unwrap() []error {
+	if o.Kind == nil {
+		return []error{o.Err}
+	}
+
+	return []error{o.Kind, o.Err}
+}
+
+// ValidationCode is a machine-readable identifier of the problems reported by
+// Validate
+type ValidationCode string
+
+const (
+	CodeEmptyID             ValidationCode = "empty-id"
+	CodeNoTags              ValidationCode = "no-tags"
+	CodeInvalidTag          ValidationCode = "invalid-tag"
+	CodeDuplicateTagID      ValidationCode = "duplicate-tag-id"
+	CodeInvalidDependentRim ValidationCode = "invalid-dependent-rim"
+	CodeInvalidProfile      ValidationCode = "invalid-profile"
+	CodeInvalidRimValidity  ValidationCode = "invalid-rim-validity"
+	CodeInvalidEntity       ValidationCode = "invalid-entity"
+	CodeInvalidLanguage     ValidationCode = "invalid-language"
+	CodeInvalidExtensions   ValidationCode = "invalid-extensions"
+)
+
+// validationIssue annotates an error found by Valid with the path and code
+// reported by Validate.  It is transparent to Error, errors.Is and errors.As.
+type validationIssue struct {
+	path string
+	code ValidationCode
+	err  error
+}
+
+func issue(path string, code ValidationCode, err error) error {
+	return &validationIssue{path: path, code: code, err: err}
+}
+
+func (o *validationIssue) Error() string {
+	return o.err.Error()
+}
+
+func (o *validationIssue) Unwrap() error {
+	return o.err
+}
+
+// Validate performs the same checks as Valid, but rather than stopping at the
+// first problem, it returns all of them, in the same order.  An empty slice is
+// returned if the target unsigned CoRIM is valid.
+func (o UnsignedCorim) Validate() []ValidationError {
+	return o.ValidateWithOptions(ValidationOptions{})
+}
+
+// ValidateWithOptions is like Validate, but allows selecting additional checks
+// via the supplied options (see ValidWithOptions)
+func (o UnsignedCorim) ValidateWithOptions(opts ValidationOptions) []ValidationError {
+	errs := o.validationErrors(opts)
+
+	ret := make([]ValidationError, 0, len(errs))
+
+	for _, err := range errs {
+		ve := ValidationError{Err: err}
+
+		var vi *validationIssue
+		if errors.As(err, &vi) {
+			ve.Path, ve.Code = vi.path, vi.code
+		}
+
+		var positional *ValidationError
+		if errors.As(err, &positional) {
+			ve.Kind, ve.Pos = positional.Kind, positional.Pos
+		} else {
+			for _, kind := range []error{ErrEmptyID, ErrNoTags, ErrInvalidProfile} {
+				if errors.Is(err, kind) {
+					ve.Kind = kind
+					break
+				}
+			}
+		}
+
+		ret = append(ret, ve)
+	}
+
+	return ret
+}
+
+// ValidationOptions controls the checks performed by
+// UnsignedCorim.ValidWithOptions
+type ValidationOptions struct {
+	// StrictTags causes each tag to be decoded according to its CBOR tag
+	// number, and the decoded CoMID, CoSWID or CoTS to be checked (see
+	// Tag.ValidStrict).  When not set, tags are treated as opaque and are
+	// only checked for being non-empty.
+	StrictTags bool
+
+	// RejectUnknownProfiles causes a declared profile for which neither a
+	// validator (see RegisterProfileValidator) nor extensions (see
+	// RegisterProfile) have been registered to be reported as an error.
+	// When not set, such profiles are only checked for being well-formed.
+	RejectUnknownProfiles bool
+
+	// AllowedThumbprintAlgorithms, when not nil, lists the hash algorithm
+	// identifiers that dependent RIM thumbprints may use (see, e.g.,
+	// StrongThumbprintAlgorithms).  A locator with a thumbprint computed
+	// using any other algorithm is reported as an error.  When nil, any
+	// algorithm known to swid.ValidHashEntry is accepted.
+	AllowedThumbprintAlgorithms []uint64
+
+	// UniqueTagIDs causes CoMIDs, and CoSWIDs, sharing the same tag-id to
+	// be reported as errors (see ValidateTagIDUniqueness).  Tags that cannot
+	// be decoded are not reported by this check, but are by StrictTags.
+	UniqueTagIDs bool
+}
+
+// StrongThumbprintAlgorithms lists the hash algorithms with an untruncated
+// output of at least 256 bits, for use as
+// ValidationOptions.AllowedThumbprintAlgorithms
+var StrongThumbprintAlgorithms = []uint64{
+	swid.Sha256, swid.Sha384, swid.Sha512,
+	swid.Sha3_256, swid.Sha3_384, swid.Sha3_512,
+}
+
+// Valid checks the validity (according to the spec) of the target unsigned
+// CoRIM, including any checks registered for its profile (see
+// RegisterProfileValidator)
+func (o UnsignedCorim) Valid() error {
+	return o.ValidWithOptions(ValidationOptions{})
+}
+
+// ValidWithOptions is like Valid, but allows selecting additional checks via
+// the supplied options
+func (o UnsignedCorim) ValidWithOptions(opts ValidationOptions) error {
+	if errs := o.validationErrors(opts); len(errs) != 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// validationErrors returns all the problems found in the target unsigned
+// CoRIM, in the order in which Valid reports them
+func (o UnsignedCorim) validationErrors(opts ValidationOptions) []error {
+	var errs []error
+
+	for _, p := range validationPhases {
+		errs = p.check(o, opts, errs)
+	}
+
+	return errs
+}
+
+// validationPhases lists the checks performed by Valid, grouped in the phases
+// reported to ValidationHooks.  Each check appends the problems it finds to
+// the supplied errors.
+var validationPhases = []struct {
+	phase ValidationPhase
+	check func(o UnsignedCorim, opts ValidationOptions, errs []error) []error
+}{
+	{ValidationPhaseCorim, UnsignedCorim.validCorimFields},
+	{ValidationPhaseTags, UnsignedCorim.validTags},
+	{ValidationPhaseDependentRims, UnsignedCorim.validDependentRims},
+	{ValidationPhaseProfile, UnsignedCorim.validProfilePhase},
+}
+
+func (o UnsignedCorim) validCorimFields(_ ValidationOptions, errs []error) []error {
+	if o.ID == (swid.TagID{}) {
+		errs = append(errs, issue("corim-id", CodeEmptyID, ErrEmptyID))
+	}
+
+	if len(o.Tags) == 0 {
+		errs = append(errs, issue("tags", CodeNoTags, fmt.Errorf("tags validation failed: %w", ErrNoTags)))
+	}
+
+	if o.RimValidity != nil {
+		if err := o.RimValidity.Valid(); err != nil {
+			errs = append(errs, issue("rim-validity", CodeInvalidRimValidity,
+				fmt.Errorf("RIM validity validation failed: %w", err)))
+		}
+	}
+
+	if o.Entities != nil {
+		for i, e := range o.Entities.Values {
+			if err := e.Valid(); err != nil {
+				errs = append(errs, issue(fmt.Sprintf("entities[%d]", i), CodeInvalidEntity,
+					fmt.Errorf("entity validation failed at pos %d: %w", i, err)))
+			}
+		}
+	}
+
+	if err := o.validLanguage(); err != nil {
+		errs = append(errs, issue("language", CodeInvalidLanguage, err))
+	}
+
+	if err := o.Extensions.validCorim(&o); err != nil {
+		errs = append(errs, issue("extensions", CodeInvalidExtensions, err))
+	}
+
+	return errs
+}
+
+func (o UnsignedCorim) validTags(opts ValidationOptions, errs []error) []error {
+	for i, t := range o.Tags {
+		validTag := t.Valid
+		if opts.StrictTags {
+			validTag = t.ValidStrict
+		}
+
+		if err := validTag(); err != nil {
+			errs = append(errs, issue(fmt.Sprintf("tags[%d]", i), CodeInvalidTag,
+				&ValidationError{Kind: ErrInvalidTag, Pos: i, Err: err}))
+		}
+	}
+
+	if opts.UniqueTagIDs {
+		errs = o.validTagIDUniqueness(errs)
+	}
+
+	return errs
+}
+
+func (o UnsignedCorim) validTagIDUniqueness(errs []error) []error {
+	dups, _ := o.duplicateTagIDs(true)
+
+	for _, d := range dups {
+		errs = append(errs, issue(fmt.Sprintf("tags[%d]", d.Pos), CodeDuplicateTagID, d))
+	}
+
+	return errs
+}
+
+func (o UnsignedCorim) validDependentRims(opts ValidationOptions, errs []error) []error {
+	if o.DependentRims != nil {
+		for i, r := range *o.DependentRims {
+			err := r.Valid()
+			if err == nil && opts.AllowedThumbprintAlgorithms != nil {
+				err = r.validThumbprintAlgorithms(opts.AllowedThumbprintAlgorithms)
+			}
+
+			if err != nil {
+				errs = append(errs, issue(fmt.Sprintf("dependent-rims[%d]", i), CodeInvalidDependentRim,
+					&ValidationError{Kind: ErrInvalidDependentRim, Pos: i, Err: err}))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (o UnsignedCorim) validProfilePhase(opts ValidationOptions, errs []error) []error {
+	if o.Profile != nil {
+		if err := ValidProfile(*o.Profile); err != nil {
+			return append(errs, issue("profile", CodeInvalidProfile,
+				fmt.Errorf("%w: %w", ErrInvalidProfile, err)))
+		}
+	}
+
+	// profile-specific checks only make sense on an otherwise valid CoRIM
+	if len(errs) == 0 {
+		if err := o.validProfile(opts.RejectUnknownProfiles); err != nil {
+			errs = append(errs, issue("profile", CodeInvalidProfile, err))
+		}
+	}
+
+	return errs
+}
+
+// ToCBOR serializes the target unsigned CoRIM to CBOR
+func (o UnsignedCorim) ToCBOR() ([]byte, error) {
+	return o.toCBOR(em)
+}
+
+func (o UnsignedCorim) toCBOR(em cbor.EncMode) ([]byte, error) {
+	src, unknown := o.cborSource()
+	return encoding.SerializeStructToCBORWithUnknown(em, src, unknown)
+}
+
+// EncodeCBOR is like ToCBOR, but writes the serialized unsigned CoRIM to w
+// entry by entry, instead of assembling the whole encoding in memory first.
+func (o UnsignedCorim) EncodeCBOR(w io.Writer) error {
+	src, unknown := o.cborSource()
+	return encoding.EncodeStructToCBORWithUnknown(em, w, src, unknown)
+}
+
+// cborSource returns the value to serialize in place of the target CoRIM,
+// along with the map entries to add for RawExtensions
+func (o UnsignedCorim) cborSource() (UnsignedCorim, map[int]cbor.RawMessage) {
+	// If extensions have been registered, the collection will exist, but
+	// might be empty. If that is the case, set it to nil to avoid
+	// marshaling an empty list (and let the marshaller omit the claim
+	// instead). Note that since the receiver was passed by value, we do not
+	// need to worry about saving the field's value before setting it to
+	// nil.
+	if o.Entities != nil && o.Entities.IsEmpty() {
+		o.Entities = nil
+	}
+
+	var unknown map[int]cbor.RawMessage
+
+	if len(o.RawExtensions) != 0 {
+		unknown = make(map[int]cbor.RawMessage, len(o.RawExtensions))
+		for k, v := range o.RawExtensions {
+			unknown[int(k)] = v
+		}
+	}
+
+	return o, unknown
+}
+
+// FromCBOR deserializes a CBOR-encoded unsigned CoRIM into the target
+// UnsignedCorim.  Map entries that are not understood are kept in
+// RawExtensions.  The profile can be either in the legacy bare form or
+// wrapped in a CBOR tag (see EncodeOptions), and it is also accepted as a
+// one-element array.  The tags array and the profile array may be encoded as
+// indefinite-length arrays.
+func (o *UnsignedCorim) FromCBOR(data []byte) error {
+	return o.fromCBOR(dm, data)
+}
+
+// DecodeCBOR is like FromCBOR, but reads a single CBOR-encoded unsigned CoRIM
+// from r.  Note that r may be read past the end of the unsigned CoRIM.
+func (o *UnsignedCorim) DecodeCBOR(r io.Reader) error {
+	var data cbor.RawMessage
+
+	if err := dm.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+
+	return o.fromCBOR(dm, data)
+}
+
+// DecodeOptions specifies the limits enforced by FromCBORWithOptions.  Zero
+// values select the defaults documented for each field.
+type DecodeOptions struct {
+	// MaxSize is the maximum length of the input in bytes.  The default is
+	// DefaultMaxSize.
+	MaxSize int
+	// MaxTags is the maximum number of entries in the tags array.  The
+	// default is DefaultMaxTags.
+	MaxTags int
+	// MaxNestedLevels is the maximum nesting depth of CBOR arrays, maps and
+	// tags.  It can be set to a value between 4 and 65535, and the default
+	// is DefaultMaxNestedLevels.
+	MaxNestedLevels int
+	// MaxArrayElements is the maximum number of elements of any CBOR array,
+	// and the maximum number of pairs of any CBOR map.  It can be set to a
+	// value between 16 and 2147483647, and the default is 131072.
+	MaxArrayElements int
+}
+
+const (
+	// DefaultMaxSize is the default DecodeOptions.MaxSize (16 MiB)
+	DefaultMaxSize = 16 << 20
+	// DefaultMaxTags is the default DecodeOptions.MaxTags
+	DefaultMaxTags = 4096
+	// DefaultMaxNestedLevels is the default DecodeOptions.MaxNestedLevels
+	DefaultMaxNestedLevels = 32
+)
+
+// FromCBORWithOptions is like FromCBOR, but checks the input against the limits
+// specified by the supplied options before decoding it, so that maliciously
+// crafted input cannot cause excessive resource consumption.  Note that the
+// limits apply to the unsigned-corim-map, not to the content of the tags, which
+// is decoded separately (see GetComids, GetCoswids and GetCots).  On failure,
+// the fields of the target are left unchanged.
+func (o *UnsignedCorim) FromCBORWithOptions(data []byte, opts DecodeOptions) error {
+	maxSize := opts.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	if len(data) > maxSize {
+		return fmt.Errorf("input size %d exceeds the maximum of %d bytes", len(data), maxSize)
+	}
+
+	limitedDM, err := newLimitsDecMode(opts.MaxNestedLevels, opts.MaxArrayElements)
+	if err != nil {
+		return fmt.Errorf("invalid decode options: %w", err)
+	}
+
+	if err := limitedDM.Wellformed(data); err != nil {
+		return err
+	}
+
+	maxTags := opts.MaxTags
+	if maxTags == 0 {
+		maxTags = DefaultMaxTags
+	}
+
+	n, err := numTags(limitedDM, data, maxTags)
+	if err != nil {
+		return err
+	}
+
+	if n > uint64(maxTags) {
+		return fmt.Errorf("number of tags %d exceeds the maximum of %d", n, maxTags)
+	}
+
+	// decode into a temporary that carries the extensions registered with
+	// the target, so that the target is only updated on success
+	tmp := UnsignedCorim{Extensions: o.Extensions}
+
+	if o.Entities != nil {
+		es := *o.Entities
+		es.Values = nil
+		tmp.Entities = &es
+	}
+
+	if err := tmp.fromCBOR(modes.wideDec, data); err != nil {
+		return err
+	}
+
+	*o = tmp
+
+	return nil
+}
+
+// numTags returns the number of entries in the tags array of the supplied
+// unsigned-corim-map, as read from the head of the array, without decoding
+// them.  Entries of an indefinite-length array are counted one by one, up to
+// limit+1.  If there is no tags array, 0 is returned and the error, if any, is
+// left to the caller to report.
+func numTags(dm cbor.DecMode, data []byte, limit int) (uint64, error) {
+	opts := dm.DecOptions()
+	opts.IndefLength = cbor.IndefLengthAllowed
+
+	idm, err := opts.DecMode()
+	if err != nil {
+		return 0, err
+	}
+
+	var probe struct {
+		Tags cbor.RawMessage `cbor:"1,keyasint,omitempty"`
+	}
+
+	if err := idm.Unmarshal(data, &probe); err != nil {
+		return 0, err
+	}
+
+	if len(probe.Tags) == 0 {
+		return 0, nil
+	}
+
+	if probe.Tags[0] == cborIndefiniteArray {
+		n := uint64(0)
+
+		for rest := probe.Tags[1:]; len(rest) > 0 && rest[0] != cborBreak; n++ {
+			if n > uint64(limit) {
+				break
+			}
+
+			var item cbor.RawMessage
+			if rest, err = idm.UnmarshalFirst(rest, &item); err != nil {
+				return 0, fmt.Errorf("decoding tags: %w", err)
+			}
+		}
+
+		return n, nil
+	}
+
+	major, n, err := readCBORHead(bufio.NewReader(bytes.NewReader(probe.Tags)), nil)
+	if err != nil || major != cborMajorTypeArray {
+		return 0, nil
+	}
+
+	return n, nil
+}
+
+func (o *UnsignedCorim) fromCBOR(dm cbor.DecMode, data []byte) error {
+	data, err := normalizeCorimMap(dm, data)
+	if err != nil {
+		return err
+	}
+
+	unknown, err := encoding.PopulateStructFromCBORWithUnknown(dm, data, o)
+	if err != nil {
+		return err
+	}
+
+	o.RawExtensions = nil
+
+	if len(unknown) != 0 {
+		o.RawExtensions = make(map[int64]cbor.RawMessage, len(unknown))
+		for k, v := range unknown {
+			o.RawExtensions[int64(k)] = v
+		}
+	}
+
+	return nil
+}
+
+// ToTaggedCBOR serializes the target unsigned CoRIM to CBOR, wrapped in the
+// tagged-unsigned-corim-map CBOR tag (501)
+func (o UnsignedCorim) ToTaggedCBOR() ([]byte, error) {
+	data, err := o.ToCBOR()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(TagHeader(UnsignedCorimTagNumber), data...), nil
+}
+
+// FromTaggedCBOR deserializes a CBOR-encoded tagged-unsigned-corim-map into the
+// target UnsignedCorim.  An error is returned if the data is not wrapped in the
+// expected CBOR tag (501).
+func (o *UnsignedCorim) FromTaggedCBOR(data []byte) error {
+	if len(data) == 0 || data[0]>>5 != cborMajorTypeTag {
+		return errors.New("missing tagged-unsigned-corim-map CBOR tag")
+	}
+
+	num, content, err := Tag(data).split()
+	if err != nil {
+		return err
+	}
+
+	if num != UnsignedCorimTagNumber {
+		return fmt.Errorf("expecting CBOR tag %d, got %d", UnsignedCorimTagNumber, num)
+	}
+
+	return o.FromCBOR(content)
+}
+
+// ToEDN serializes the target unsigned CoRIM to CBOR and renders it in
+// Extended Diagnostic Notation (see RFC 8610, Appendix G), which is intended
+// for debugging.  The entries of the tags array are shown as embedded CBOR
+// (i.e., <<506({...})>>) rather than as opaque byte strings.  Map entries are
+// shown in ascending key order.
+func (o UnsignedCorim) ToEDN() (string, error) {
+	data, err := o.ToCBOR()
+	if err != nil {
+		return "", err
+	}
+
+	var m map[int]cbor.RawMessage
+	if err := dm.Unmarshal(data, &m); err != nil {
+		return "", err
+	}
+
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	entries := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		var val string
+
+		if k == 1 { // tags
+			val, err = tagsToEDN(m[k])
+		} else {
+			val, err = cbor.Diagnose(m[k])
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("rendering key %d: %w", k, err)
+		}
+
+		entries = append(entries, fmt.Sprintf("%d: %s", k, val))
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}", nil
+}
+
+func tagsToEDN(data []byte) (string, error) {
+	var tags []Tag
+	if err := dm.Unmarshal(data, &tags); err != nil {
+		return "", err
+	}
+
+	items := make([]string, 0, len(tags))
+
+	for i, t := range tags {
+		s, err := cbor.Diagnose(t)
+		if err != nil {
+			return "", fmt.Errorf("tag at pos %d: %w", i, err)
+		}
+		items = append(items, "<<"+s+">>")
+	}
+
+	return "[" + strings.Join(items, ", ") + "]", nil
+}
+
+// ToJSON serializes the target unsigned CoRIM to JSON.  CoMID, CoSWID and CoTS
+// tags are decoded and emitted as JSON objects (see Tag.MarshalJSON).  Note that
+// when the resulting JSON is deserialized with FromJSON, the tags are
+// re-encoded to CBOR, which may not produce the exact same bytes as the
+// original tags.
+func (o UnsignedCorim) ToJSON() ([]byte, error) {
+	// If extensions have been registered, the collection will exist, but
+	// might be empty. If that is the case, set it to nil to avoid
+	// marshaling an empty list (and let the marshaller omit the claim
+	// instead). Note that since the receiver was passed by value, we do not
+	// need to worry about saving the field's value before setting it to
+	// nil.
+	if o.Entities != nil && o.Entities.IsEmpty() {
+		o.Entities = nil
+	}
+
+	// the profile is emitted in its TypedProfile form
+	w := unsignedCorimJSON{UnsignedCorim: o}
+	if o.Profile != nil {
+		w.Profile = &TypedProfile{*o.Profile}
+		w.UnsignedCorim.Profile = nil
+	}
+
+	return encoding.SerializeStructToJSON(w)
+}
+
+// FromJSON deserializes a JSON-encoded unsigned CoRIM into the target
+// UnsignedCorim.  The profile can be encoded either as a bare string or in the
+// TypedProfile form.
+func (o *UnsignedCorim) FromJSON(data []byte) error {
+	w := unsignedCorimJSON{UnsignedCorim: *o}
+
+	if err := encoding.PopulateStructFromJSON(data, &w); err != nil {
+		return err
+	}
+
+	*o = w.UnsignedCorim
+	if w.Profile != nil {
+		o.Profile = &w.Profile.Profile
+	}
+
+	return nil
+}
+
+// FromBytes deserializes the supplied data, which can be either the CBOR or the
+// JSON encoding of an unsigned CoRIM, into the target UnsignedCorim.  The format
+// is detected as follows:
+//
+//   - if the first byte has CBOR major type 5 (map) or 6 (tag), the data is
+//     decoded as CBOR (this covers both unsigned-corim-map and its tagged
+//     form);
+//   - otherwise, after skipping a UTF-8 byte order mark and any JSON
+//     whitespace, data starting with '{' is decoded as JSON.
+//
+// The two cases cannot overlap, since JSON text never starts with a byte of
+// CBOR major type 5 or 6 (0xa0-0xdf), and a UTF-8 BOM (0xef) is a CBOR
+// simple value.  Note however that whitespace or a BOM preceding a CBOR map is
+// not skipped, as those bytes are valid CBOR items, and that the CBOR case
+// includes any tag, so a non-CoRIM tagged item results in a CBOR decoding
+// error rather than in a JSON one.
+func (o *UnsignedCorim) FromBytes(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty input")
+	}
+
+	if major := data[0] >> 5; major == cborMajorTypeMap || major == cborMajorTypeTag {
+		return o.FromCBOR(data)
+	}
+
+	text := bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text = bytes.TrimLeft(text, " \t\r\n")
+
+	if len(text) != 0 && text[0] == '{' {
+		return o.FromJSON(text)
+	}
+
+	return errors.New("unable to detect format: input is neither a CBOR map nor a JSON object")
+}
+
+// Tag is either a CBOR-encoded CoMID, CoSWID or CoTS
+type Tag []byte
+
+func (o Tag) Valid() error {
+	// there is no much we can check here, except making sure that the tag is
+	// not zero-length
+	if len(o) == 0 {
+		return errors.New("empty tag")
+	}
+	return nil
+}
+
+// ValidStrict decodes the target Tag according to its CBOR tag number and
+// checks that it is a well-formed CoMID, CoSWID or CoTS.  Tags with any other
+// tag number are reported as invalid.
+func (o Tag) ValidStrict() error {
+	if err := o.Valid(); err != nil {
+		return err
+	}
+
+	num, content, err := o.split()
+	if err != nil {
+		return err
+	}
+
+	if err := checkTagPayload(num, content); err != nil {
+		return err
+	}
+
+	switch num {
+	case ComidTagNumber:
+		var c comid.Comid
+		if err := c.FromCBOR(content); err != nil {
+			return fmt.Errorf("decoding CoMID: %w", err)
+		}
+		if err := c.Valid(); err != nil {
+			return fmt.Errorf("invalid CoMID: %w", err)
+		}
+	case CoswidTagNumber:
+		// the swid package doesn't offer an interface for validating a
+		// CoSWID (see https://github.com/veraison/swid/issues/23), so
+		// a successful decoding is all we can check
+		var c swid.SoftwareIdentity
+		if err := c.FromCBOR(content); err != nil {
+			return fmt.Errorf("decoding CoSWID: %w", err)
+		}
+	case CotsTagNumber:
+		var c cots.ConciseTaStore
+		if err := c.FromCBOR(content); err != nil {
+			return fmt.Errorf("decoding CoTS: %w", err)
+		}
+		if err := c.Valid(); err != nil {
+			return fmt.Errorf("invalid CoTS: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown tag number %d", num)
+	}
+
+	return nil
+}
+
+// ErrTagPayloadMismatch is returned by Tag.ValidStrict when the structure of
+// the payload is inconsistent with the declared tag number, e.g. a CoSWID
+// wrapped in the CoMID tag
+var ErrTagPayloadMismatch = errors.New("payload does not match tag number")
+
+// checkTagPayload peeks at key 1 of the top-level map of the payload, which
+// is a tag-identity map in CoMIDs and CoTSs, and a software-name text string
+// in CoSWIDs, to catch payloads wrapped in the tag of a different type.
+// Payloads that cannot be classified are left to the full decoding.
+func checkTagPayload(num uint64, content []byte) error {
+	var m map[int64]cbor.RawMessage
+	if err := dm.Unmarshal(content, &m); err != nil {
+		return nil
+	}
+
+	v, ok := m[1]
+	if !ok || len(v) == 0 {
+		return nil
+	}
+
+	const (
+		cborTypeTextString = 3
+		cborTypeMap        = 5
+	)
+
+	var expected byte
+
+	switch num {
+	case ComidTagNumber, CotsTagNumber:
+		expected = cborTypeMap
+	case CoswidTagNumber:
+		expected = cborTypeTextString
+	default:
+		return nil
+	}
+
+	if mt := v[0] >> 5; mt != expected && (mt == cborTypeMap || mt == cborTypeTextString) {
+		return fmt.Errorf("%w %d", ErrTagPayloadMismatch, num)
+	}
+
+	return nil
+}
+
+// MarshalJSON serializes the target Tag to JSON.  CoMID, CoSWID and CoTS tags
+// are decoded and emitted as a JSON object with the following shape:
+//
+//	{
+//	  "type": "<TAG_TYPE>",
+//	  "value": <TAG_VALUE>
+//	}
+//
+// where <TAG_TYPE> is one of "comid", "coswid" or "cots", and <TAG_VALUE> is
+// the JSON encoding of the decoded tag.  Tags of any other type are emitted as
+// a base64-encoded string of their CBOR encoding.
+func (o Tag) MarshalJSON() ([]byte, error) {
+	num, content, err := o.split()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		typ   string
+		value []byte
+	)
+
+	switch num {
+	case ComidTagNumber:
+		var c comid.Comid
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoMID: %w", err)
+		}
+		typ = "comid"
+		value, err = c.ToJSON()
+	case CoswidTagNumber:
+		var c swid.SoftwareIdentity
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoSWID: %w", err)
+		}
+		typ = "coswid"
+		value, err = c.ToJSON()
+	case CotsTagNumber:
+		var c cots.ConciseTaStore
+		if err := c.FromCBOR(content); err != nil {
+			return nil, fmt.Errorf("decoding CoTS: %w", err)
+		}
+		typ = "cots"
+		value, err = c.ToJSON()
+	default:
+		return json.Marshal([]byte(o))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", typ, err)
+	}
+
+	return json.Marshal(encoding.TypeAndValue{Type: typ, Value: value})
+}
+
+// UnmarshalJSON deserializes the supplied JSON into the target Tag.  The JSON
+// can either be an object in the form emitted by MarshalJSON, in which case
+// the tag is re-encoded to CBOR, or a base64-encoded string of the tag's CBOR
+// encoding.
+func (o *Tag) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var b []byte
+		if err := json.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		*o = b
+		return nil
+	}
+
+	var tnv encoding.TypeAndValue
+
+	if err := json.Unmarshal(data, &tnv); err != nil {
+		return fmt.Errorf("tag decoding failure: %w", err)
+	}
+
+	var (
+		prefix  []byte
+		payload []byte
+		err     error
+	)
+
+	switch tnv.Type {
+	case "comid":
+		var c comid.Comid
+		if err = c.FromJSON(tnv.Value); err != nil {
+			return fmt.Errorf("decoding CoMID: %w", err)
+		}
+		prefix = TagHeader(ComidTagNumber)
+		payload, err = c.ToCBOR()
+	case "coswid":
+		var c swid.SoftwareIdentity
+		if err = c.FromJSON(tnv.Value); err != nil {
+			return fmt.Errorf("decoding CoSWID: %w", err)
+		}
+		prefix = TagHeader(CoswidTagNumber)
+		payload, err = c.ToCBOR()
+	case "cots":
+		var c cots.ConciseTaStore
+		if err = c.FromJSON(tnv.Value); err != nil {
+			return fmt.Errorf("decoding CoTS: %w", err)
+		}
+		prefix = TagHeader(CotsTagNumber)
+		payload, err = c.ToCBOR()
+	default:
+		return fmt.Errorf("unknown tag type %q", tnv.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", tnv.Type, err)
+	}
+
+	*o = append(prefix, payload...)
+
+	return nil
+}
+
+// split returns the CBOR tag number and the enclosed (untagged) content of the
+// target Tag
+func (o Tag) split() (uint64, []byte, error) {
+	var rt cbor.RawTag
+
+	if err := dm.Unmarshal(o, &rt); err != nil {
+		return 0, nil, fmt.Errorf("decoding tag: %w", err)
+	}
+
+	return rt.Number, rt.Content, nil
+}
+
+// Locator is the internal representation of the corim-locator-map with CBOR and
+// JSON serialization.
+type Locator struct {
+	Href       comid.TaggedURI `cbor:"0,keyasint" json:"href"`
+	Thumbprint *swid.HashEntry `cbor:"1,keyasint,omitempty" json:"thumbprint,omitempty"`
+	// AltThumbprints are further thumbprints of the same RIM, computed using
+	// hash algorithms different from that of Thumbprint (e.g., while
+	// migrating from one algorithm to another).  When present, the thumbprints
+	// are serialized as an array.  AltThumbprints must not be set unless
+	// Thumbprint is.
+	AltThumbprints []swid.HashEntry `cbor:"-" json:"-"`
+	// Hints carries corim-locator-map entries that are not defined by the
+	// base spec, such as profile-defined transport or authentication hints
+	// (see SetLocatorHint).  They are preserved across CBOR round-trips, but
+	// are not serialized to JSON.
+	Hints map[int64]cbor.RawMessage `cbor:"-" json:"-"`
+}
+
+// locatorCBOR and locatorJSON are the wire representations of Locator, where
+// the thumbprint is either a single digest or an array of digests
+type locatorCBOR struct {
+	Href       comid.TaggedURI `cbor:"0,keyasint"`
+	Thumbprint interface{}     `cbor:"1,keyasint,omitempty"`
+}
+
+type locatorJSON struct {
+	Href       comid.TaggedURI `json:"href"`
+	Thumbprint interface{}     `json:"thumbprint,omitempty"`
+}
+
+// clone returns a deep copy of the target Locator
+func (o Locator) clone() Locator {
+	c := o
+
+	if o.Thumbprint != nil {
+		c.Thumbprint = &swid.HashEntry{
+			HashAlgID: o.Thumbprint.HashAlgID,
+			HashValue: bytes.Clone(o.Thumbprint.HashValue),
+		}
+	}
+
+	if o.Hints != nil {
+		c.Hints = make(map[int64]cbor.RawMessage, len(o.Hints))
+		for k, v := range o.Hints {
+			c.Hints[k] = bytes.Clone(v)
+		}
+	}
+
+	if o.AltThumbprints != nil {
+		c.AltThumbprints = make([]swid.HashEntry, len(o.AltThumbprints))
+		for i, tp := range o.AltThumbprints {
+			c.AltThumbprints[i] = swid.HashEntry{
+				HashAlgID: tp.HashAlgID,
+				HashValue: bytes.Clone(tp.HashValue),
+			}
+		}
+	}
+
+	return c
+}
+
+// Thumbprints returns all the thumbprints of the target Locator, i.e.,
+// Thumbprint followed by AltThumbprints
+func (o Locator) Thumbprints() []swid.HashEntry {
+	if o.Thumbprint == nil {
+		return nil
+	}
+
+	return append([]swid.HashEntry{*o.Thumbprint}, o.AltThumbprints...)
+}
+
+func (o *Locator) setThumbprints(tps []swid.HashEntry) error {
+	if len(tps) == 0 {
+		return errors.New("empty thumbprint array")
+	}
+
+	o.Thumbprint = &tps[0]
+	o.AltThumbprints = nil
+	if len(tps) > 1 {
+		o.AltThumbprints = tps[1:]
+	}
+
+	return nil
+}
+
+func (o Locator) wireThumbprint() interface{} {
+	if len(o.AltThumbprints) != 0 {
+		return o.Thumbprints()
+	}
+
+	if o.Thumbprint != nil {
+		return o.Thumbprint
+	}
+
+	return nil
+}
+
+func (o Locator) MarshalCBOR() ([]byte, error) {
+	temp := locatorCBOR{
+		Href:       o.Href,
+		Thumbprint: o.wireThumbprint(),
+	}
+
+	if len(o.Hints) == 0 {
+		return em.Marshal(temp)
+	}
+
+	unknown := make(map[int]cbor.RawMessage, len(o.Hints))
+	for k, v := range o.Hints {
+		unknown[int(k)] = v
+	}
+
+	return encoding.SerializeStructToCBORWithUnknown(em, temp, unknown)
+}
+
+func (o *Locator) UnmarshalCBOR(data []byte) error {
+	var temp struct {
+		Href       comid.TaggedURI `cbor:"0,keyasint"`
+		Thumbprint cbor.RawMessage `cbor:"1,keyasint,omitempty"`
+	}
+
+	if err := dm.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	var all map[int64]cbor.RawMessage
+	if err := dm.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	o.Href = temp.Href
+	o.Thumbprint = nil
+	o.AltThumbprints = nil
+	o.Hints = nil
+
+	for k, v := range all {
+		if k == 0 || k == 1 {
+			continue
+		}
+
+		if o.Hints == nil {
+			o.Hints = make(map[int64]cbor.RawMessage)
+		}
+		o.Hints[k] = v
+	}
+
+	if temp.Thumbprint == nil {
+		return nil
+	}
+
+	var tp swid.HashEntry
+	if err := dm.Unmarshal(temp.Thumbprint, &tp); err == nil {
+		o.Thumbprint = &tp
+		return nil
+	}
+
+	var tps []swid.HashEntry
+	if err := dm.Unmarshal(temp.Thumbprint, &tps); err != nil {
+		return fmt.Errorf("decoding thumbprint: %w", err)
+	}
+
+	return o.setThumbprints(tps)
+}
+
+func (o Locator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(locatorJSON{
+		Href:       o.Href,
+		Thumbprint: o.wireThumbprint(),
+	})
+}
+
+func (o *Locator) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		Href       comid.TaggedURI `json:"href"`
+		Thumbprint json.RawMessage `json:"thumbprint,omitempty"`
+	}
+
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	o.Href = temp.Href
+	o.Thumbprint = nil
+	o.AltThumbprints = nil
+
+	if temp.Thumbprint == nil {
+		return nil
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(temp.Thumbprint), []byte("[")) {
+		var tps []swid.HashEntry
+		if err := json.Unmarshal(temp.Thumbprint, &tps); err != nil {
+			return fmt.Errorf("decoding thumbprint: %w", err)
+		}
+
+		return o.setThumbprints(tps)
+	}
+
+	var tp swid.HashEntry
+	if err := json.Unmarshal(temp.Thumbprint, &tp); err != nil {
+		return fmt.Errorf("decoding thumbprint: %w", err)
+	}
+
+	o.Thumbprint = &tp
+
+	return nil
+}
+
+// ThumbprintMatches reports whether any of the thumbprints of the target
+// Locator uses the supplied hash algorithm identifier and has the supplied
+// value.  It returns false if the Locator has no thumbprint.
+//
+// The values are compared in constant time (using crypto/subtle), so that an
+// attacker submitting candidate RIMs cannot learn how many leading bytes of
+// the expected thumbprint they got right by timing the comparison.  Do not
+// replace this with bytes.Equal.
+func (o Locator) ThumbprintMatches(alg uint64, value []byte) bool {
+	for _, tp := range o.Thumbprints() {
+		if thumbprintMatches(&tp, alg, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func thumbprintMatches(tp *swid.HashEntry, alg uint64, value []byte) bool {
+	if tp == nil || tp.HashAlgID != alg {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(tp.HashValue, value) == 1
+}
+
+// LocatorSchemes is the list of URI schemes accepted in the href of a
+// corim-locator-map.  It can be modified to suit the needs of the caller.
+var LocatorSchemes = []string{"http", "https", "file", OCIScheme}
+
+func (o Locator) Valid() error {
+	if o.Href.Empty() {
+		return errors.New("empty href")
+	}
+
+	u, err := url.Parse(string(o.Href))
+	if err != nil {
+		return fmt.Errorf("invalid locator href: %w", err)
+	}
+
+	if !u.IsAbs() {
+		return errors.New("locator href must be an absolute URI")
+	}
+
+	if !slices.Contains(LocatorSchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("locator href scheme %q is not allowed", u.Scheme)
+	}
+
+	if o.Thumbprint == nil && len(o.AltThumbprints) != 0 {
+		return errors.New("alternative thumbprints set without a thumbprint")
+	}
+
+	if tp := o.Thumbprint; tp != nil {
+		if err := swid.ValidHashEntry(tp.HashAlgID, tp.HashValue); err != nil {
+			return fmt.Errorf("invalid locator thumbprint: %w", err)
+		}
+	}
+
+	algs := make(map[uint64]bool)
+
+	for i, tp := range o.Thumbprints() {
+		if i > 0 {
+			if err := swid.ValidHashEntry(tp.HashAlgID, tp.HashValue); err != nil {
+				return fmt.Errorf("invalid locator thumbprint at pos %d: %w", i, err)
+			}
+		}
+
+		if algs[tp.HashAlgID] {
+			return fmt.Errorf("duplicate locator thumbprint algorithm %d at pos %d", tp.HashAlgID, i)
+		}
+		algs[tp.HashAlgID] = true
+	}
+
+	if strings.EqualFold(u.Scheme, OCIScheme) {
+		if err := o.validOCI(); err != nil {
+			return fmt.Errorf("invalid OCI locator: %w", err)
+		}
+	}
+
+	return o.validHints()
+}
+
+func (o Locator) validThumbprintAlgorithms(allowed []uint64) error {
+	for _, tp := range o.Thumbprints() {
+		if !slices.Contains(allowed, tp.HashAlgID) {
+			return fmt.Errorf("thumbprint algorithm %d is not allowed", tp.HashAlgID)
+		}
+	}
+
+	return nil
+}
+
+// ValidProfile checks that the supplied profile is in one of the supported
+// formats (i.e., URI or OID)
 func ValidProfile(p eat.Profile) error {
 	if !p.IsOID() && !p.IsURI() {
 		return errors.New("profile should be OID or URI")
 	}
 	return nil
 }
+
+// NormalizeProfile returns the normalized string form of the supplied profile,
+// which can be used to compare profiles for equivalence.  OIDs are returned in
+// dotted-decimal notation.  URIs have their scheme and host lower-cased, and
+// an empty path is replaced with "/".
+func NormalizeProfile(p eat.Profile) (string, error) {
+	if err := ValidProfile(p); err != nil {
+		return "", err
+	}
+
+	s, err := p.Get()
+	if err != nil {
+		return "", err
+	}
+
+	if p.IsOID() {
+		return s, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("profile URI: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	if u.Path == "" && u.Opaque == "" {
+		u.Path = "/"
+	}
+
+	return u.String(), nil
+}
+
+// CanonicalizeProfiles returns a copy of the supplied profiles in which the
+// URIs found in uriToOID are replaced with the corresponding dotted-decimal
+// OIDs.  URIs are looked up in their normalized form (see NormalizeProfile).
+// OIDs, unknown URIs and profiles whose table entry is not a valid OID are
+// left unchanged.
+func CanonicalizeProfiles(profiles []eat.Profile, uriToOID map[string]string) []eat.Profile {
+	if profiles == nil {
+		return nil
+	}
+
+	table := make(map[string]string, len(uriToOID))
+	for u, oid := range uriToOID {
+		p, err := eat.NewProfile(u)
+		if err != nil || !p.IsURI() {
+			continue
+		}
+
+		if n, err := NormalizeProfile(*p); err == nil {
+			table[n] = oid
+		}
+	}
+
+	ret := make([]eat.Profile, len(profiles))
+	for i, p := range profiles {
+		ret[i] = p
+
+		if !p.IsURI() {
+			continue
+		}
+
+		n, err := NormalizeProfile(p)
+		if err != nil {
+			continue
+		}
+
+		oid, ok := table[n]
+		if !ok {
+			continue
+		}
+
+		if c, err := eat.NewProfile(oid); err == nil && c.IsOID() {
+			ret[i] = *c
+		}
+	}
+
+	return ret
+}
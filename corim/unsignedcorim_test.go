@@ -4,15 +4,21 @@
 package corim
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/veraison/corim/comid"
 	"github.com/veraison/corim/cots"
 	"github.com/veraison/corim/extensions"
+	"github.com/veraison/eat"
 	"github.com/veraison/swid"
 )
 
@@ -339,9 +345,31 @@ func TestUnsignedCorim_ToJSON(t *testing.T) {
 	expectedJSON := `
 	{
 		"corim-id":"invalid.tags.corim",
-		"tags":["2QH6ogGhAHV2ZW5kb3IuZXhhbXBsZS9wcm9kLzEEoQOBgqEB2CVQMftavwI+SZKqTpX5wVA7+oHZAip4sS0tLS0tQkVHSU4gUFVCTElDIEtFWS0tLS0tCk1Ga3dFd1lIS29aSXpqMENBUVlJS29aSXpqMERBUWNEUWdBRVcxQnZxRisvcnk4QldhN1pFTVUxeFlZSEVROEIKbExUNE1GSE9hTytJQ1R0SXZyRWVFcHIvc2ZUQVA2NkgyaENIZGI1SEVYS3RSS29kNlFMY09MUEExUT09Ci0tLS0tRU5EIFBVQkxJQyBLRVktLS0tLQ=="],
+		"tags":[
+			{
+				"type":"comid",
+				"value":{
+					"tag-identity":{"id":"vendor.example/prod/1"},
+					"triples":{
+						"attester-verification-keys":[
+							{
+								"environment":{
+									"instance":{"type":"uuid","value":"31fb5abf-023e-4992-aa4e-95f9c1503bfa"}
+								},
+								"verification-keys":[
+									{
+										"type":"pkix-base64-key",
+										"value":"-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEW1BvqF+/ry8BWa7ZEMU1xYYHEQ8B\nlLT4MFHOaO+ICTtIvrEeEpr/sfTAP66H2hCHdb5HEXKtRKod6QLcOLPA1Q==\n-----END PUBLIC KEY-----"
+									}
+								]
+							}
+						]
+					}
+				}
+			}
+		],
 		"dependent-rims":[{"href":"http://endorser.example/addon.corim"}],
-		"profile":"https://arm.com/psa/iot/2.0.0"
+		"profile":{"type":"uri","value":"https://arm.com/psa/iot/2.0.0"}
 	}
 	`
 
@@ -408,6 +436,15 @@ func TestLocator_Valid(t *testing.T) {
 	l := Locator{}
 	assert.EqualError(t, l.Valid(), "empty href")
 
+	l.Href = comid.TaggedURI("example.com/addon.corim")
+	assert.EqualError(t, l.Valid(), "locator href must be an absolute URI")
+
+	l.Href = comid.TaggedURI("ftp://example.com/addon.corim")
+	assert.EqualError(t, l.Valid(), `locator href scheme "ftp" is not allowed`)
+
+	l.Href = comid.TaggedURI("file:///var/lib/corim/addon.corim")
+	assert.NoError(t, l.Valid())
+
 	l.Href = comid.TaggedURI("https://example.com")
 	assert.NoError(t, l.Valid())
 
@@ -415,3 +452,1255 @@ func TestLocator_Valid(t *testing.T) {
 	assert.EqualError(t, l.Valid(), "invalid locator thumbprint: unknown hash algorithm 0")
 
 }
+
+func TestUnsignedCorim_RemoveTag(t *testing.T) {
+	tv := NewUnsignedCorim().SetID("test corim id")
+	require.NotNil(t, tv)
+
+	tv.Tags = append(tv.Tags, Tag{0x01}, Tag{0x02}, Tag{0x03})
+
+	assert.Nil(t, tv.RemoveTag(3))
+	assert.Nil(t, tv.RemoveTag(-1))
+
+	require.NotNil(t, tv.RemoveTag(1))
+	assert.Equal(t, []Tag{{0x01}, {0x03}}, tv.Tags)
+
+	require.NotNil(t, tv.RemoveTag(0))
+	require.NotNil(t, tv.RemoveTag(0))
+	assert.NotNil(t, tv.Tags)
+	assert.Len(t, tv.Tags, 0)
+
+	assert.EqualError(t, tv.Valid(), "tags validation failed: no tags")
+}
+
+func TestUnsignedCorim_SetRandomID(t *testing.T) {
+	a := NewTestCorim().SetRandomID()
+	require.NotNil(t, a)
+	require.NoError(t, a.Valid())
+
+	id, err := uuid.Parse(a.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(4), id.Version())
+
+	b := NewTestCorim().SetRandomID()
+	require.NotNil(t, b)
+	assert.NotEqual(t, a.GetID(), b.GetID())
+}
+
+func TestUnsignedCorim_SetContentID(t *testing.T) {
+	psa := comidFromJSON(t, comid.PSARefValJSONTemplate)
+	cca := comidFromJSON(t, comid.CCARealmRefValJSONTemplate)
+
+	a := NewUnsignedCorim().
+		AddComid(psa).
+		AddComid(cca).
+		SetProfile("http://example.com/profile").
+		SetContentID(uuid.NameSpaceURL)
+	require.NotNil(t, a)
+	require.NoError(t, a.Valid())
+
+	id, err := uuid.Parse(a.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(5), id.Version())
+
+	// same content, different tag order and profile spelling
+	b := NewUnsignedCorim().
+		AddComid(cca).
+		AddComid(psa).
+		SetProfile("HTTP://EXAMPLE.COM/profile").
+		SetContentID(uuid.NameSpaceURL)
+	require.NotNil(t, b)
+	assert.Equal(t, a.GetID(), b.GetID())
+
+	// the existing corim-id and the other entries are not part of the content
+	require.NotNil(t, b.SetID("other").SetRimValidity(time.Now().Add(time.Hour), nil))
+	require.NotNil(t, b.SetContentID(uuid.NameSpaceURL))
+	assert.Equal(t, a.GetID(), b.GetID())
+
+	for _, c := range []*UnsignedCorim{
+		NewUnsignedCorim().AddComid(psa).AddComid(cca).SetContentID(uuid.NameSpaceURL),
+		NewUnsignedCorim().AddComid(psa).SetProfile("http://example.com/profile").SetContentID(uuid.NameSpaceURL),
+		NewUnsignedCorim().AddComid(psa).AddComid(cca).SetProfile("http://example.com/profile").SetContentID(uuid.NameSpaceOID),
+	} {
+		require.NotNil(t, c)
+		assert.NotEqual(t, a.GetID(), c.GetID())
+	}
+
+	bad := NewUnsignedCorim()
+	bad.Tags = []Tag{{0xff}}
+	assert.Nil(t, bad.SetContentID(uuid.NameSpaceURL))
+}
+
+func TestUnsignedCorim_SortTags(t *testing.T) {
+	newComid := func(id string) comid.Comid {
+		c := comid.NewComid().
+			SetTagIdentity(id, 0).
+			AddAttestVerifKey(
+				comid.KeyTriple{
+					Environment: comid.Environment{
+						Instance: comid.MustNewUUIDInstance(comid.TestUUID),
+					},
+					VerifKeys: *comid.NewCryptoKeys().
+						Add(
+							comid.MustNewPKIXBase64Key(comid.TestECPubKey),
+						),
+				},
+			)
+		require.NotNil(t, c)
+		return *c
+	}
+
+	unknown := Tag{0xd9, 0x01, 0xf8, 0x00}
+
+	a := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).
+		AddComid(newComid("vendor.example/b")).
+		AddComid(newComid("vendor.example/a"))
+	require.NotNil(t, a)
+	a.Tags = append(a.Tags, unknown)
+
+	b := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	b.Tags = append([]Tag{unknown}, b.Tags...)
+	require.NotNil(t, b.AddComid(newComid("vendor.example/a")).AddComid(newComid("vendor.example/b")))
+	b.Tags[0], b.Tags[1] = b.Tags[1], b.Tags[0]
+
+	aCBOR, err := a.ToCBOR()
+	require.NoError(t, err)
+	bCBOR, err := b.ToCBOR()
+	require.NoError(t, err)
+	assert.NotEqual(t, aCBOR, bCBOR)
+
+	require.NotNil(t, a.SortTags())
+	require.NotNil(t, b.SortTags())
+
+	aCBOR, err = a.ToCBOR()
+	require.NoError(t, err)
+	bCBOR, err = b.ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, aCBOR, bCBOR)
+
+	assert.Equal(t, unknown, a.Tags[0])
+
+	comids, err := a.GetComids()
+	require.NoError(t, err)
+	require.Len(t, comids, 3)
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", comids[0].TagIdentity.TagID.String())
+	assert.Equal(t, "vendor.example/a", comids[1].TagIdentity.TagID.String())
+	assert.Equal(t, "vendor.example/b", comids[2].TagIdentity.TagID.String())
+
+	// undecodable tags leave the array untouched
+	a.Tags = append(a.Tags, append(ComidTag, 0x01))
+	before := append([]Tag(nil), a.Tags...)
+	assert.Nil(t, a.SortTags())
+	assert.Equal(t, before, a.Tags)
+}
+
+func TestUnsignedCorim_RemoveComidByID(t *testing.T) {
+	var tv UnsignedCorim
+	err := tv.FromCBOR(testGoodUnsignedCorimCBOR)
+	require.NoError(t, err)
+
+	c := comid.NewComid().
+		SetTagIdentity("vendor.example/prod/1", 0).
+		AddAttestVerifKey(
+			comid.KeyTriple{
+				Environment: comid.Environment{
+					Instance: comid.MustNewUUIDInstance(comid.TestUUID),
+				},
+				VerifKeys: *comid.NewCryptoKeys().
+					Add(
+						comid.MustNewPKIXBase64Key(comid.TestECPubKey),
+					),
+			},
+		)
+	require.NotNil(t, c)
+	require.NotNil(t, tv.AddComid(*c))
+	require.Len(t, tv.Tags, 2)
+
+	id := swid.NewTagID("vendor.example/prod/1")
+	require.NotNil(t, id)
+
+	require.NotNil(t, tv.RemoveComidByID(*id))
+	assert.Len(t, tv.Tags, 1)
+
+	assert.Nil(t, tv.RemoveComidByID(*id))
+}
+
+func TestUnsignedCorim_ReplaceComid(t *testing.T) {
+	tv := NewTestCorim().AddRawTag(CoswidTagNumber, []byte{0xa0})
+	require.NotNil(t, tv)
+	tv = tv.AddComid(comidFromJSON(t, comid.PSARefValJSONTemplate))
+	require.NotNil(t, tv)
+	require.Len(t, tv.Tags, 3)
+
+	coswidTag, psaTag := tv.Tags[1], tv.Tags[2]
+
+	comids, err := tv.GetComids()
+	require.NoError(t, err)
+	updated := comids[0]
+	updated.TagIdentity.TagVersion = 1
+
+	ok, err := tv.ReplaceComid(updated)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.Len(t, tv.Tags, 3)
+	assert.Equal(t, coswidTag, tv.Tags[1])
+	assert.Equal(t, psaTag, tv.Tags[2])
+
+	comids, err = tv.GetComids()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), comids[0].TagIdentity.TagVersion)
+
+	// no CoMID with a matching tag-id
+	other := updated
+	other.TagIdentity.TagID = *swid.NewTagID("vendor.example/other")
+	ok, err = tv.ReplaceComid(other)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = tv.ReplaceComid(comid.Comid{})
+	assert.ErrorContains(t, err, "invalid CoMID")
+}
+
+func TestUnsignedCorim_GetComids(t *testing.T) {
+	var tv UnsignedCorim
+	err := tv.FromCBOR(testGoodUnsignedCorimCBOR)
+	require.NoError(t, err)
+
+	// an unknown tag is skipped
+	tv.Tags = append(tv.Tags, Tag{0xd9, 0x01, 0xf8, 0x00})
+
+	comids, err := tv.GetComids()
+	require.NoError(t, err)
+	require.Len(t, comids, 1)
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", comids[0].TagIdentity.TagID.String())
+
+	coswids, err := tv.GetCoswids()
+	assert.NoError(t, err)
+	assert.Len(t, coswids, 0)
+
+	// a malformed CoMID is reported
+	tv.Tags = append(tv.Tags, append(ComidTag, 0x01))
+	_, err = tv.GetComids()
+	assert.ErrorContains(t, err, "decoding CoMID at pos 2")
+}
+
+func TestUnsignedCorim_ComidAt(t *testing.T) {
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	// non-CoMID tags do not shift the index space
+	tv.Tags = append([]Tag{{0xd9, 0x01, 0xf8, 0x00}}, tv.Tags...)
+	require.NotNil(t, tv.AddComid(comidFromJSON(t, comid.PSAKeysJSONTemplate)))
+
+	assert.Equal(t, 2, tv.NumComids())
+
+	c, err := tv.ComidAt(0)
+	require.NoError(t, err)
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", c.TagIdentity.TagID.String())
+
+	c, err = tv.ComidAt(1)
+	require.NoError(t, err)
+	assert.NotNil(t, c.Triples.AttestVerifKeys)
+
+	_, err = tv.ComidAt(2)
+	assert.EqualError(t, err, "CoMID index 2 out of range")
+
+	_, err = tv.ComidAt(-1)
+	assert.EqualError(t, err, "CoMID index -1 out of range")
+
+	// only the selected CoMID is decoded
+	tv.Tags = append(tv.Tags, append(ComidTag, 0x01))
+	assert.Equal(t, 3, tv.NumComids())
+
+	_, err = tv.ComidAt(0)
+	assert.NoError(t, err)
+
+	_, err = tv.ComidAt(2)
+	assert.ErrorContains(t, err, "decoding CoMID at pos 3")
+}
+
+func TestUnsignedCorim_GetCoswids(t *testing.T) {
+	var c swid.SoftwareIdentity
+	err := c.FromXML([]byte(`<SoftwareIdentity xmlns="http://standards.iso.org/iso/19770/-2/2015/schema.xsd" tagId="com.acme.rrd2013-ce-sp1-v4-1-5-0" name="ACME Roadrunner Detector 2013 Coyote Edition SP1" version="4.1.5"><Entity name="The ACME Corporation" regid="acme.com" role="tagCreator softwareCreator"></Entity></SoftwareIdentity>`))
+	require.NoError(t, err)
+
+	tv := NewUnsignedCorim().SetID("test corim id").AddCoswid(c)
+	require.NotNil(t, tv)
+
+	coswids, err := tv.GetCoswids()
+	require.NoError(t, err)
+	require.Len(t, coswids, 1)
+	assert.Equal(t, "com.acme.rrd2013-ce-sp1-v4-1-5-0", coswids[0].TagID.String())
+
+	comids, err := tv.GetComids()
+	assert.NoError(t, err)
+	assert.Len(t, comids, 0)
+}
+
+func TestUnsignedCorim_GetCots(t *testing.T) {
+	c := cots.ConciseTaStore{}
+	err := c.FromJSON([]byte(cots.ConciseTaStoreTemplateSingleOrg))
+	require.NoError(t, err)
+
+	tv := NewUnsignedCorim().SetID("test corim id with CoTS").AddCots(c)
+	require.NotNil(t, tv)
+
+	stores, err := tv.GetCots()
+	require.NoError(t, err)
+	require.Len(t, stores, 1)
+	assert.Equal(t, c.Keys, stores[0].Keys)
+
+	comids, err := tv.GetComids()
+	assert.NoError(t, err)
+	assert.Len(t, comids, 0)
+
+	tv.Tags = append(tv.Tags, append(cots.CotsTag, 0x01))
+	_, err = tv.GetCots()
+	assert.ErrorContains(t, err, "decoding CoTS at pos 1")
+}
+
+func TestUnsignedCorim_Clone(t *testing.T) {
+	var tv UnsignedCorim
+	err := tv.FromCBOR(testGoodUnsignedCorimCBOR)
+	require.NoError(t, err)
+
+	thumbprint := swid.HashEntry{
+		HashAlgID: swid.Sha256,
+		HashValue: comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75"),
+	}
+
+	require.NotNil(t, tv.
+		AddDependentRim("https://endorser.example/addon.corim", &thumbprint).
+		SetProfile("https://arm.com/psa/iot/2.0.0").
+		AddEntity("ACME Ltd.", nil, RoleManifestCreator))
+
+	orig := tv.Clone()
+	require.Equal(t, orig, tv.Clone())
+
+	clone := tv.Clone()
+	clone.Tags[0][0] = 0x00
+	clone.Tags = append(clone.Tags, Tag{0x01})
+	(*clone.DependentRims)[0].Thumbprint.HashValue[0] = 0x00
+	*clone.DependentRims = append(*clone.DependentRims, Locator{})
+	require.NoError(t, clone.Profile.Set("2.5.2.8192"))
+	clone.Entities.Values[0].Roles[0] = Role(666)
+
+	assert.Equal(t, orig, &tv)
+}
+
+func TestUnsignedCorim_AddLocator(t *testing.T) {
+	src := NewUnsignedCorim().AddDependentRim("https://example.com/a",
+		&swid.HashEntry{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)},
+		&swid.HashEntry{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)})
+	require.NotNil(t, src)
+
+	l := (*src.DependentRims)[0]
+	require.NotNil(t, l.SetLocatorHint(-1, "bearer"))
+
+	tv := dependentCorim(t, "root")
+	require.NotNil(t, tv.AddLocator(l))
+	require.Len(t, *tv.DependentRims, 1)
+	assert.Equal(t, l, (*tv.DependentRims)[0])
+	assert.NoError(t, tv.Valid())
+
+	// the locator is copied
+	l.Thumbprint.HashValue[0] = 0xff
+	l.Hints[-1][1] = 'B'
+	assert.Equal(t, byte(0x00), (*tv.DependentRims)[0].Thumbprint.HashValue[0])
+	assert.NotEqual(t, l.Hints[-1], (*tv.DependentRims)[0].Hints[-1])
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	var hint string
+	require.NoError(t, (*actual.DependentRims)[0].GetLocatorHint(-1, &hint))
+	assert.Equal(t, "bearer", hint)
+	assert.Len(t, (*actual.DependentRims)[0].Thumbprints(), 2)
+
+	assert.Nil(t, tv.AddLocator(Locator{}))
+	assert.Nil(t, tv.AddLocator(Locator{Href: "relative/path"}))
+	assert.Len(t, *tv.DependentRims, 1)
+}
+
+func TestUnsignedCorim_AddRawTag(t *testing.T) {
+	c := comid.Comid{}
+	err := c.FromJSON([]byte(comid.PSARefValJSONTemplate))
+	require.NoError(t, err)
+
+	comidCBOR, err := c.ToCBOR()
+	require.NoError(t, err)
+
+	tv := NewUnsignedCorim().SetID("test corim id")
+	require.NotNil(t, tv)
+
+	require.NotNil(t, tv.AddRawTag(506, comidCBOR))
+	require.Len(t, tv.Tags, 1)
+	assert.Equal(t, Tag(append(ComidTag, comidCBOR...)), tv.Tags[0])
+
+	assert.Nil(t, tv.AddRawTag(501, comidCBOR))
+	assert.Nil(t, tv.AddRawTag(506, []byte{0xff}))
+	assert.Len(t, tv.Tags, 1)
+}
+
+func TestUnsignedCorim_AddCoswidRaw(t *testing.T) {
+	// {0: "tag", 12: 0}, with a non-shortest encoding of 0 that re-encoding
+	// would not preserve
+	untagged := []byte{0xa2, 0x00, 0x63, 0x74, 0x61, 0x67, 0x0c, 0x18, 0x00}
+	tagged := append(bytes.Clone(CoswidTag), untagged...)
+
+	tv := NewUnsignedCorim().
+		AddCoswidRaw(untagged).
+		AddCoswidRaw(tagged)
+	require.NotNil(t, tv)
+	require.Len(t, tv.Tags, 2)
+	assert.Equal(t, Tag(tagged), tv.Tags[0])
+	assert.Equal(t, Tag(tagged), tv.Tags[1])
+
+	// the input is not retained
+	tagged[len(tagged)-1] = 0x01
+	assert.Equal(t, byte(0x00), tv.Tags[1][len(tagged)-1])
+
+	for _, tc := range []struct {
+		data     []byte
+		expected string
+	}{
+		{nil, "empty CoSWID"},
+		{[]byte{0x80}, "CoSWID is not a CBOR map"},
+		{append(bytes.Clone(ComidTag), untagged...), "CoSWID wrapped in an unexpected CBOR tag"},
+		{[]byte{0xa1, 0x00}, "malformed CoSWID: unexpected EOF"},
+		{CoswidTag, "malformed CoSWID: EOF"},
+	} {
+		assert.EqualError(t, tv.AddCoswidRawErr(tc.data), tc.expected)
+	}
+
+	assert.Nil(t, tv.AddCoswidRaw([]byte{0x80}))
+	assert.Len(t, tv.Tags, 2)
+}
+
+func TestUnsignedCorim_Add_errors(t *testing.T) {
+	tv := NewUnsignedCorim()
+
+	err := tv.AddComidErr(comid.Comid{})
+	assert.EqualError(t, err, "invalid CoMID: tag-identity validation failed: empty tag-id")
+
+	err = tv.AddCotsErr(cots.ConciseTaStore{})
+	assert.ErrorContains(t, err, "invalid CoTS: ")
+
+	err = tv.AddRawTagErr(501, []byte{0xa0})
+	assert.EqualError(t, err, "unsupported tag number 501")
+
+	err = tv.AddRawTagErr(506, []byte{0xff})
+	assert.ErrorContains(t, err, "malformed payload: ")
+
+	err = tv.SetProfileErr("")
+	assert.ErrorContains(t, err, `invalid profile "": `)
+
+	assert.Len(t, tv.Tags, 0)
+	assert.Nil(t, tv.Profile)
+
+	var nilCorim *UnsignedCorim
+	assert.EqualError(t, nilCorim.AddComidErr(comid.Comid{}), "nil UnsignedCorim")
+}
+
+func TestUnsignedCorim_FindDependentRim(t *testing.T) {
+	sha256Value := comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75")
+	sha384Value := comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75e45b72f5c0c0b572db4d8d3ab7e97f36")
+
+	tv := NewUnsignedCorim().
+		AddDependentRim("https://endorser.example/no-thumbprint.corim", nil).
+		AddDependentRim("https://endorser.example/sha256.corim",
+			&swid.HashEntry{HashAlgID: swid.Sha256, HashValue: sha256Value}).
+		AddDependentRim("https://endorser.example/sha384.corim",
+			&swid.HashEntry{HashAlgID: swid.Sha384, HashValue: sha384Value})
+	require.NotNil(t, tv)
+
+	l := tv.FindDependentRim(swid.Sha384, sha384Value)
+	require.NotNil(t, l)
+	assert.Equal(t, comid.TaggedURI("https://endorser.example/sha384.corim"), l.Href)
+
+	l = tv.FindDependentRim(swid.Sha256, sha256Value)
+	require.NotNil(t, l)
+	assert.Equal(t, comid.TaggedURI("https://endorser.example/sha256.corim"), l.Href)
+
+	assert.Nil(t, tv.FindDependentRim(swid.Sha512, sha256Value))
+	assert.Nil(t, tv.FindDependentRim(swid.Sha256, sha384Value))
+	assert.Nil(t, NewUnsignedCorim().FindDependentRim(swid.Sha256, sha256Value))
+}
+
+func TestUnsignedCorim_RewriteLocators(t *testing.T) {
+	sha256Value := comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75")
+	tp := &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: sha256Value}
+
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).
+		AddDependentRim("https://endorser.example/a.corim", tp).
+		AddDependentRim("https://other.example/b.corim")
+	require.NotNil(t, tv)
+
+	mirror := func(href string) string {
+		return strings.Replace(href, "https://endorser.example/", "https://mirror.internal/", 1)
+	}
+
+	require.NotNil(t, tv.RewriteLocators(mirror))
+
+	rims := *tv.DependentRims
+	assert.Equal(t, comid.TaggedURI("https://mirror.internal/a.corim"), rims[0].Href)
+	assert.Equal(t, tp, rims[0].Thumbprint)
+	assert.Equal(t, comid.TaggedURI("https://other.example/b.corim"), rims[1].Href)
+	assert.NoError(t, tv.Valid())
+
+	require.NotNil(t, tv.RewriteLocators(func(string) string { return "relative/path" }))
+	assert.Error(t, tv.Valid())
+
+	// no dependent RIMs
+	assert.NotNil(t, NewUnsignedCorim().RewriteLocators(mirror))
+}
+
+func TestUnsignedCorim_TaggedCBOR_roundtrip(t *testing.T) {
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	data, err := tv.ToTaggedCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd9, 0x01, 0xf5}, data[:3])
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromTaggedCBOR(data))
+	assert.Equal(t, tv, actual)
+}
+
+func TestUnsignedCorim_FromTaggedCBOR_errors(t *testing.T) {
+	var tv UnsignedCorim
+
+	err := tv.FromTaggedCBOR(testGoodUnsignedCorimCBOR)
+	assert.EqualError(t, err, "missing tagged-unsigned-corim-map CBOR tag")
+
+	err = tv.FromTaggedCBOR(append(ComidTag, testGoodUnsignedCorimCBOR...))
+	assert.EqualError(t, err, "expecting CBOR tag 501, got 506")
+}
+
+func TestUnsignedCorim_JSON_roundtrip(t *testing.T) {
+	c := comid.Comid{}
+	require.NoError(t, c.FromJSON([]byte(comid.PSARefValJSONTemplate)))
+
+	s := cots.ConciseTaStore{}
+	require.NoError(t, s.FromJSON([]byte(cots.ConciseTaStoreTemplateSingleOrg)))
+
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddComid(c).
+		AddCots(s)
+	require.NotNil(t, tv)
+
+	data, err := tv.ToJSON()
+	require.NoError(t, err)
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromJSON(data))
+	require.Len(t, actual.Tags, 2)
+
+	for i := range tv.Tags {
+		assertCBOREq(t, tv.Tags[i], actual.Tags[i])
+	}
+}
+
+func TestTag_UnmarshalJSON(t *testing.T) {
+	var tv Tag
+
+	// base64-encoded CBOR is accepted as is
+	require.NoError(t, tv.UnmarshalJSON([]byte(`"2QH6oA=="`)))
+	assert.Equal(t, Tag{0xd9, 0x01, 0xfa, 0xa0}, tv)
+
+	err := tv.UnmarshalJSON([]byte(`{"type":"foo","value":{}}`))
+	assert.EqualError(t, err, `unknown tag type "foo"`)
+
+	err = tv.UnmarshalJSON([]byte(`{"type":"comid","value":{}}`))
+	assert.ErrorContains(t, err, "decoding CoMID: ")
+}
+
+func TestTag_MarshalJSON_unknown(t *testing.T) {
+	tv := Tag{0xd9, 0x01, 0xf8, 0xa0}
+
+	actual, err := tv.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `"2QH4oA=="`, string(actual))
+}
+
+func TestNormalizeProfile(t *testing.T) {
+	for _, tc := range []struct {
+		profile  string
+		expected string
+	}{
+		{"HTTPS://Arm.COM/psa/iot/2.0.0", "https://arm.com/psa/iot/2.0.0"},
+		{"https://arm.com", "https://arm.com/"},
+		{"tag:arm.com,2023:cca_platform#1.0.0", "tag:arm.com,2023:cca_platform#1.0.0"},
+		{"2.5.2.8192", "2.5.2.8192"},
+	} {
+		p, err := eat.NewProfile(tc.profile)
+		require.NoError(t, err)
+
+		actual, err := NormalizeProfile(*p)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, actual)
+	}
+
+	_, err := NormalizeProfile(eat.Profile{})
+	assert.EqualError(t, err, "profile should be OID or URI")
+}
+
+func TestCanonicalizeProfiles(t *testing.T) {
+	mustProfile := func(s string) eat.Profile {
+		p, err := eat.NewProfile(s)
+		require.NoError(t, err)
+		return *p
+	}
+
+	table := map[string]string{
+		"http://arm.com/psa/2.0.0":            "2.16.840.1.113741.1.2.3",
+		"tag:arm.com,2023:cca_platform#1.0.0": "1.3.6.1.4.1.4128.2.1",
+		"http://example.com/bad":              "not an OID",
+	}
+
+	in := []eat.Profile{
+		mustProfile("HTTP://ARM.com/psa/2.0.0"),
+		mustProfile("tag:arm.com,2023:cca_platform#1.0.0"),
+		mustProfile("http://example.com/unknown"),
+		mustProfile("http://example.com/bad"),
+		mustProfile("1.2.3.4"),
+	}
+
+	expected := []eat.Profile{
+		mustProfile("2.16.840.1.113741.1.2.3"),
+		mustProfile("1.3.6.1.4.1.4128.2.1"),
+		mustProfile("http://example.com/unknown"),
+		mustProfile("http://example.com/bad"),
+		mustProfile("1.2.3.4"),
+	}
+
+	assert.Equal(t, expected, CanonicalizeProfiles(in, table))
+
+	// the input is left untouched
+	assert.True(t, in[0].IsURI())
+
+	assert.Nil(t, CanonicalizeProfiles(nil, table))
+	assert.Equal(t, in, CanonicalizeProfiles(in, nil))
+}
+
+func TestUnsignedCorim_RawExtensions_roundtrip(t *testing.T) {
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(testGoodUnsignedCorimCBOR))
+	assert.Nil(t, tv.RawExtensions)
+
+	require.NotNil(t, tv.SetExtension(-1, "foo"))
+	require.NotNil(t, tv.SetExtension(-70000, []int{1, 2, 3}))
+	require.NotNil(t, tv.SetExtension(10, true))
+	assert.Nil(t, tv.SetExtension(3, "collides with profile"))
+	assert.Nil(t, tv.SetExtension(0, "collides with corim-id"))
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	assert.Equal(t, tv.RawExtensions, actual.RawExtensions)
+
+	var s string
+	require.NoError(t, actual.GetExtension(-1, &s))
+	assert.Equal(t, "foo", s)
+
+	var a []int
+	require.NoError(t, actual.GetExtension(-70000, &a))
+	assert.Equal(t, []int{1, 2, 3}, a)
+
+	err = actual.GetExtension(-2, &s)
+	assert.ErrorIs(t, err, extensions.ErrExtensionNotFound)
+
+	// an encode/decode round-trip preserves the unknown entries
+	again, err := actual.ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestUnsignedCorim_unknown_keys_relay(t *testing.T) {
+	// a CoRIM from a future spec version, with an extra top-level entry
+	// (6: {1: "v2"}) this implementation knows nothing about
+	require.Equal(t, byte(0xa2), testGoodUnsignedCorimCBOR[0])
+	data := append([]byte{0xa3}, testGoodUnsignedCorimCBOR[1:]...)
+	data = append(data, 0x06, 0xa1, 0x01, 0x62, 'v', '2')
+
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(data))
+	require.NoError(t, tv.Valid())
+	assert.Equal(t, map[int64]cbor.RawMessage{6: {0xa1, 0x01, 0x62, 'v', '2'}}, tv.RawExtensions)
+
+	relayed, err := tv.ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, data, relayed)
+
+	relayed, err = tv.Clone().ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, data, relayed)
+}
+
+func TestUnsignedCorim_ValidWithOptions_strict_tags(t *testing.T) {
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	opts := ValidationOptions{StrictTags: true}
+
+	assert.NoError(t, tv.ValidWithOptions(opts))
+
+	// a corrupt CoSWID passes lenient validation, but not strict validation
+	tv.Tags = append(tv.Tags, append(CoswidTag, 0xa1, 0x00, 0x00))
+
+	assert.NoError(t, tv.Valid())
+	assert.ErrorContains(t, tv.ValidWithOptions(opts), "tag validation failed at pos 1: decoding CoSWID: ")
+
+	// so does an opaque tag
+	tv.Tags[1] = Tag{0xd9, 0x01, 0xf8, 0xa0}
+
+	assert.NoError(t, tv.Valid())
+	assert.EqualError(t, tv.ValidWithOptions(opts), "tag validation failed at pos 1: unknown tag number 504")
+}
+
+func TestTag_ValidStrict(t *testing.T) {
+	assert.EqualError(t, Tag{}.ValidStrict(), "empty tag")
+
+	tv := Tag(append(ComidTag, 0xa0))
+	assert.ErrorContains(t, tv.ValidStrict(), "decoding CoMID: ")
+
+	s := cots.ConciseTaStore{}
+	require.NoError(t, s.FromJSON([]byte(cots.ConciseTaStoreTemplateSingleOrg)))
+
+	c := NewUnsignedCorim().AddCots(s)
+	require.NotNil(t, c)
+	assert.NoError(t, c.Tags[0].ValidStrict())
+}
+
+func TestTag_ValidStrict_payload_mismatch(t *testing.T) {
+	var tv UnsignedCorim
+	require.NoError(t, tv.FromCBOR(testGoodUnsignedCorimCBOR))
+
+	comidPayload := tv.Tags[0][len(ComidTag):]
+
+	// a CoMID under the CoSWID tag number
+	err := Tag(append(CoswidTag, comidPayload...)).ValidStrict()
+	assert.ErrorIs(t, err, ErrTagPayloadMismatch)
+	assert.EqualError(t, err, "payload does not match tag number 505")
+
+	// a CoSWID under the CoMID tag number
+	var sw swid.SoftwareIdentity
+	require.NoError(t, sw.FromXML([]byte(`<SoftwareIdentity xmlns="http://standards.iso.org/iso/19770/-2/2015/schema.xsd" tagId="com.acme.rrd2013-ce-sp1-v4-1-5-0" name="ACME Roadrunner Detector 2013 Coyote Edition SP1" version="4.1.5"><Entity name="The ACME Corporation" regid="acme.com" role="tagCreator softwareCreator"></Entity></SoftwareIdentity>`)))
+	coswidPayload, err := sw.ToCBOR()
+	require.NoError(t, err)
+
+	require.NoError(t, Tag(append(CoswidTag, coswidPayload...)).ValidStrict())
+
+	err = Tag(append(ComidTag, coswidPayload...)).ValidStrict()
+	assert.EqualError(t, err, "payload does not match tag number 506")
+
+	err = Tag(append(cots.CotsTag, coswidPayload...)).ValidStrict()
+	assert.EqualError(t, err, "payload does not match tag number 507")
+
+	// the position is reported by strict validation
+	tv.Tags = append(tv.Tags, append(ComidTag, coswidPayload...))
+	assert.EqualError(t, tv.ValidWithOptions(ValidationOptions{StrictTags: true}),
+		"tag validation failed at pos 1: payload does not match tag number 506")
+}
+
+func TestUnsignedCorim_SetTagID_GetTagID(t *testing.T) {
+	id := swid.NewTagID("43bbe37f-2e61-4b33-aed3-53cff1428b16")
+	require.NotNil(t, id)
+
+	tv := NewUnsignedCorim().SetTagID(*id)
+	require.NotNil(t, tv)
+
+	assert.Equal(t, *id, tv.GetTagID())
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", tv.GetID())
+
+	var nilCorim *UnsignedCorim
+	assert.Nil(t, nilCorim.SetTagID(*id))
+}
+
+func TestLocator_ThumbprintMatches(t *testing.T) {
+	value := comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75")
+
+	tv := Locator{
+		Href:       "https://example.com/rim.cbor",
+		Thumbprint: &swid.HashEntry{HashAlgID: swid.Sha256, HashValue: value},
+	}
+
+	assert.True(t, tv.ThumbprintMatches(swid.Sha256, value))
+	assert.False(t, tv.ThumbprintMatches(swid.Sha384, value))
+	assert.False(t, tv.ThumbprintMatches(swid.Sha256, value[:16]))
+	assert.False(t, tv.ThumbprintMatches(swid.Sha256, make([]byte, 32)))
+
+	tv.Thumbprint = nil
+	assert.False(t, tv.ThumbprintMatches(swid.Sha256, value))
+}
+
+func TestUnsignedCorim_ToEDN(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	actual, err := tv.ToEDN()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(actual,
+		`{0: "test corim id", 1: [<<506({0: "en-GB", 1: {0: h'43bbe37f2e614b33aed353cff1428b16'}, `),
+		actual)
+	assert.True(t, strings.HasSuffix(actual, `})>>]}`), actual)
+
+	tv = NewUnsignedCorim().SetID("test corim id").
+		AddRawTag(CoswidTagNumber, []byte{0xa0}).
+		AddDependentRim("https://example.com/rim.cbor", nil)
+	require.NotNil(t, tv)
+
+	actual, err = tv.ToEDN()
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{0: "test corim id", 1: [<<505({})>>], 2: [{0: 32("https://example.com/rim.cbor")}]}`,
+		actual)
+}
+
+func TestLocator_multiple_thumbprints(t *testing.T) {
+	sha256Value := comid.MustHexDecode(t, "e45b72f5c0c0b572db4d8d3ab7e97f368ff74e62347a824decb67a84e5224d75")
+	sha384Value := make([]byte, 48)
+
+	tv := NewUnsignedCorim().
+		SetID("test corim id").
+		AddRawTag(CoswidTagNumber, []byte{0xa0}).
+		AddDependentRim("https://example.com/rim.cbor",
+			&swid.HashEntry{HashAlgID: swid.Sha256, HashValue: sha256Value},
+			nil,
+			&swid.HashEntry{HashAlgID: swid.Sha384, HashValue: sha384Value},
+		)
+	require.NotNil(t, tv)
+	require.NoError(t, tv.Valid())
+
+	l := (*tv.DependentRims)[0]
+	assert.Len(t, l.Thumbprints(), 2)
+	assert.True(t, l.ThumbprintMatches(swid.Sha256, sha256Value))
+	assert.True(t, l.ThumbprintMatches(swid.Sha384, sha384Value))
+	assert.False(t, l.ThumbprintMatches(swid.Sha512, sha384Value))
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	// dependent-rims: [{0: 32("https://example.com/rim.cbor"), 1: [[1, h'...'], [7, h'...']]}]
+	assert.Contains(t, fmt.Sprintf("%x", data), "01828201"+"5820"+fmt.Sprintf("%x", sha256Value)+"8207"+"5830")
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.FromCBOR(data))
+	assert.Equal(t, tv.DependentRims, actual.DependentRims)
+
+	j, err := json.Marshal(l)
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"href":"https://example.com/rim.cbor","thumbprint":["sha-256;5Fty9cDAtXLbTY06t+l/No/3TmI0eoJN7LZ6hOUiTXU=","sha-384;AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"]}`,
+		string(j))
+
+	var fromJSON Locator
+	require.NoError(t, json.Unmarshal(j, &fromJSON))
+	assert.Equal(t, l, fromJSON)
+
+	l.AltThumbprints[0].HashAlgID = swid.Sha256
+	l.AltThumbprints[0].HashValue = sha256Value
+	assert.EqualError(t, l.Valid(), "duplicate locator thumbprint algorithm 1 at pos 1")
+
+	l.Thumbprint = nil
+	assert.EqualError(t, l.Valid(), "alternative thumbprints set without a thumbprint")
+}
+
+func TestLocator_single_thumbprint_JSON(t *testing.T) {
+	j := `{"href":"https://example.com/rim.cbor","thumbprint":"sha-256;5Fty9cDAtXLbTY06t+l/No/3TmI0eoJN7LZ6hOUiTXU="}`
+
+	var l Locator
+	require.NoError(t, json.Unmarshal([]byte(j), &l))
+	require.NotNil(t, l.Thumbprint)
+	assert.Nil(t, l.AltThumbprints)
+
+	actual, err := json.Marshal(l)
+	require.NoError(t, err)
+	assert.JSONEq(t, j, string(actual))
+}
+
+func TestUnsignedCorim_FromBytes(t *testing.T) {
+	orig := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	tagged, err := orig.ToTaggedCBOR()
+	require.NoError(t, err)
+
+	j, err := orig.ToJSON()
+	require.NoError(t, err)
+
+	tvs := map[string][]byte{
+		"CBOR":              testGoodUnsignedCorimCBOR,
+		"tagged CBOR":       tagged,
+		"JSON":              j,
+		"JSON with BOM":     append([]byte("\xef\xbb\xbf"), j...),
+		"JSON with spacing": append([]byte(" \r\n\t"), j...),
+	}
+
+	for name, data := range tvs {
+		t.Run(name, func(t *testing.T) {
+			var actual UnsignedCorim
+			require.NoError(t, actual.FromBytes(data))
+			assert.Equal(t, orig.ID, actual.ID)
+			assert.Len(t, actual.Tags, len(orig.Tags))
+		})
+	}
+}
+
+func TestUnsignedCorim_FromBytes_NOK(t *testing.T) {
+	var tv UnsignedCorim
+
+	assert.EqualError(t, tv.FromBytes(nil), "empty input")
+	assert.EqualError(t, tv.FromBytes([]byte("[]")),
+		"unable to detect format: input is neither a CBOR map nor a JSON object")
+	assert.EqualError(t, tv.FromBytes([]byte(" \n")),
+		"unable to detect format: input is neither a CBOR map nor a JSON object")
+	assert.ErrorContains(t, tv.FromBytes([]byte(`{"corim-id": 1}`)), "error unmarshaling tag-id")
+}
+
+func TestUnsignedCorim_MergeTags(t *testing.T) {
+	from := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	keys := comidFromJSON(t, comid.PSAKeysJSONTemplate)
+	tv := NewUnsignedCorim().SetID("merged").AddComid(keys)
+	require.NotNil(t, tv)
+
+	require.NotNil(t, tv.MergeTags(*from))
+	require.Len(t, tv.Tags, 2)
+	assert.Equal(t, from.Tags[0], tv.Tags[1])
+
+	// merged tags do not alias those of the source
+	tv.Tags[1][len(tv.Tags[1])-1] ^= 0xff
+	assert.NotEqual(t, from.Tags[0], tv.Tags[1])
+
+	bad := UnsignedCorim{Tags: []Tag{from.Tags[0], {}}}
+	assert.EqualError(t, tv.MergeTagsErr(bad), "tag validation failed at pos 1: empty tag")
+	assert.Len(t, tv.Tags, 2)
+	assert.Nil(t, tv.MergeTags(bad))
+
+	var nilCorim *UnsignedCorim
+	assert.EqualError(t, nilCorim.MergeTagsErr(*from), "nil UnsignedCorim")
+}
+
+func TestUnsignedCorim_EncodeCBOR_DecodeCBOR(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	tv.RawExtensions = map[int64]cbor.RawMessage{99: {0xf5}}
+
+	expected, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tv.EncodeCBOR(&buf))
+	assert.Equal(t, expected, buf.Bytes())
+
+	var actual UnsignedCorim
+	require.NoError(t, actual.DecodeCBOR(&buf))
+	assert.Equal(t, "test corim id", actual.GetID())
+	assert.Equal(t, tv.Tags, actual.Tags)
+	assert.Equal(t, tv.RawExtensions, actual.RawExtensions)
+
+	assert.Error(t, actual.DecodeCBOR(bytes.NewReader(nil)))
+	assert.Error(t, actual.DecodeCBOR(bytes.NewReader([]byte{0xa1, 0x00})))
+}
+
+func TestUnsignedCorim_FromCBORWithOptions(t *testing.T) {
+	var tv UnsignedCorim
+
+	require.NoError(t, tv.FromCBORWithOptions(testGoodUnsignedCorimCBOR, DecodeOptions{}))
+	assert.Equal(t, "test corim id", tv.GetID())
+
+	err := tv.FromCBORWithOptions(testGoodUnsignedCorimCBOR, DecodeOptions{MaxSize: 100})
+	assert.EqualError(t, err, fmt.Sprintf(
+		"input size %d exceeds the maximum of 100 bytes", len(testGoodUnsignedCorimCBOR)))
+
+	two := NewUnsignedCorim().SetID("test corim id").
+		AddRawTag(ComidTagNumber, []byte{0xa0}).
+		AddRawTag(ComidTagNumber, []byte{0xa0})
+	require.NotNil(t, two)
+
+	data, err := two.ToCBOR()
+	require.NoError(t, err)
+
+	err = tv.FromCBORWithOptions(data, DecodeOptions{MaxTags: 1})
+	assert.EqualError(t, err, "number of tags 2 exceeds the maximum of 1")
+
+	// the target is left unchanged on failure
+	assert.Equal(t, "test corim id", tv.GetID())
+	assert.Len(t, tv.Tags, 1)
+
+	// the number of tags is checked before they are decoded
+	bad := []byte{
+		0xa2,             // map(2)
+		0x00, 0x61, 0x78, // 0: "x"
+		0x01, 0x83, 0x01, 0x02, 0x03, // 1: [1, 2, 3]
+	}
+
+	err = tv.FromCBORWithOptions(bad, DecodeOptions{MaxTags: 2})
+	assert.EqualError(t, err, "number of tags 3 exceeds the maximum of 2")
+
+	err = tv.FromCBORWithOptions(bad, DecodeOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, "test corim id", tv.GetID())
+
+	indefinite, err := two.ToCBORWithOptions(EncodeOptions{IndefiniteLengthTags: true})
+	require.NoError(t, err)
+
+	err = tv.FromCBORWithOptions(indefinite, DecodeOptions{MaxTags: 1})
+	assert.EqualError(t, err, "number of tags 2 exceeds the maximum of 1")
+
+	require.NoError(t, tv.FromCBORWithOptions(indefinite, DecodeOptions{MaxTags: 2}))
+	assert.Len(t, tv.Tags, 2)
+
+	// registered extensions are populated
+	type corimExtensions struct {
+		Extension1 *string `cbor:"-1,keyasint,omitempty" json:"ext1,omitempty"`
+	}
+
+	var ext UnsignedCorim
+	require.NoError(t, ext.RegisterExtensions(
+		extensions.NewMap().Add(ExtUnsignedCorim, &corimExtensions{}),
+	))
+	require.NoError(t, ext.FromCBORWithOptions(testUnsignedCorimWithExtensionsCBOR, DecodeOptions{}))
+	assert.Equal(t, "foo", ext.Extensions.MustGetString("Extension1"))
+
+	// the dependent-rims array exceeds the element limit
+	many := NewUnsignedCorim().SetID("test corim id").AddRawTag(ComidTagNumber, []byte{0xa0})
+	for i := 0; i < 17; i++ {
+		many.AddDependentRim(fmt.Sprintf("https://example.com/%d", i))
+	}
+
+	data, err = many.ToCBOR()
+	require.NoError(t, err)
+
+	err = tv.FromCBORWithOptions(data, DecodeOptions{MaxArrayElements: 16})
+	assert.ErrorContains(t, err, "exceeded max number of elements 16 for CBOR array")
+
+	err = tv.FromCBORWithOptions(data, DecodeOptions{MaxNestedLevels: 1})
+	assert.ErrorContains(t, err, "invalid decode options: ")
+}
+
+func TestUnsignedCorim_Valid_sentinel_errors(t *testing.T) {
+	tv := UnsignedCorim{}
+	assert.ErrorIs(t, tv.Valid(), ErrEmptyID)
+
+	tv.SetID("test corim id")
+	assert.ErrorIs(t, tv.Valid(), ErrNoTags)
+
+	tv.Tags = []Tag{append(bytes.Clone(ComidTag), 0xa0), {}}
+	err := tv.Valid()
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, 1, ve.Pos)
+	assert.EqualError(t, ve.Err, "empty tag")
+
+	tv.Tags = tv.Tags[:1]
+	tv.AddDependentRim("https://example.com/rim.cbor").AddDependentRim("")
+	err = tv.Valid()
+	assert.ErrorIs(t, err, ErrInvalidDependentRim)
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, 1, ve.Pos)
+	assert.EqualError(t, err, "dependent RIM validation failed at pos 1: empty href")
+
+	tv.DependentRims = nil
+	tv.Profile = &eat.Profile{}
+	assert.ErrorIs(t, tv.Valid(), ErrInvalidProfile)
+}
+
+func TestUnsignedCorim_CollectReferenceValues(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	// CoSWIDs and CoMIDs without reference values do not contribute
+	require.NotNil(t, tv.AddRawTag(CoswidTagNumber, []byte{0xa0}))
+	require.NotNil(t, tv.AddComid(comidFromJSON(t, comid.PSAKeysJSONTemplate)))
+
+	actual, err := tv.CollectReferenceValues()
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+
+	assert.Equal(t, "43bbe37f-2e61-4b33-aed3-53cff1428b16", actual[0].ComidTagID.String())
+	assert.Len(t, actual[0].Triple.Measurements.Values, 3)
+
+	tv.Tags = append(tv.Tags, append(bytes.Clone(ComidTag), 0xa0))
+	_, err = tv.CollectReferenceValues()
+	assert.ErrorContains(t, err, "decoding CoMID at pos 3: ")
+}
+
+func TestUnsignedCorim_HasProfile(t *testing.T) {
+	tv := NewUnsignedCorim()
+	assert.False(t, tv.HasProfile("https://arm.com/psa/iot/1"))
+
+	require.NotNil(t, tv.SetProfile("HTTPS://Arm.com/psa/iot/1/"))
+	assert.True(t, tv.HasProfile("https://arm.com/psa/iot/1"))
+	assert.True(t, tv.HasProfile("https://ARM.COM/psa/iot/1/"))
+	assert.False(t, tv.HasProfile("https://arm.com/PSA/iot/1"))
+	assert.False(t, tv.HasProfile("https://arm.com/psa/iot/2"))
+	assert.False(t, tv.HasProfile("%%%"))
+
+	require.NotNil(t, tv.SetProfile("2.5.2.8192"))
+	assert.True(t, tv.HasProfile("2.5.2.8192"))
+	assert.False(t, tv.HasProfile("2.5.2.8193"))
+
+	// the same OID decoded from its CBOR encoding
+	p, err := eat.NewProfile("2.5.2.8192")
+	require.NoError(t, err)
+	data, err := p.MarshalCBOR()
+	require.NoError(t, err)
+
+	var decoded eat.Profile
+	require.NoError(t, decoded.UnmarshalCBOR(data))
+	tv.Profile = &decoded
+	assert.True(t, tv.HasProfile("2.5.2.8192"))
+}
+
+func TestUnsignedCorim_GetProfiles(t *testing.T) {
+	tv := NewUnsignedCorim()
+	assert.Equal(t, []string{}, tv.GetProfiles())
+
+	require.NotNil(t, tv.SetProfile("HTTPS://Arm.com/psa/iot/1"))
+	assert.Equal(t, []string{"https://arm.com/psa/iot/1"}, tv.GetProfiles())
+
+	require.NotNil(t, tv.SetProfile("2.5.2.8192"))
+	assert.Equal(t, []string{"2.5.2.8192"}, tv.GetProfiles())
+
+	tv.Profile = &eat.Profile{}
+	assert.Equal(t, []string{}, tv.GetProfiles())
+}
+
+func TestUnsignedCorim_FilterTags(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).
+		AddRawTag(CoswidTagNumber, []byte{0xa0}).
+		AddRawTag(CotsTagNumber, []byte{0xa0})
+	require.NotNil(t, tv)
+	require.Len(t, tv.Tags, 3)
+
+	var seen []uint64
+	require.NotNil(t, tv.FilterTags(func(tagNumber uint64, payload []byte) bool {
+		seen = append(seen, tagNumber)
+		return tagNumber != CotsTagNumber
+	}))
+	assert.Equal(t, []uint64{ComidTagNumber, CoswidTagNumber, CotsTagNumber}, seen)
+	assert.Len(t, tv.Tags, 2)
+
+	require.NotNil(t, tv.KeepOnlyComids())
+	require.Len(t, tv.Tags, 1)
+	assert.Equal(t, unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).Tags[0], tv.Tags[0])
+	assert.NoError(t, tv.Valid())
+
+	require.NotNil(t, tv.FilterTags(func(uint64, []byte) bool { return false }))
+	assert.ErrorIs(t, tv.Valid(), ErrNoTags)
+
+	tv.Tags = []Tag{{0x01}}
+	assert.Nil(t, tv.KeepOnlyComids())
+	assert.Len(t, tv.Tags, 1)
+}
+
+func TestNewTestCorim(t *testing.T) {
+	tv := NewTestCorim()
+	require.NoError(t, tv.Valid())
+	assert.Equal(t, TestCorimID.String(), tv.GetID())
+
+	comids, err := tv.GetComids()
+	require.NoError(t, err)
+	require.Len(t, comids, 1)
+	assert.Equal(t, TestComidID.String(), comids[0].TagIdentity.TagID.String())
+
+	data, err := tv.ToCBOR()
+	require.NoError(t, err)
+
+	again, err := NewTestCorim().ToCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestUnsignedCorim_ValidWithOptions_thumbprint_algorithms(t *testing.T) {
+	tv := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR).
+		AddDependentRim("https://example.com/a",
+			&swid.HashEntry{HashAlgID: swid.Sha256, HashValue: make([]byte, 32)}).
+		AddDependentRim("https://example.com/b",
+			&swid.HashEntry{HashAlgID: swid.Sha384, HashValue: make([]byte, 48)},
+			&swid.HashEntry{HashAlgID: swid.Sha256_64, HashValue: make([]byte, 8)}).
+		AddDependentRim("https://example.com/c", nil)
+	require.NotNil(t, tv)
+
+	// permissive by default
+	assert.NoError(t, tv.Valid())
+
+	err := tv.ValidWithOptions(ValidationOptions{
+		AllowedThumbprintAlgorithms: StrongThumbprintAlgorithms,
+	})
+	assert.ErrorIs(t, err, ErrInvalidDependentRim)
+	assert.EqualError(t, err,
+		"dependent RIM validation failed at pos 1: thumbprint algorithm 5 is not allowed")
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, 1, verr.Pos)
+
+	assert.NoError(t, tv.ValidWithOptions(ValidationOptions{
+		AllowedThumbprintAlgorithms: []uint64{swid.Sha256, swid.Sha384, swid.Sha256_64},
+	}))
+}
+
+func TestUnsignedCorim_Validate(t *testing.T) {
+	assert.Empty(t, NewTestCorim().Validate())
+
+	tv := NewUnsignedCorim().
+		AddDependentRim("https://example.com/a", nil).
+		AddDependentRim("not a URI", nil)
+	require.NotNil(t, tv)
+	tv.Tags = []Tag{{}}
+	tv.Profile = &eat.Profile{}
+
+	problems := tv.Validate()
+	require.Len(t, problems, 4)
+
+	assert.Equal(t, "corim-id", problems[0].Path)
+	assert.Equal(t, CodeEmptyID, problems[0].Code)
+	assert.Equal(t, ErrEmptyID, problems[0].Kind)
+
+	assert.Equal(t, "tags[0]", problems[1].Path)
+	assert.Equal(t, CodeInvalidTag, problems[1].Code)
+	assert.Equal(t, ErrInvalidTag, problems[1].Kind)
+	assert.Equal(t, 0, problems[1].Pos)
+
+	assert.Equal(t, "dependent-rims[1]", problems[2].Path)
+	assert.Equal(t, CodeInvalidDependentRim, problems[2].Code)
+	assert.Equal(t, 1, problems[2].Pos)
+	assert.ErrorIs(t, &problems[2], ErrInvalidDependentRim)
+
+	assert.Equal(t, "profile", problems[3].Path)
+	assert.Equal(t, CodeInvalidProfile, problems[3].Code)
+	assert.EqualError(t, &problems[3],
+		"profile: profile validation failed: profile should be OID or URI")
+
+	// Valid reports the first problem
+	assert.EqualError(t, tv.Valid(), problems[0].Err.Error())
+}
+
+func FuzzFromCBOR(f *testing.F) {
+	f.Add(testGoodUnsignedCorimCBOR)
+	f.Add(testUnsignedCorimWithExtensionsCBOR)
+	f.Add([]byte{0xa0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var tv UnsignedCorim
+		if err := tv.FromCBOR(data); err != nil {
+			return
+		}
+
+		// a successfully decoded CoRIM must not make any of these panic
+		_ = tv.Valid()
+		_, _ = tv.ToCBOR()
+		_, _ = tv.GetComids()
+		_, _ = tv.GetCoswids()
+		_, _ = tv.ToJSON()
+	})
+}
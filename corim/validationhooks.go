@@ -0,0 +1,78 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"context"
+	"time"
+)
+
+// ValidationPhase identifies a group of checks performed when validating an
+// unsigned CoRIM
+type ValidationPhase string
+
+const (
+	// ValidationPhaseCorim covers the checks on the corim-id, the presence
+	// of tags, the RIM validity, the entities and the extensions
+	ValidationPhaseCorim ValidationPhase = "corim"
+	// ValidationPhaseTags covers the checks on the individual tags
+	ValidationPhaseTags ValidationPhase = "tags"
+	// ValidationPhaseDependentRims covers the checks on the dependent RIMs
+	ValidationPhaseDependentRims ValidationPhase = "dependent-rims"
+	// ValidationPhaseProfile covers the checks on the profile, including
+	// those registered with RegisterProfileValidator
+	ValidationPhaseProfile ValidationPhase = "profile"
+)
+
+// ValidationHooks receives notifications about the progress of
+// ValidateWithContext.  Either callback can be left nil.
+type ValidationHooks struct {
+	// OnPhase is called at the end of each validation phase with the time
+	// the phase took
+	OnPhase func(ctx context.Context, phase ValidationPhase, elapsed time.Duration)
+	// OnFailure is called for each problem found during a validation
+	// phase, including the ones following the first (which is the one
+	// returned by ValidateWithContext)
+	OnFailure func(ctx context.Context, phase ValidationPhase, err error)
+}
+
+// ValidateWithContext performs the same checks as Valid, reporting the time
+// taken by each validation phase and the problems found to the supplied hooks.
+// The supplied context is passed to the hooks, and validation stops with the
+// context's error if it is done before a phase starts.  When no hooks are set,
+// this is equivalent to Valid.
+func (o UnsignedCorim) ValidateWithContext(ctx context.Context, hooks ValidationHooks) error {
+	if hooks.OnPhase == nil && hooks.OnFailure == nil {
+		return o.Valid()
+	}
+
+	var errs []error
+
+	for _, p := range validationPhases {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		n := len(errs)
+
+		errs = p.check(o, ValidationOptions{}, errs)
+
+		if hooks.OnPhase != nil {
+			hooks.OnPhase(ctx, p.phase, time.Since(start))
+		}
+
+		if hooks.OnFailure != nil {
+			for _, err := range errs[n:] {
+				hooks.OnFailure(ctx, p.phase, err)
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return errs[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsignedCorim_ValidateWithContext_ok(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	var phases []ValidationPhase
+
+	err := c.ValidateWithContext(context.Background(), ValidationHooks{
+		OnPhase: func(_ context.Context, p ValidationPhase, elapsed time.Duration) {
+			assert.GreaterOrEqual(t, elapsed, time.Duration(0))
+			phases = append(phases, p)
+		},
+		OnFailure: func(_ context.Context, p ValidationPhase, err error) {
+			assert.Fail(t, "unexpected failure", "%s: %v", p, err)
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ValidationPhase{
+		ValidationPhaseCorim,
+		ValidationPhaseTags,
+		ValidationPhaseDependentRims,
+		ValidationPhaseProfile,
+	}, phases)
+}
+
+func TestUnsignedCorim_ValidateWithContext_failures(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	c.Tags = append(c.Tags, Tag{})
+	require.NotNil(t, c.AddDependentRim("not a URI", nil))
+
+	failures := map[ValidationPhase][]error{}
+
+	err := c.ValidateWithContext(context.Background(), ValidationHooks{
+		OnFailure: func(_ context.Context, p ValidationPhase, err error) {
+			failures[p] = append(failures[p], err)
+		},
+	})
+	assert.Equal(t, c.Valid(), err)
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	require.Len(t, failures[ValidationPhaseTags], 1)
+	require.Len(t, failures[ValidationPhaseDependentRims], 1)
+	assert.ErrorIs(t, failures[ValidationPhaseDependentRims][0], ErrInvalidDependentRim)
+	assert.Empty(t, failures[ValidationPhaseCorim])
+	assert.Empty(t, failures[ValidationPhaseProfile])
+}
+
+func TestUnsignedCorim_ValidateWithContext_no_hooks(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	assert.NoError(t, c.ValidateWithContext(context.Background(), ValidationHooks{}))
+
+	c.Tags = nil
+	assert.ErrorIs(t, c.ValidateWithContext(context.Background(), ValidationHooks{}), ErrNoTags)
+}
+
+func TestUnsignedCorim_ValidateWithContext_cancelled(t *testing.T) {
+	c := unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := c.ValidateWithContext(ctx, ValidationHooks{
+		OnPhase: func(_ context.Context, p ValidationPhase, _ time.Duration) {
+			if p == ValidationPhaseTags {
+				cancel()
+			}
+		},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
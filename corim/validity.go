@@ -4,10 +4,20 @@
 package corim
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+var (
+	// ErrExpired is returned by Validity.CheckTime when the supplied time is
+	// after not-after
+	ErrExpired = errors.New("validity period expired")
+	// ErrNotYetValid is returned by Validity.CheckTime when the supplied time
+	// is before not-before
+	ErrNotYetValid = errors.New("validity period not yet started")
+)
+
 type Validity struct {
 	NotBefore *time.Time `cbor:"0,keyasint,omitempty" json:"not-before,omitempty"`
 	NotAfter  time.Time  `cbor:"1,keyasint" json:"not-after"`
@@ -43,3 +53,16 @@ func (o Validity) Valid() error {
 	}
 	return nil
 }
+
+// CheckTime checks that the supplied time falls within the validity period
+func (o Validity) CheckTime(now time.Time) error {
+	if o.NotBefore != nil && now.Before(*o.NotBefore) {
+		return fmt.Errorf("%w: not-before is %s", ErrNotYetValid, o.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	if now.After(o.NotAfter) {
+		return fmt.Errorf("%w: not-after is %s", ErrExpired, o.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
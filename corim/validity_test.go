@@ -0,0 +1,37 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidity_CheckTime(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	v := NewValidity().Set(notAfter, &notBefore)
+	require.NotNil(t, v)
+
+	assert.NoError(t, v.CheckTime(notBefore))
+	assert.NoError(t, v.CheckTime(notAfter))
+	assert.NoError(t, v.CheckTime(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	err := v.CheckTime(notBefore.Add(-time.Second))
+	assert.ErrorIs(t, err, ErrNotYetValid)
+	assert.EqualError(t, err, "validity period not yet started: not-before is 2024-01-01T00:00:00Z")
+
+	err = v.CheckTime(notAfter.Add(time.Second))
+	assert.ErrorIs(t, err, ErrExpired)
+	assert.EqualError(t, err, "validity period expired: not-after is 2025-01-01T00:00:00Z")
+
+	// no lower bound
+	v = NewValidity().Set(notAfter, nil)
+	require.NotNil(t, v)
+	assert.NoError(t, v.CheckTime(time.Time{}))
+}
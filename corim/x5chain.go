@@ -0,0 +1,134 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	cose "github.com/veraison/go-cose"
+)
+
+// SignWithCerts is like Sign, but also embeds the supplied certificate chain
+// in the x5chain parameter (RFC 9360) of the protected header, so that the
+// signed-corim can be verified without out-of-band key distribution.  The
+// chain is a list of DER-encoded X.509 certificates, starting with the
+// certificate of the signing key.
+func (o *SignedCorim) SignWithCerts(signer cose.Signer, chain [][]byte) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("empty certificate chain")
+	}
+
+	for i, der := range chain {
+		if _, err := x509.ParseCertificate(der); err != nil {
+			return nil, fmt.Errorf("invalid certificate at pos %d: %w", i, err)
+		}
+	}
+
+	if err := o.sign1(signer, chain); err != nil {
+		return nil, err
+	}
+
+	wrap, err := o.message.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("signed-corim marshaling failed: %w", err)
+	}
+
+	return wrap, nil
+}
+
+// CertChain returns the certificate chain carried in the x5chain parameter of
+// the protected header of the decoded signed-corim, or nil if there is none
+func (o SignedCorim) CertChain() ([]*x509.Certificate, error) {
+	if o.message == nil {
+		return nil, errors.New("no Sign1 message found")
+	}
+
+	v, ok := o.message.Headers.Protected[cose.HeaderLabelX5Chain]
+	if !ok {
+		return nil, nil
+	}
+
+	var ders [][]byte
+
+	switch t := v.(type) {
+	case []byte:
+		ders = [][]byte{t}
+	case []interface{}:
+		for i, e := range t {
+			der, ok := e.([]byte)
+			if !ok {
+				return nil, fmt.Errorf("x5chain: expecting a byte string at pos %d, got %T", i, e)
+			}
+			ders = append(ders, der)
+		}
+	default:
+		return nil, fmt.Errorf("x5chain: unexpected type %T", v)
+	}
+
+	if len(ders) == 0 {
+		return nil, errors.New("x5chain: empty certificate chain")
+	}
+
+	chain := make([]*x509.Certificate, 0, len(ders))
+
+	for i, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("x5chain: invalid certificate at pos %d: %w", i, err)
+		}
+		chain = append(chain, cert)
+	}
+
+	return chain, nil
+}
+
+// VerifyWithChain verifies the signature of the decoded signed-corim using the
+// public key of the first certificate in its x5chain (see CertChain), and
+// returns the chain.  If roots is not nil, the chain is also verified against
+// it, using the rest of the chain as intermediates.
+func (o *SignedCorim) VerifyWithChain(roots *x509.CertPool) ([]*x509.Certificate, error) {
+	chain, err := o.CertChain()
+	if err != nil {
+		return nil, err
+	}
+
+	if chain == nil {
+		return nil, errors.New("no x5chain in protected header")
+	}
+
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range chain[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := chain[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("certificate chain verification failed: %w", err)
+		}
+	}
+
+	if err := o.Verify(chain[0].PublicKey); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+// x5chainHeader returns the value of the x5chain header parameter for the
+// supplied chain: a single certificate is carried as a byte string, more
+// than one as an array of byte strings
+func x5chainHeader(chain [][]byte) interface{} {
+	if len(chain) == 1 {
+		return chain[0]
+	}
+
+	return chain
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package corim
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertChain returns a leaf certificate for pub issued by a freshly
+// generated self-signed CA, along with the CA certificate
+func testCertChain(t *testing.T, pub crypto.PublicKey) (leaf, ca *x509.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(time.Hour)
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	ca, err = x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test CoRIM Signer"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, pub, caKey)
+	require.NoError(t, err)
+
+	leaf, err = x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	return leaf, ca
+}
+
+func TestSignedCorim_SignWithCerts_VerifyWithChain(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	leaf, ca := testCertChain(t, pk)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	for _, chain := range [][][]byte{
+		{leaf.Raw},
+		{leaf.Raw, ca.Raw},
+	} {
+		var SignedCorimIn SignedCorim
+
+		SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+		SignedCorimIn.Meta = *metaGood(t)
+
+		cbor, err := SignedCorimIn.SignWithCerts(signer, chain)
+		require.NoError(t, err)
+
+		var SignedCorimOut SignedCorim
+
+		require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+
+		certs, err := SignedCorimOut.VerifyWithChain(nil)
+		require.NoError(t, err)
+		require.Len(t, certs, len(chain))
+		assert.Equal(t, leaf.Raw, certs[0].Raw)
+
+		_, err = SignedCorimOut.VerifyWithChain(roots)
+		assert.NoError(t, err)
+
+		// plain Verify still works with the leaf key
+		assert.NoError(t, SignedCorimOut.Verify(pk))
+	}
+}
+
+func TestSignedCorim_VerifyWithChain_fail(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	pk, err := NewPublicKeyFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	otherPK, err := NewPublicKeyFromJWK(testES384Key)
+	require.NoError(t, err)
+
+	leaf, _ := testCertChain(t, pk)
+	_, otherCA := testCertChain(t, pk)
+	wrongLeaf, _ := testCertChain(t, otherPK)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	// untrusted root
+	cbor, err := SignedCorimIn.SignWithCerts(signer, [][]byte{leaf.Raw})
+	require.NoError(t, err)
+
+	var SignedCorimOut SignedCorim
+	require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(otherCA)
+
+	_, err = SignedCorimOut.VerifyWithChain(roots)
+	assert.ErrorContains(t, err, "certificate chain verification failed")
+
+	// leaf key does not match the signing key
+	cbor, err = SignedCorimIn.SignWithCerts(signer, [][]byte{wrongLeaf.Raw})
+	require.NoError(t, err)
+	require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+
+	_, err = SignedCorimOut.VerifyWithChain(nil)
+	assert.Error(t, err)
+
+	// no chain
+	cbor, err = SignedCorimIn.Sign(signer)
+	require.NoError(t, err)
+	require.NoError(t, SignedCorimOut.FromCOSE(cbor))
+
+	_, err = SignedCorimOut.VerifyWithChain(nil)
+	assert.EqualError(t, err, "no x5chain in protected header")
+}
+
+func TestSignedCorim_SignWithCerts_fail(t *testing.T) {
+	signer, err := NewSignerFromJWK(testES256Key)
+	require.NoError(t, err)
+
+	var SignedCorimIn SignedCorim
+
+	SignedCorimIn.UnsignedCorim = *unsignedCorimFromCBOR(t, testGoodUnsignedCorimCBOR)
+	SignedCorimIn.Meta = *metaGood(t)
+
+	_, err = SignedCorimIn.SignWithCerts(signer, nil)
+	assert.EqualError(t, err, "empty certificate chain")
+
+	_, err = SignedCorimIn.SignWithCerts(signer, [][]byte{{0xde, 0xad}})
+	assert.ErrorContains(t, err, "invalid certificate at pos 0")
+}
@@ -11,6 +11,8 @@ import (
 )
 
 var (
+	deterministicEncoding bool
+
 	em, emError = initCBOREncMode()
 	dm, dmError = initCBORDecMode()
 )
@@ -45,6 +47,10 @@ func initCBOREncMode() (en cbor.EncMode, err error) {
 		IndefLength: cbor.IndefLengthForbidden,
 		TimeTag:     cbor.EncTagRequired,
 	}
+	if deterministicEncoding {
+		encOpt.Sort = cbor.SortCoreDeterministic
+		encOpt.ShortestFloat = cbor.ShortestFloat16
+	}
 	return encOpt.EncModeWithTags(cotsTags())
 }
 
@@ -64,3 +70,26 @@ func init() {
 		panic(dmError)
 	}
 }
+
+// SetDeterministicEncoding toggles the use of Core Deterministic Encoding (see
+// RFC 8949, §4.2.1) when serializing CoTS to CBOR.  When enabled, map keys are
+// sorted in bytewise lexicographic order of their encoding (Sort:
+// cbor.SortCoreDeterministic) and floating point values are encoded using the
+// shortest form that preserves their value (ShortestFloat:
+// cbor.ShortestFloat16).  Integers and lengths always use their shortest form,
+// and indefinite-length items are always forbidden.  On failure, the previous
+// setting is kept.
+func SetDeterministicEncoding(v bool) error {
+	prev := deterministicEncoding
+	deterministicEncoding = v
+
+	mode, err := initCBOREncMode()
+	if err != nil {
+		deterministicEncoding = prev
+		return err
+	}
+
+	em = mode
+
+	return nil
+}
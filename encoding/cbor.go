@@ -4,11 +4,14 @@
 package encoding
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -16,6 +19,51 @@ import (
 )
 
 func SerializeStructToCBOR(em cbor.EncMode, source any) ([]byte, error) {
+	return SerializeStructToCBORWithUnknown(em, source, nil)
+}
+
+// SerializeStructToCBORWithUnknown is like SerializeStructToCBOR, but also
+// serializes the supplied map entries, which do not correspond to any field of
+// source. The entries are added after the struct fields, in ascending key
+// order, unless em sorts map keys, in which case all the entries, struct fields
+// included, are written in the order required by em (e.g., bytewise
+// lexicographic order of the encoded keys with cbor.SortCoreDeterministic). A
+// key that clashes with one of the struct fields results in an error.
+func SerializeStructToCBORWithUnknown(
+	em cbor.EncMode,
+	source any,
+	unknown map[int]cbor.RawMessage,
+) ([]byte, error) {
+	rawMap, err := structToRawMap(em, source, unknown)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawMap.ToCBOR(em)
+}
+
+// EncodeStructToCBORWithUnknown is like SerializeStructToCBORWithUnknown, but
+// writes the serialized map to w one entry at a time, rather than assembling
+// it in memory.
+func EncodeStructToCBORWithUnknown(
+	em cbor.EncMode,
+	w io.Writer,
+	source any,
+	unknown map[int]cbor.RawMessage,
+) error {
+	rawMap, err := structToRawMap(em, source, unknown)
+	if err != nil {
+		return err
+	}
+
+	return rawMap.WriteCBOR(em, w)
+}
+
+func structToRawMap(
+	em cbor.EncMode,
+	source any,
+	unknown map[int]cbor.RawMessage,
+) (*structFieldsCBOR, error) {
 	rawMap := newStructFieldsCBOR()
 
 	structType := reflect.TypeOf(source)
@@ -25,7 +73,19 @@ func SerializeStructToCBOR(em cbor.EncMode, source any) ([]byte, error) {
 		return nil, err
 	}
 
-	return rawMap.ToCBOR(em)
+	keys := make([]int, 0, len(unknown))
+	for k := range unknown {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	for _, k := range keys {
+		if err := rawMap.Add(k, unknown[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	return rawMap, nil
 }
 
 func doSerializeStructToCBOR(
@@ -105,16 +165,32 @@ func doSerializeStructToCBOR(
 }
 
 func PopulateStructFromCBOR(dm cbor.DecMode, data []byte, dest any) error {
+	_, err := PopulateStructFromCBORWithUnknown(dm, data, dest)
+	return err
+}
+
+// PopulateStructFromCBORWithUnknown is like PopulateStructFromCBOR, but also
+// returns the map entries found in data that do not correspond to any field of
+// dest.
+func PopulateStructFromCBORWithUnknown(
+	dm cbor.DecMode,
+	data []byte,
+	dest any,
+) (map[int]cbor.RawMessage, error) {
 	rawMap := newStructFieldsCBOR()
 
 	if err := rawMap.FromCBOR(dm, data); err != nil {
-		return err
+		return nil, err
 	}
 
 	structType := reflect.TypeOf(dest)
 	structVal := reflect.ValueOf(dest)
 
-	return doPopulateStructFromCBOR(dm, rawMap, structType, structVal)
+	if err := doPopulateStructFromCBOR(dm, rawMap, structType, structVal); err != nil {
+		return nil, err
+	}
+
+	return rawMap.Fields, nil
 }
 
 func doPopulateStructFromCBOR(
@@ -245,41 +321,90 @@ func (o *structFieldsCBOR) Delete(key int) {
 }
 
 func (o *structFieldsCBOR) ToCBOR(em cbor.EncMode) ([]byte, error) {
-	var out []byte
+	var out bytes.Buffer
+
+	if err := o.WriteCBOR(em, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func (o *structFieldsCBOR) WriteCBOR(em cbor.EncMode, w io.Writer) error {
+	var head []byte
 
 	header := byte(0xa0) // 0b101_00000 -- Major Type 5 ==  map
 	mapLen := len(o.Keys)
-	if mapLen == 0 {
-		return []byte{header}, nil
-	} else if mapLen < 24 {
+	if mapLen < 24 {
 		header |= byte(mapLen)
-		out = append(out, header)
+		head = append(head, header)
 	} else if mapLen <= math.MaxUint8 {
 		header |= byte(24)
-		out = append(out, header, uint8(mapLen))
+		head = append(head, header, uint8(mapLen))
 	} else if mapLen <= math.MaxUint16 {
 		header |= byte(25)
-		out = append(out, header)
-		out = binary.BigEndian.AppendUint16(out, uint16(mapLen))
+		head = append(head, header)
+		head = binary.BigEndian.AppendUint16(head, uint16(mapLen))
 	} else if mapLen <= math.MaxUint32 {
 		header |= byte(26)
-		out = append(out, header)
-		out = binary.BigEndian.AppendUint32(out, uint32(mapLen))
+		head = append(head, header)
+		head = binary.BigEndian.AppendUint32(head, uint32(mapLen))
 	} else {
-		return nil, errors.New("mapLen cannot exceed math.MaxUint32")
+		return errors.New("mapLen cannot exceed math.MaxUint32")
 	}
 
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+
+	entries := make([]mapEntry, 0, len(o.Keys))
 	for _, key := range o.Keys {
 		marshalledKey, err := em.Marshal(key)
 		if err != nil {
-			return nil, fmt.Errorf("problem marshaling key %d: %w", key, err)
+			return fmt.Errorf("problem marshaling key %d: %w", key, err)
 		}
 
-		out = append(out, marshalledKey...)
-		out = append(out, o.Fields[key]...)
+		entries = append(entries, mapEntry{key: marshalledKey, val: o.Fields[key]})
 	}
 
-	return out, nil
+	sortMapEntries(em.EncOptions().Sort, entries)
+
+	for _, e := range entries {
+		if _, err := w.Write(e.key); err != nil {
+			return err
+		}
+
+		if _, err := w.Write(e.val); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapEntry is a map entry with its key already encoded
+type mapEntry struct {
+	key []byte
+	val []byte
+}
+
+// sortMapEntries orders entries as required by the supplied key sort mode,
+// comparing the encoded keys.  With cbor.SortNone the insertion order is kept.
+func sortMapEntries(mode cbor.SortMode, entries []mapEntry) {
+	switch mode {
+	case cbor.SortLengthFirst:
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i].key, entries[j].key
+			if len(a) != len(b) {
+				return len(a) < len(b)
+			}
+			return bytes.Compare(a, b) < 0
+		})
+	case cbor.SortBytewiseLexical:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+	}
 }
 
 func (o *structFieldsCBOR) FromCBOR(dm cbor.DecMode, data []byte) error {
@@ -300,6 +425,10 @@ func (o *structFieldsCBOR) FromCBOR(dm cbor.DecMode, data []byte) error {
 			return err
 		}
 
+		if len(rest) == 0 {
+			return errors.New("unexpected EOF")
+		}
+
 		header = rest[0]
 		rest = rest[1:]
 		majorType = (0xe0 & header) >> 5
@@ -317,8 +446,14 @@ func (o *structFieldsCBOR) FromCBOR(dm cbor.DecMode, data []byte) error {
 		return err
 	}
 
+	if mapLen < 0 {
+		return fmt.Errorf("cbor: invalid map length %d", mapLen)
+	}
+
 	if mapLen != 0 {
-		o.Fields = make(map[int]cbor.RawMessage, mapLen)
+		// each entry takes at least two bytes, so do not trust the
+		// declared length for pre-allocation
+		o.Fields = make(map[int]cbor.RawMessage, min(mapLen, len(rest)/2))
 
 		for i := 0; i < mapLen; i++ {
 			rest, err = o.unmarshalKeyValue(dm, rest)
@@ -3,6 +3,7 @@
 package encoding
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/fxamacker/cbor/v2"
@@ -258,6 +259,14 @@ func Test_structFieldsCBOR_CBOR_decode_negative(t *testing.T) {
 
 	err = sfOut.FromCBOR(dm, []byte{0x00})
 	assert.EqualError(t, err, `expected map (CBOR Major Type 5), found Major Type 0`)
+
+	// tag header with no content
+	err = sfOut.FromCBOR(dm, []byte{0xda, 0x30, 0x30, 0x30, 0x30})
+	assert.EqualError(t, err, `unexpected EOF`)
+
+	// declared length much larger than the input
+	err = sfOut.FromCBOR(dm, []byte{0xba, 0xff, 0xff, 0xff, 0xff, 0x00})
+	assert.ErrorContains(t, err, `map item 0: could not unmarshal value`)
 }
 
 func Test_processAdditionalInfo(t *testing.T) {
@@ -278,3 +287,119 @@ func Test_processAdditionalInfo(t *testing.T) {
 	_, _, err = processAdditionalInfo(addInfo, []byte{})
 	assert.EqualError(t, err, "unexpected EOF")
 }
+
+func Test_PopulateStructFromCBORWithUnknown(t *testing.T) {
+	type SimpleStruct struct {
+		FieldOne string `cbor:"0,keyasint,omitempty"`
+		FieldTwo int    `cbor:"1,keyasint"`
+	}
+
+	var v SimpleStruct
+
+	data := []byte{
+		0xa3, // map(3)
+
+		0x01, // key 1
+		0x06, // val 6
+
+		0x20,                   // key -1
+		0x64,                   // val tstr(4)
+		0x61, 0x63, 0x6d, 0x65, // "acme"
+
+		0x05, // key 5
+		0xf5, // val true
+	}
+
+	dm, err := cbor.DecOptions{}.DecMode()
+	require.NoError(t, err)
+
+	unknown, err := PopulateStructFromCBORWithUnknown(dm, data, &v)
+	require.NoError(t, err)
+	assert.Equal(t, 6, v.FieldTwo)
+	assert.Equal(t, map[int]cbor.RawMessage{
+		-1: {0x64, 0x61, 0x63, 0x6d, 0x65},
+		5:  {0xf5},
+	}, unknown)
+
+	em, err := cbor.EncOptions{}.EncMode()
+	require.NoError(t, err)
+
+	res, err := SerializeStructToCBORWithUnknown(em, &v, unknown)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xa3, // map(3)
+
+		0x01, // key 1
+		0x06, // val 6
+
+		0x20,                   // key -1
+		0x64,                   // val tstr(4)
+		0x61, 0x63, 0x6d, 0x65, // "acme"
+
+		0x05, // key 5
+		0xf5, // val true
+	}, res)
+
+	_, err = SerializeStructToCBORWithUnknown(em, &v, map[int]cbor.RawMessage{1: {0xf5}})
+	assert.EqualError(t, err, "duplicate cbor key: 1")
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStructToCBORWithUnknown(em, &buf, &v, unknown))
+	assert.Equal(t, res, buf.Bytes())
+}
+
+func Test_SerializeStructToCBORWithUnknown_deterministic(t *testing.T) {
+	type SimpleStruct struct {
+		FieldOne string `cbor:"-1,keyasint,omitempty"`
+		FieldTwo int    `cbor:"1,keyasint"`
+	}
+
+	v := SimpleStruct{FieldOne: "a", FieldTwo: 2}
+	unknown := map[int]cbor.RawMessage{
+		-10: {0xf5},
+		6:   {0xf4},
+	}
+
+	em, err := cbor.CoreDetEncOptions().EncMode()
+	require.NoError(t, err)
+
+	expected := []byte{
+		0xa4, // map(4)
+
+		0x01, // key 1
+		0x02, // val 2
+
+		0x06, // key 6
+		0xf4, // val false
+
+		0x20,       // key -1
+		0x61, 0x61, // val "a"
+
+		0x29, // key -10
+		0xf5, // val true
+	}
+
+	res, err := SerializeStructToCBORWithUnknown(em, &v, unknown)
+	require.NoError(t, err)
+	assert.Equal(t, expected, res)
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStructToCBORWithUnknown(em, &buf, &v, unknown))
+	assert.Equal(t, expected, buf.Bytes())
+
+	em, err = cbor.CanonicalEncOptions().EncMode()
+	require.NoError(t, err)
+
+	res, err = SerializeStructToCBORWithUnknown(em, &v, unknown)
+	require.NoError(t, err)
+	assert.Equal(t, expected, res) // all keys encode in one byte
+
+	// without sorting, struct fields come first, then the unknown entries in
+	// ascending key order
+	em, err = cbor.EncOptions{}.EncMode()
+	require.NoError(t, err)
+
+	res, err = SerializeStructToCBORWithUnknown(em, &v, unknown)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xa4, 0x20, 0x61, 0x61, 0x01, 0x02, 0x29, 0xf5, 0x06, 0xf4}, res)
+}